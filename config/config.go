@@ -0,0 +1,70 @@
+package config
+
+import (
+	"flag"
+	"log"
+	"os"
+	"time"
+)
+
+// Config holds the settings the server needs at startup. Every value can be
+// set with a command-line flag and falls back to an environment variable.
+type Config struct {
+	Port     string
+	DBDriver string // mongo, postgres, sqlite or memory
+	DBURL    string // empty selects the driver default; a file path for sqlite
+	DBName   string // MongoDB database name; ignored by the SQL backends
+
+	// JWTKeysFile names a JSON file listing the token signing keys; see
+	// middleware.LoadKeySet. JWTSecret is a shortcut for a single HS256 key.
+	JWTKeysFile string
+	JWTSecret   string
+
+	AccessTokenTTL  time.Duration
+	RefreshTokenTTL time.Duration
+
+	// PurgeRetention is how long soft deleted records are kept before an
+	// admin purge removes them.
+	PurgeRetention time.Duration
+
+	// AdminEmail and AdminPassword, when both set, make sure an admin
+	// account exists at startup.
+	AdminEmail    string
+	AdminPassword string
+}
+
+// Load parses the command-line flags, using environment variables and then
+// the built-in defaults for anything not given explicitly.
+func Load() *Config {
+	cfg := &Config{}
+	flag.StringVar(&cfg.Port, "port", getEnv("PORT", "8080"), "HTTP listen port")
+	flag.StringVar(&cfg.DBDriver, "db-driver", getEnv("DB_DRIVER", "mongo"), "storage backend: mongo, postgres, sqlite or memory")
+	flag.StringVar(&cfg.DBURL, "db-url", getEnv("DB_URL", ""), "database connection string, or the database file for sqlite")
+	flag.StringVar(&cfg.DBName, "db-name", getEnv("DB_NAME", "db"), "MongoDB database name")
+	flag.StringVar(&cfg.JWTKeysFile, "jwt-keys-file", getEnv("JWT_KEYS_FILE", ""), "JSON file listing the JWT signing keys")
+	flag.StringVar(&cfg.JWTSecret, "jwt-secret", getEnv("JWT_SECRET", ""), "HS256 secret used when no keys file is given")
+	flag.DurationVar(&cfg.AccessTokenTTL, "access-token-ttl", getEnvDuration("ACCESS_TOKEN_TTL", 15*time.Minute), "lifetime of access tokens")
+	flag.DurationVar(&cfg.RefreshTokenTTL, "refresh-token-ttl", getEnvDuration("REFRESH_TOKEN_TTL", 7*24*time.Hour), "lifetime of refresh tokens")
+	flag.DurationVar(&cfg.PurgeRetention, "purge-retention", getEnvDuration("PURGE_RETENTION", 30*24*time.Hour), "how long deleted records are kept before they can be purged")
+	flag.StringVar(&cfg.AdminEmail, "admin-email", getEnv("ADMIN_EMAIL", ""), "email of the bootstrap admin account")
+	flag.StringVar(&cfg.AdminPassword, "admin-password", getEnv("ADMIN_PASSWORD", ""), "password for a newly created bootstrap admin")
+	flag.Parse()
+	return cfg
+}
+
+func getEnv(key, fallback string) string {
+	if value, ok := os.LookupEnv(key); ok {
+		return value
+	}
+	return fallback
+}
+
+func getEnvDuration(key string, fallback time.Duration) time.Duration {
+	if value, ok := os.LookupEnv(key); ok {
+		if d, err := time.ParseDuration(value); err == nil {
+			return d
+		}
+		log.Printf("Ignoring invalid %s=%q", key, value)
+	}
+	return fallback
+}
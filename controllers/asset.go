@@ -4,12 +4,13 @@ import (
 	"employee-asset-system/db"
 	"employee-asset-system/models"
 	"encoding/json"
+	"errors"
 	"net/http"
+	"strconv"
 	"time"
 
 	"github.com/google/uuid"
 	"github.com/gorilla/mux"
-	"go.mongodb.org/mongo-driver/bson"
 )
 
 // CreateAsset godoc
@@ -18,93 +19,236 @@ import (
 // @Tags Assets
 // @Accept json
 // @Produce json
-// @Param asset body models.Asset true "Asset data"
+// @Param asset body models.AssetPatch true "Asset data"
 // @Success 201 {object} map[string]string
+// @Header 201 {string} ETag "Version of the asset"
 // @Failure 400 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Failure 422 {object} ValidationErrorResponse
 // @Failure 500 {object} map[string]string
 // @Router /assets [post]
-func CreateAsset(w http.ResponseWriter, r *http.Request) {
-	var asset models.Asset
-	if err := json.NewDecoder(r.Body).Decode(&asset); err != nil {
-		http.Error(w, "Invalid input", http.StatusBadRequest)
+func (s *Server) CreateAsset(w http.ResponseWriter, r *http.Request) {
+	var patch models.AssetPatch
+	if !readPatch(w, r, nil, &patch, func() models.ValidationErrors { return patch.Validate(true) }) {
 		return
 	}
 
+	var asset models.Asset
+	patch.Apply(&asset)
+
 	asset.AssetID = uuid.New().String()
 	asset.CreatedAt = time.Now()
 	asset.UpdatedAt = time.Now()
+	asset.CreatedBy = actorID(r)
+	asset.UpdatedBy = asset.CreatedBy
+	if !s.checkSerialNumber(w, r, &asset) {
+		return
+	}
 
-	_, err := db.Database.Collection("asset").InsertOne(r.Context(), asset)
-	if err != nil {
-		http.Error(w, "Failed to create asset", http.StatusInternalServerError)
+	if err := s.Assets.CreateAsset(r.Context(), &asset); err != nil {
+		writeStoreError(w, err, "", "Failed to create asset")
 		return
 	}
+	after := auditSnapshot(&asset)
+	s.audit(r, models.AuditActionCreate, models.AuditEntityAsset, asset.AssetID, nil, after)
+	s.recordVersion(r, models.AuditEntityAsset, asset.AssetID, models.AuditActionCreate, nil, after, 0)
 
+	w.Header().Set("ETag", etag(asset.Version))
 	w.WriteHeader(http.StatusCreated)
 	json.NewEncoder(w).Encode(map[string]string{"message": "Asset created successfully"})
 }
 
 // EditAsset godoc
-// @Summary Edit an asset's details
-// @Description Updates an asset's information by ID
+// @Summary Replace an asset's details
+// @Description Replaces every editable field of an asset. Fields missing from the body are cleared. Only the fields of AssetPatch may be set; any other key is rejected.
 // @Tags Assets
 // @Accept json
 // @Produce json
 // @Param assetId path string true "Asset ID"
-// @Param data body map[string]interface{} true "Updated data"
+// @Param data body models.AssetPatch true "Asset data"
+// @Param If-Match header string false "ETag the client last read; the write is refused with 412 when the record has changed since"
 // @Success 200 {object} map[string]string
+// @Header 200 {string} ETag "Version of the asset"
 // @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 412 {object} map[string]string
+// @Failure 422 {object} ValidationErrorResponse
 // @Failure 500 {object} map[string]string
-// @Router /assets/{assetId} [put]
-func EditAsset(w http.ResponseWriter, r *http.Request) {
+// @Router /asset/editasset/{assetId} [put]
+func (s *Server) EditAsset(w http.ResponseWriter, r *http.Request) {
+	s.updateAsset(w, r)
+}
+
+// PatchAsset godoc
+// @Summary Partially update an asset
+// @Description Applies a JSON Merge Patch (RFC 7386) or JSON Patch (RFC 6902) to the editable fields of an asset, chosen by Content-Type. Removing a field clears it. A failed test operation is rejected with 409.
+// @Tags Assets
+// @Accept application/merge-patch+json,application/json-patch+json
+// @Produce json
+// @Param assetId path string true "Asset ID"
+// @Param patch body object true "Merge patch object or JSON Patch operation array"
+// @Param If-Match header string false "ETag the client last read; the write is refused with 412 when the record has changed since"
+// @Success 200 {object} map[string]string
+// @Header 200 {string} ETag "Version of the asset"
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Failure 412 {object} map[string]string
+// @Failure 415 {object} map[string]string
+// @Failure 422 {object} ValidationErrorResponse
+// @Failure 500 {object} map[string]string
+// @Router /asset/editasset/{assetId} [patch]
+func (s *Server) PatchAsset(w http.ResponseWriter, r *http.Request) {
+	s.updateAsset(w, r)
+}
+
+// updateAsset replaces the editable fields of an asset with the body of a
+// PUT request or the result of applying the body of a PATCH request.
+func (s *Server) updateAsset(w http.ResponseWriter, r *http.Request) {
 	assetID := mux.Vars(r)["assetId"]
 
-	var updatedData bson.M
-	if err := json.NewDecoder(r.Body).Decode(&updatedData); err != nil {
-		http.Error(w, "Invalid input", http.StatusBadRequest)
+	asset, err := s.Assets.GetAsset(r.Context(), assetID)
+	if err != nil {
+		writeStoreError(w, err, "Asset not found", "Failed to update asset")
+		return
+	}
+	if !checkIfMatch(w, r, asset.Version) {
+		return
+	}
+	before := auditSnapshot(asset)
+
+	var fields models.AssetPatch
+	current := models.NewAssetPatch(asset)
+	if !readPatch(w, r, &current, &fields, func() models.ValidationErrors { return fields.Validate(true) }) {
+		return
+	}
+	fields.Replace(asset)
+	if !s.checkSerialNumber(w, r, asset) {
 		return
 	}
 
-	updatedData["updated_at"] = time.Now()
-	filter := bson.M{"asset_id": assetID}
-	update := bson.M{"$set": updatedData}
+	asset.UpdatedAt = time.Now()
+	asset.UpdatedBy = actorID(r)
 
-	_, err := db.Database.Collection("asset").UpdateOne(r.Context(), filter, update)
-	if err != nil {
-		http.Error(w, "Failed to update asset", http.StatusInternalServerError)
+	if err := s.Assets.UpdateAsset(r.Context(), asset); err != nil {
+		writeWriteError(w, r, err, "Asset not found", "Failed to update asset")
 		return
 	}
+	after := auditSnapshot(asset)
+	s.audit(r, models.AuditActionUpdate, models.AuditEntityAsset, assetID, before, after)
+	s.recordVersion(r, models.AuditEntityAsset, assetID, models.AuditActionUpdate, before, after, 0)
 
+	w.Header().Set("ETag", etag(asset.Version))
 	w.WriteHeader(http.StatusOK)
 	json.NewEncoder(w).Encode(map[string]string{"message": "Asset updated successfully"})
 }
 
+// AssetPage is one page of GetAllAssets results.
+type AssetPage struct {
+	Assets        []models.Asset `json:"assets"`
+	NextPageToken string         `json:"next_page_token,omitempty"` // empty on the last page
+	Total         int64          `json:"total"`
+}
+
 // GetAllAssets godoc
 // @Summary Get all assets
-// @Description Fetches all assets from the database
+// @Description Lists assets a page at a time. Pass the next_page_token of a page as page_token, with the same sort, to fetch the one after it.
 // @Tags Assets
 // @Produce json
-// @Success 200 {array} models.Asset
+// @Param asset_type query string false "Only assets of this type"
+// @Param name query string false "Only assets whose name starts with this, ignoring case"
+// @Param shared query bool false "Only shared or only exclusive assets"
+// @Param created_after query string false "Only assets created after this RFC 3339 time"
+// @Param created_before query string false "Only assets created before this RFC 3339 time"
+// @Param serial_number query string false "Only the asset with this serial number"
+// @Param manufacturer query string false "Only assets of this manufacturer, ignoring case"
+// @Param model query string false "Only assets of this model, ignoring case"
+// @Param vendor query string false "Only assets bought from this vendor, ignoring case"
+// @Param invoice_number query string false "Only assets on this invoice"
+// @Param purchased_after query string false "Only assets purchased after this date or RFC 3339 time"
+// @Param purchased_before query string false "Only assets purchased before this date or RFC 3339 time"
+// @Param warranty_expires_after query string false "Only assets whose warranty expires after this date or RFC 3339 time"
+// @Param warranty_expires_before query string false "Only assets whose warranty expires before this date or RFC 3339 time"
+// @Param sort query string false "Sort field (created_at, updated_at, asset_name, asset_type, asset_id), prefixed with - for descending order"
+// @Param page_size query int false "Assets per page (default 50, at most 200)"
+// @Param page_token query string false "Token of the page to fetch"
+// @Success 200 {object} AssetPage
+// @Failure 400 {object} map[string]string
 // @Failure 500 {object} map[string]string
 // @Router /assets [get]
-func GetAllAssets(w http.ResponseWriter, r *http.Request) {
-	cursor, err := db.Database.Collection("asset").Find(r.Context(), bson.M{})
+func (s *Server) GetAllAssets(w http.ResponseWriter, r *http.Request) {
+	q, ok := readAssetQuery(w, r)
+	if !ok {
+		return
+	}
+
+	assets, total, err := s.Assets.ListAssets(r.Context(), q)
 	if err != nil {
-		http.Error(w, "Failed to fetch assets", http.StatusInternalServerError)
+		writeListError(w, err, "Failed to fetch assets")
 		return
 	}
-	defer cursor.Close(r.Context())
+	page := AssetPage{Assets: assets, Total: total}
+	if pageSize := q.Limit - 1; len(assets) > pageSize {
+		page.Assets = assets[:pageSize]
+		page.NextPageToken = nextPageToken(q.ListOptions, db.AssetCursor(&assets[pageSize-1], q.Sort))
+	}
+	if page.Assets == nil {
+		page.Assets = []models.Asset{}
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(page)
+}
 
-	var assets []models.Asset
-	for cursor.Next(r.Context()) {
-		var asset models.Asset
-		if err := cursor.Decode(&asset); err == nil {
-			assets = append(assets, asset)
+// readAssetQuery parses the filters, sort and page of an asset listing. It
+// writes a 400 reply and returns false when one of them is invalid.
+func readAssetQuery(w http.ResponseWriter, r *http.Request) (db.AssetQuery, bool) {
+	query := r.URL.Query()
+	q := db.AssetQuery{
+		AssetType:  query.Get("asset_type"),
+		NamePrefix: query.Get("name"),
+	}
+	if value := query.Get("shared"); value != "" {
+		shared, err := strconv.ParseBool(value)
+		if err != nil {
+			http.Error(w, "Invalid shared", http.StatusBadRequest)
+			return q, false
 		}
+		q.Shared = &shared
 	}
+	q.SerialNumber = query.Get("serial_number")
+	q.Manufacturer = query.Get("manufacturer")
+	q.Model = query.Get("model")
+	q.Vendor = query.Get("vendor")
+	q.InvoiceNumber = query.Get("invoice_number")
+	ok := readTimeParam(w, r, "created_after", &q.CreatedAfter) &&
+		readTimeParam(w, r, "created_before", &q.CreatedBefore) &&
+		readTimeParam(w, r, "purchased_after", &q.PurchasedAfter) &&
+		readTimeParam(w, r, "purchased_before", &q.PurchasedBefore) &&
+		readTimeParam(w, r, "warranty_expires_after", &q.WarrantyExpiresAfter) &&
+		readTimeParam(w, r, "warranty_expires_before", &q.WarrantyExpiresBefore) &&
+		readListOptions(w, r, db.AssetSortFields, &q.ListOptions)
+	return q, ok
+}
 
-	w.WriteHeader(http.StatusOK)
-	json.NewEncoder(w).Encode(assets)
+// checkSerialNumber replies 422 and returns false when the serial number of
+// asset is already used by another asset, deleted ones included.
+func (s *Server) checkSerialNumber(w http.ResponseWriter, r *http.Request, asset *models.Asset) bool {
+	if asset.SerialNumber == "" {
+		return true
+	}
+	existing, err := s.Assets.AssetsBySerial(r.Context(), []string{asset.SerialNumber})
+	if err != nil {
+		http.Error(w, "Failed to look up serial number", http.StatusInternalServerError)
+		return false
+	}
+	for _, other := range existing {
+		if other.AssetID != asset.AssetID {
+			writeValidationErrors(w, models.ValidationErrors{{Field: "serial_number", Message: "is already used by asset " + other.AssetID}})
+			return false
+		}
+	}
+	return true
 }
 
 // GetAssetById godoc
@@ -113,16 +257,21 @@ func GetAllAssets(w http.ResponseWriter, r *http.Request) {
 // @Tags Assets
 // @Produce json
 // @Param assetId path string true "Asset ID"
+// @Param If-None-Match header string false "ETag of the copy the client holds"
 // @Success 200 {object} models.Asset
+// @Success 304 "Not modified"
+// @Header 200 {string} ETag "Version of the asset"
 // @Failure 404 {object} map[string]string
 // @Router /assets/{assetId} [get]
-func GetAssetById(w http.ResponseWriter, r *http.Request) {
+func (s *Server) GetAssetById(w http.ResponseWriter, r *http.Request) {
 	assetID := mux.Vars(r)["assetId"]
 
-	var asset models.Asset
-	err := db.Database.Collection("asset").FindOne(r.Context(), bson.M{"asset_id": assetID}).Decode(&asset)
+	asset, err := s.Assets.GetAsset(r.Context(), assetID)
 	if err != nil {
-		http.Error(w, "Asset not found", http.StatusNotFound)
+		writeStoreError(w, err, "Asset not found", "Failed to fetch asset")
+		return
+	}
+	if !checkIfNoneMatch(w, r, asset.Version) {
 		return
 	}
 
@@ -132,23 +281,86 @@ func GetAssetById(w http.ResponseWriter, r *http.Request) {
 
 // DeleteAsset godoc
 // @Summary Delete an asset
-// @Description Deletes an asset from the database by ID
+// @Description Soft deletes an asset by ID. It can be restored until it is purged. The delete is refused while the asset has active asset mappings unless cascade is set, which returns them first with the given reason. Closed mappings are kept as history.
 // @Tags Assets
 // @Produce json
 // @Param assetId path string true "Asset ID"
+// @Param cascade query bool false "Return the asset's active mappings instead of refusing the delete"
+// @Param reason query string false "Reason recorded on mappings closed by cascade"
+// @Param If-Match header string false "ETag the client last read; the write is refused with 412 when the record has changed since"
 // @Success 200 {object} map[string]string
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Failure 412 {object} map[string]string
 // @Failure 500 {object} map[string]string
 // @Router /assets/{assetId} [delete]
-func DeleteAsset(w http.ResponseWriter, r *http.Request) {
+func (s *Server) DeleteAsset(w http.ResponseWriter, r *http.Request) {
 	assetID := mux.Vars(r)["assetId"]
 
-	filter := bson.M{"asset_id": assetID}
-	_, err := db.Database.Collection("asset").DeleteOne(r.Context(), filter)
+	cascade, err := cascadeReturn(r, "asset deleted")
 	if err != nil {
-		http.Error(w, "Failed to delete asset", http.StatusInternalServerError)
+		http.Error(w, "Invalid cascade parameter", http.StatusBadRequest)
+		return
+	}
+
+	// Remember what a cascade is about to close so it can be audited.
+	var closed []models.EmployeeAssetMapping
+	if cascade != nil {
+		closed, err = s.Mappings.ListMappingsByAsset(r.Context(), assetID, models.MappingStatusActive)
+		if err != nil {
+			http.Error(w, "Failed to delete asset", http.StatusInternalServerError)
+			return
+		}
+	}
+
+	deletion := models.Deletion{DeletedAt: time.Now(), DeletedBy: actorID(r), Cascade: cascade}
+	if r.Header.Get("If-Match") != "" {
+		current, err := s.Assets.GetAsset(r.Context(), assetID)
+		if err != nil {
+			writeStoreError(w, err, "Asset not found", "Failed to delete asset")
+			return
+		}
+		if !checkIfMatch(w, r, current.Version) {
+			return
+		}
+		deletion.IfVersion = current.Version
+	}
+	if err := s.Assets.DeleteAsset(r.Context(), assetID, deletion); err != nil {
+		if errors.Is(err, db.ErrActiveMappings) {
+			http.Error(w, "Asset is still assigned; return it first or delete with cascade=true", http.StatusConflict)
+			return
+		}
+		writeWriteError(w, r, err, "Asset not found", "Failed to delete asset")
 		return
 	}
+	s.auditCascade(r, closed)
+	s.audit(r, models.AuditActionDelete, models.AuditEntityAsset, assetID,
+		auditSnapshot(tombstone{}), auditSnapshot(tombstone{&deletion.DeletedAt, deletion.DeletedBy}))
 
 	w.WriteHeader(http.StatusOK)
 	json.NewEncoder(w).Encode(map[string]string{"message": "Asset deleted successfully"})
 }
+
+// RestoreAsset godoc
+// @Summary Restore a deleted asset
+// @Description Undoes the soft delete of an asset. Mappings closed when it was deleted stay closed.
+// @Tags Assets
+// @Produce json
+// @Param assetId path string true "Asset ID"
+// @Success 200 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /asset/restoreasset/{assetId} [post]
+func (s *Server) RestoreAsset(w http.ResponseWriter, r *http.Request) {
+	assetID := mux.Vars(r)["assetId"]
+
+	if err := s.Assets.RestoreAsset(r.Context(), assetID); err != nil {
+		writeStoreError(w, err, "Deleted asset not found", "Failed to restore asset")
+		return
+	}
+	s.audit(r, models.AuditActionRestore, models.AuditEntityAsset, assetID, nil, nil)
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]string{"message": "Asset restored successfully"})
+}
@@ -0,0 +1,194 @@
+package controllers
+
+import (
+	"employee-asset-system/db"
+	"employee-asset-system/middleware"
+	"employee-asset-system/models"
+	"encoding/json"
+	"log"
+	"net"
+	"net/http"
+	"reflect"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Audit log paging limits.
+const (
+	defaultAuditPageSize = 50
+	maxAuditPageSize     = 200
+)
+
+// redactedFields are recorded as changed without their values.
+var redactedFields = map[string]bool{"password": true}
+
+// auditSnapshot captures v as a JSON object so that it can be diffed later,
+// even if v itself is modified in between.
+func auditSnapshot(v interface{}) map[string]interface{} {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil
+	}
+	var snapshot map[string]interface{}
+	json.Unmarshal(data, &snapshot)
+	delete(snapshot, "id") // MongoDB's internal _id
+	return snapshot
+}
+
+// auditDiff lists the fields whose values differ between two snapshots.
+func auditDiff(before, after map[string]interface{}) map[string]models.FieldChange {
+	changes := make(map[string]models.FieldChange)
+	for field, value := range after {
+		if old, ok := before[field]; !ok || !reflect.DeepEqual(old, value) {
+			changes[field] = models.FieldChange{Before: before[field], After: value}
+		}
+	}
+	for field, old := range before {
+		if _, ok := after[field]; !ok {
+			changes[field] = models.FieldChange{Before: old}
+		}
+	}
+	for field, change := range changes {
+		if redactedFields[field] {
+			changes[field] = models.FieldChange{Before: redact(change.Before), After: redact(change.After)}
+		}
+	}
+	return changes
+}
+
+func redact(value interface{}) interface{} {
+	if value == nil {
+		return nil
+	}
+	return "[redacted]"
+}
+
+// sourceIP returns the address the request came from. Forwarding headers are
+// not trusted as they can be set by any client.
+func sourceIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+// audit appends an entry for a change made by r. before and after are
+// snapshots from auditSnapshot; either may be nil. The change has already
+// been made, so a failure to record it is logged rather than returned.
+func (s *Server) audit(r *http.Request, action, entityType, entityID string, before, after map[string]interface{}) {
+	entry := &models.AuditEntry{
+		EntryID:    uuid.New().String(),
+		Timestamp:  time.Now(),
+		ActorID:    actorID(r),
+		Action:     action,
+		EntityType: entityType,
+		EntityID:   entityID,
+		Changes:    auditDiff(before, after),
+		RequestID:  middleware.RequestIDFromContext(r.Context()),
+		SourceIP:   sourceIP(r),
+	}
+	if err := s.Audit.AppendAudit(r.Context(), entry); err != nil {
+		log.Printf("Failed to write audit entry for %s %s %s (request %s): %v",
+			action, entityType, entityID, entry.RequestID, err)
+	}
+}
+
+// tombstone is the part of a record a soft delete changes.
+type tombstone struct {
+	DeletedAt *time.Time `json:"deleted_at"`
+	DeletedBy string     `json:"deleted_by"`
+}
+
+// auditCascade records the closing of mappings that were active before a
+// cascading delete, reading back how the delete left them.
+func (s *Server) auditCascade(r *http.Request, closed []models.EmployeeAssetMapping) {
+	for i := range closed {
+		before := auditSnapshot(&closed[i])
+		after, err := s.Mappings.GetMapping(r.Context(), closed[i].MappingID)
+		if err != nil {
+			log.Printf("Failed to read mapping %s closed by cascade: %v", closed[i].MappingID, err)
+			continue
+		}
+		s.audit(r, models.AuditActionReturn, models.AuditEntityMapping, after.MappingID, before, auditSnapshot(after))
+	}
+}
+
+// AuditPage is one page of GetAuditLog results.
+type AuditPage struct {
+	Entries  []models.AuditEntry `json:"entries"`
+	Page     int                 `json:"page"`
+	PageSize int                 `json:"page_size"`
+	Total    int64               `json:"total"`
+}
+
+// GetAuditLog godoc
+// @Summary List audit log entries
+// @Description Lists the audit trail of every change made through the API, newest first
+// @Tags Audit
+// @Produce json
+// @Param actor query string false "Only changes made by this employee ID"
+// @Param action query string false "Only this action (create, update, delete, restore, purge, assign, return)"
+// @Param entity_type query string false "Only this entity type (employee, asset, mapping)"
+// @Param entity_id query string false "Only changes to this entity"
+// @Param from query string false "Only changes at or after this RFC 3339 time"
+// @Param to query string false "Only changes at or before this RFC 3339 time"
+// @Param page query int false "Page number, starting at 1"
+// @Param page_size query int false "Entries per page (default 50, at most 200)"
+// @Success 200 {object} AuditPage
+// @Failure 400 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /audit [get]
+func (s *Server) GetAuditLog(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	q := db.AuditQuery{
+		ActorID:    query.Get("actor"),
+		Action:     query.Get("action"),
+		EntityType: query.Get("entity_type"),
+		EntityID:   query.Get("entity_id"),
+	}
+
+	var err error
+	if from := query.Get("from"); from != "" {
+		if q.From, err = time.Parse(time.RFC3339, from); err != nil {
+			http.Error(w, "Invalid from time", http.StatusBadRequest)
+			return
+		}
+	}
+	if to := query.Get("to"); to != "" {
+		if q.To, err = time.Parse(time.RFC3339, to); err != nil {
+			http.Error(w, "Invalid to time", http.StatusBadRequest)
+			return
+		}
+	}
+
+	page, pageSize := 1, defaultAuditPageSize
+	if value := query.Get("page"); value != "" {
+		if page, err = strconv.Atoi(value); err != nil || page < 1 {
+			http.Error(w, "Invalid page", http.StatusBadRequest)
+			return
+		}
+	}
+	if value := query.Get("page_size"); value != "" {
+		if pageSize, err = strconv.Atoi(value); err != nil || pageSize < 1 || pageSize > maxAuditPageSize {
+			http.Error(w, "Invalid page_size", http.StatusBadRequest)
+			return
+		}
+	}
+	q.Offset = (page - 1) * pageSize
+	q.Limit = pageSize
+
+	entries, total, err := s.Audit.ListAudit(r.Context(), q)
+	if err != nil {
+		http.Error(w, "Failed to fetch audit log", http.StatusInternalServerError)
+		return
+	}
+	if entries == nil {
+		entries = []models.AuditEntry{}
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(AuditPage{Entries: entries, Page: page, PageSize: pageSize, Total: total})
+}
@@ -1,25 +1,34 @@
 package controllers
 
 import (
+	"context"
 	"employee-asset-system/db"
+	"employee-asset-system/middleware"
+	"employee-asset-system/models"
 	"employee-asset-system/utils"
 	"encoding/json"
+	"errors"
+	"log"
 	"net/http"
 	"time"
 
 	"github.com/golang-jwt/jwt/v4"
-	"go.mongodb.org/mongo-driver/bson"
+	"github.com/google/uuid"
 )
 
-var jwtKey = []byte("your_secret_key")
-
 type LoginRequest struct {
 	Identifier string `json:"identifier"`
 	Password   string `json:"password"`
 }
 
 type LoginResponse struct {
-	Token string `json:"token"`
+	Token        string `json:"token"`
+	RefreshToken string `json:"refresh_token"`
+	ExpiresIn    int    `json:"expires_in"` // access token lifetime in seconds
+}
+
+type RefreshRequest struct {
+	RefreshToken string `json:"refresh_token"`
 }
 
 // @Summary Login for employees
@@ -31,38 +40,188 @@ type LoginResponse struct {
 // @Success 200 {object} LoginResponse
 // @Failure 401 {object} map[string]string
 // @Router /login/auth [post]
-func Login(w http.ResponseWriter, r *http.Request) {
+func (s *Server) Login(w http.ResponseWriter, r *http.Request) {
 	var req LoginRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 		http.Error(w, "Invalid request", http.StatusBadRequest)
 		return
 	}
 
-	collection := db.Database.Collection("employee")
-	var employee bson.M
-	err := collection.FindOne(r.Context(), bson.M{
-		"$or": []bson.M{
-			{"phone_number": req.Identifier},
-			{"employee_email": req.Identifier},
-		},
-	}).Decode(&employee)
-
-	if err != nil || !utils.CheckPassword(req.Password, employee["password"].(string)) {
+	employee, err := s.Employees.FindEmployeeByIdentifier(r.Context(), req.Identifier)
+	if err != nil || !utils.CheckPassword(req.Password, employee.Password) {
 		http.Error(w, "Invalid credentials", http.StatusUnauthorized)
 		return
 	}
 
-	token := GenerateJWT(employee["emp_id"].(string))
-	json.NewEncoder(w).Encode(LoginResponse{Token: token})
+	// Every login starts a new token family.
+	resp, err := s.issueTokens(r.Context(), employee, uuid.New().String())
+	if err != nil {
+		http.Error(w, "Failed to issue tokens", http.StatusInternalServerError)
+		return
+	}
+	json.NewEncoder(w).Encode(resp)
+}
+
+// Refresh godoc
+// @Summary Exchange a refresh token
+// @Description Rotates a refresh token, returning a new access token and refresh token. Presenting an already used refresh token revokes every token of its login session.
+// @Tags Login
+// @Accept  json
+// @Produce  json
+// @Param   request body RefreshRequest true "Refresh token"
+// @Success 200 {object} LoginResponse
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Router /login/refresh [post]
+func (s *Server) Refresh(w http.ResponseWriter, r *http.Request) {
+	var req RefreshRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.RefreshToken == "" {
+		http.Error(w, "Invalid request", http.StatusBadRequest)
+		return
+	}
+
+	now := time.Now()
+	token, err := s.Tokens.UseRefreshToken(r.Context(), utils.HashToken(req.RefreshToken), now)
+	if errors.Is(err, db.ErrTokenReused) {
+		// A rotated token came back: either the client or an attacker holds
+		// a stale copy. Revoke the whole family so neither can continue.
+		log.Printf("Refresh token reuse detected for employee %s, revoking session %s", token.EmpID, token.FamilyID)
+		if err := s.Tokens.RevokeTokenFamily(r.Context(), token.FamilyID, now); err != nil {
+			http.Error(w, "Failed to revoke session", http.StatusInternalServerError)
+			return
+		}
+		http.Error(w, "Invalid refresh token", http.StatusUnauthorized)
+		return
+	}
+	if errors.Is(err, db.ErrNotFound) {
+		http.Error(w, "Invalid refresh token", http.StatusUnauthorized)
+		return
+	}
+	if err != nil {
+		http.Error(w, "Failed to refresh token", http.StatusInternalServerError)
+		return
+	}
+	if token.RevokedAt != nil || now.After(token.ExpiresAt) {
+		http.Error(w, "Invalid refresh token", http.StatusUnauthorized)
+		return
+	}
+
+	// Reload the employee so role changes take effect on the next refresh.
+	employee, err := s.Employees.GetEmployee(r.Context(), token.EmpID)
+	if err != nil {
+		http.Error(w, "Invalid refresh token", http.StatusUnauthorized)
+		return
+	}
+
+	resp, err := s.issueTokens(r.Context(), employee, token.FamilyID)
+	if err != nil {
+		http.Error(w, "Failed to issue tokens", http.StatusInternalServerError)
+		return
+	}
+	json.NewEncoder(w).Encode(resp)
+}
+
+// Logout godoc
+// @Summary Log out
+// @Description Revokes the login session the refresh token belongs to. Access tokens issued for the session stop working immediately.
+// @Tags Login
+// @Accept  json
+// @Produce  json
+// @Param   request body RefreshRequest true "Refresh token"
+// @Success 200 {object} map[string]string
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Router /login/logout [post]
+func (s *Server) Logout(w http.ResponseWriter, r *http.Request) {
+	var req RefreshRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.RefreshToken == "" {
+		http.Error(w, "Invalid request", http.StatusBadRequest)
+		return
+	}
+
+	// Using the token both looks it up and makes sure it cannot be
+	// exchanged afterwards; a reused token still names its family.
+	now := time.Now()
+	token, err := s.Tokens.UseRefreshToken(r.Context(), utils.HashToken(req.RefreshToken), now)
+	if errors.Is(err, db.ErrNotFound) {
+		http.Error(w, "Invalid refresh token", http.StatusUnauthorized)
+		return
+	}
+	if err != nil && !errors.Is(err, db.ErrTokenReused) {
+		http.Error(w, "Failed to log out", http.StatusInternalServerError)
+		return
+	}
+	if err := s.Tokens.RevokeTokenFamily(r.Context(), token.FamilyID, now); err != nil {
+		http.Error(w, "Failed to log out", http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]string{"message": "Logged out successfully"})
 }
 
-func GenerateJWT(empId string) string {
-	claims := &jwt.RegisteredClaims{
-		ExpiresAt: jwt.NewNumericDate(time.Now().Add(24 * time.Hour)),
-		Subject:   empId,
+// issueTokens signs a new access token for employee and stores a fresh
+// refresh token in the given family.
+func (s *Server) issueTokens(ctx context.Context, employee *models.Employee, familyID string) (*LoginResponse, error) {
+	roles := employee.Roles
+	if len(roles) == 0 {
+		roles = []string{models.RoleEmployee}
+	}
+
+	refreshToken, err := utils.GenerateToken()
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	err = s.Tokens.CreateRefreshToken(ctx, &models.RefreshToken{
+		TokenHash: utils.HashToken(refreshToken),
+		FamilyID:  familyID,
+		EmpID:     employee.EmpID,
+		CreatedAt: now,
+		ExpiresAt: now.Add(s.RefreshTokenTTL),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	accessToken, err := s.generateJWT(employee.EmpID, roles, familyID)
+	if err != nil {
+		return nil, err
 	}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	tokenString, _ := token.SignedString(jwtKey)
-	return tokenString
+	return &LoginResponse{
+		Token:        accessToken,
+		RefreshToken: refreshToken,
+		ExpiresIn:    int(s.AccessTokenTTL / time.Second),
+	}, nil
+}
+
+// generateJWT signs an access token for empId with the active key. sessionID
+// ties the token to its refresh token family so that revoking the family
+// also invalidates the access token.
+func (s *Server) generateJWT(empId string, roles []string, sessionID string) (string, error) {
+	claims := &middleware.Claims{
+		Roles:     roles,
+		SessionID: sessionID,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(s.AccessTokenTTL)),
+			Subject:   empId,
+			ID:        uuid.New().String(),
+		},
+	}
+
+	return s.Keys.Sign(claims)
+}
+
+// GetJWKS godoc
+// @Summary Public signing keys
+// @Description Publishes the public halves of the RS256/ES256 token signing keys as a JSON Web Key Set so other services can verify access tokens
+// @Tags Login
+// @Produce json
+// @Success 200 {object} middleware.JWKS
+// @Router /.well-known/jwks.json [get]
+func (s *Server) GetJWKS(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "public, max-age=300")
+	json.NewEncoder(w).Encode(s.Keys.JWKS())
 }
@@ -0,0 +1,358 @@
+package controllers
+
+import (
+	"employee-asset-system/db"
+	"employee-asset-system/models"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// maxBulkItems bounds the items of a single bulk request.
+const maxBulkItems = 1000
+
+// Outcomes of the items of a bulk request.
+const (
+	BulkItemApplied = "applied"
+	BulkItemFailed  = "failed"
+	// BulkItemSkipped marks the items of an all-or-nothing request that
+	// would have succeeded but were not kept because another item failed.
+	BulkItemSkipped = "skipped"
+)
+
+// BulkAssignItem is one asset to assign.
+type BulkAssignItem struct {
+	EmployeeID string `json:"employee_id"`
+	AssetID    string `json:"asset_id"`
+	Notes      string `json:"notes,omitempty"`
+}
+
+// BulkAssignRequest is the body of BulkAssign.
+type BulkAssignRequest struct {
+	Items        []BulkAssignItem `json:"items"`
+	AllOrNothing bool             `json:"all_or_nothing"` // keep nothing unless every item succeeds
+}
+
+// BulkReturnItem names the mapping to close, either by its ID or by the
+// employee and asset of the active mapping.
+type BulkReturnItem struct {
+	MappingID  string `json:"mapping_id,omitempty"`
+	EmployeeID string `json:"employee_id,omitempty"`
+	AssetID    string `json:"asset_id,omitempty"`
+}
+
+// BulkReturnRequest is the body of BulkReturn. The return details apply to
+// every item.
+type BulkReturnRequest struct {
+	ReturnRequest
+	Items        []BulkReturnItem `json:"items"`
+	AllOrNothing bool             `json:"all_or_nothing"` // keep nothing unless every item succeeds
+}
+
+// BulkItemResult is the outcome of one item of a bulk request, in the order
+// of the request.
+type BulkItemResult struct {
+	Index     int    `json:"index"`
+	MappingID string `json:"mapping_id,omitempty"`
+	Status    string `json:"status"` // applied, failed or skipped
+	Error     string `json:"error,omitempty"`
+}
+
+// BulkReport is the reply to a bulk request.
+type BulkReport struct {
+	AllOrNothing bool             `json:"all_or_nothing"`
+	Applied      int              `json:"applied"`
+	Failed       int              `json:"failed"`
+	Results      []BulkItemResult `json:"results"`
+}
+
+// bulkItem is an item of a bulk request on its way to the store: either the
+// change to apply or why it was rejected beforehand.
+type bulkItem struct {
+	change db.MappingChange
+	before *models.EmployeeAssetMapping // the mapping a return closes
+	err    string
+}
+
+// BulkAssign godoc
+// @Summary Assign assets in bulk
+// @Description Assigns each asset to its employee as AssignAssetMapping would. Items fail on their own with their reason reported. Without all_or_nothing the items that succeed are kept whatever happens to the others, and on MongoDB they become visible one by one. With all_or_nothing set the items run as one transaction: nothing is kept unless every item succeeds and the reply is 409 otherwise. MongoDB deployments without transactions, such as a standalone server, refuse all_or_nothing with 501.
+// @Tags Asset Mapping
+// @Accept json
+// @Produce json
+// @Param request body BulkAssignRequest true "Assignments"
+// @Success 200 {object} BulkReport "Every item was applied"
+// @Success 207 {object} BulkReport "Some items failed"
+// @Failure 400 {object} map[string]string
+// @Failure 409 {object} BulkReport "An all-or-nothing request failed"
+// @Failure 422 {object} ValidationErrorResponse
+// @Failure 500 {object} map[string]string
+// @Failure 501 {object} map[string]string
+// @Router /mapping/bulk/assign [post]
+func (s *Server) BulkAssign(w http.ResponseWriter, r *http.Request) {
+	var req BulkAssignRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid input", http.StatusBadRequest)
+		return
+	}
+	if errs := validateBulkItems(len(req.Items)); len(errs) > 0 {
+		writeValidationErrors(w, errs)
+		return
+	}
+
+	items := make([]bulkItem, len(req.Items))
+	for i, it := range req.Items {
+		mapping, reason, err := s.newBulkMapping(r, it)
+		if err != nil {
+			http.Error(w, "Failed to assign assets", http.StatusInternalServerError)
+			return
+		}
+		items[i] = bulkItem{change: db.MappingChange{Assign: mapping}, err: reason}
+	}
+	if !s.applyBulk(w, r, items, req.AllOrNothing) {
+		return
+	}
+	for _, item := range items {
+		if m := item.change.Result; m != nil {
+			s.audit(r, models.AuditActionAssign, models.AuditEntityMapping, m.MappingID, nil, auditSnapshot(m))
+		}
+	}
+	writeBulkReport(w, items, req.AllOrNothing)
+}
+
+// newBulkMapping returns the mapping assigning it, or why it cannot be
+// assigned.
+func (s *Server) newBulkMapping(r *http.Request, it BulkAssignItem) (*models.EmployeeAssetMapping, string, error) {
+	if it.EmployeeID == "" || it.AssetID == "" {
+		return nil, "Both employee_id and asset_id are required", nil
+	}
+	if _, err := s.Employees.GetEmployee(r.Context(), it.EmployeeID); err != nil {
+		if errors.Is(err, db.ErrNotFound) {
+			return nil, "Employee not found", nil
+		}
+		return nil, "", err
+	}
+	asset, err := s.Assets.GetAsset(r.Context(), it.AssetID)
+	if err != nil {
+		if errors.Is(err, db.ErrNotFound) {
+			return nil, "Asset not found", nil
+		}
+		return nil, "", err
+	}
+	return &models.EmployeeAssetMapping{
+		MappingID:    uuid.New().String(),
+		EmployeeID:   it.EmployeeID,
+		AssetID:      it.AssetID,
+		AssignedDate: time.Now(),
+		AssignedBy:   actorID(r),
+		Status:       models.MappingStatusActive,
+		Notes:        it.Notes,
+		Exclusive:    !asset.Shared,
+	}, "", nil
+}
+
+// BulkReturn godoc
+// @Summary Return assets in bulk
+// @Description Closes each mapping as ReturnAssetMapping would. A mapping is named by mapping_id or by the employee_id and asset_id of the active mapping. Items fail on their own with their reason reported. Without all_or_nothing the items that succeed are kept whatever happens to the others, and on MongoDB they become visible one by one. With all_or_nothing set the items run as one transaction: nothing is kept unless every item succeeds and the reply is 409 otherwise. MongoDB deployments without transactions, such as a standalone server, refuse all_or_nothing with 501.
+// @Tags Asset Mapping
+// @Accept json
+// @Produce json
+// @Param request body BulkReturnRequest true "Returns"
+// @Success 200 {object} BulkReport "Every item was applied"
+// @Success 207 {object} BulkReport "Some items failed"
+// @Failure 400 {object} map[string]string
+// @Failure 409 {object} BulkReport "An all-or-nothing request failed"
+// @Failure 422 {object} ValidationErrorResponse
+// @Failure 500 {object} map[string]string
+// @Failure 501 {object} map[string]string
+// @Router /mapping/bulk/return [post]
+func (s *Server) BulkReturn(w http.ResponseWriter, r *http.Request) {
+	var req BulkReturnRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid input", http.StatusBadRequest)
+		return
+	}
+	if req.Status == "" {
+		req.Status = models.MappingStatusReturned
+	}
+	errs := validateBulkItems(len(req.Items))
+	if !models.IsClosedMappingStatus(req.Status) {
+		errs.Add("status", "must be one of returned, lost, damaged")
+	}
+	if len(errs) > 0 {
+		writeValidationErrors(w, errs)
+		return
+	}
+
+	ret := models.MappingReturn{
+		Status:       req.Status,
+		ReturnedDate: time.Now(),
+		ReturnedBy:   actorID(r),
+		Condition:    req.ReturnCondition,
+		Reason:       req.Reason,
+	}
+	items := make([]bulkItem, len(req.Items))
+	for i, it := range req.Items {
+		before, reason, err := s.bulkReturnMapping(r, it)
+		if err != nil {
+			http.Error(w, "Failed to return assets", http.StatusInternalServerError)
+			return
+		}
+		items[i] = bulkItem{before: before, err: reason}
+		if before != nil {
+			items[i].change = db.MappingChange{MappingID: before.MappingID, Return: ret}
+		}
+	}
+	if !s.applyBulk(w, r, items, req.AllOrNothing) {
+		return
+	}
+	for _, item := range items {
+		if m := item.change.Result; m != nil {
+			s.audit(r, models.AuditActionReturn, models.AuditEntityMapping, m.MappingID, auditSnapshot(item.before), auditSnapshot(m))
+		}
+	}
+	writeBulkReport(w, items, req.AllOrNothing)
+}
+
+// bulkReturnMapping returns the mapping it names, or why it cannot be
+// returned.
+func (s *Server) bulkReturnMapping(r *http.Request, it BulkReturnItem) (*models.EmployeeAssetMapping, string, error) {
+	switch {
+	case it.MappingID != "" && (it.EmployeeID != "" || it.AssetID != ""):
+		return nil, "Give either mapping_id or employee_id and asset_id, not both", nil
+	case it.MappingID != "":
+		mapping, err := s.Mappings.GetMapping(r.Context(), it.MappingID)
+		if errors.Is(err, db.ErrNotFound) {
+			return nil, "Asset mapping not found", nil
+		}
+		return mapping, "", err
+	case it.EmployeeID == "" || it.AssetID == "":
+		return nil, "Either mapping_id or both employee_id and asset_id are required", nil
+	}
+	active, err := s.Mappings.ListMappingsByEmployee(r.Context(), it.EmployeeID, models.MappingStatusActive)
+	if err != nil {
+		return nil, "", err
+	}
+	for i := range active {
+		if active[i].AssetID == it.AssetID {
+			return &active[i], "", nil
+		}
+	}
+	return nil, "Asset is not assigned to the employee", nil
+}
+
+func validateBulkItems(n int) models.ValidationErrors {
+	var errs models.ValidationErrors
+	switch {
+	case n == 0:
+		errs.Add("items", "is required")
+	case n > maxBulkItems:
+		errs.Add("items", fmt.Sprintf("must list at most %d items", maxBulkItems))
+	}
+	return errs
+}
+
+// applyBulk applies the changes of the items that were not rejected
+// beforehand, leaving the Result of only those kept. An all-or-nothing
+// request with a rejected item is not sent to the store at all. It writes an
+// error reply and returns false when the batch as a whole fails.
+func (s *Server) applyBulk(w http.ResponseWriter, r *http.Request, items []bulkItem, allOrNothing bool) bool {
+	var changes []db.MappingChange
+	var applied []int
+	for i, item := range items {
+		if item.err == "" {
+			changes = append(changes, item.change)
+			applied = append(applied, i)
+		} else if allOrNothing {
+			return true
+		}
+	}
+	if len(changes) == 0 {
+		return true
+	}
+	err := s.Mappings.ApplyMappingChanges(r.Context(), changes, allOrNothing)
+	switch {
+	case errors.Is(err, db.ErrNoTransactions):
+		http.Error(w, "all_or_nothing is not supported by this database", http.StatusNotImplemented)
+		return false
+	case err != nil:
+		http.Error(w, "Failed to apply the changes", http.StatusInternalServerError)
+		return false
+	}
+	failed := false
+	for j, i := range applied {
+		items[i].change = changes[j]
+		if err := changes[j].Err; err != nil {
+			items[i].err = bulkError(changes[j], err)
+			failed = true
+		}
+	}
+	if allOrNothing && failed {
+		// Nothing was kept.
+		for _, i := range applied {
+			items[i].change.Result = nil
+		}
+	}
+	return true
+}
+
+// bulkError is the message reported for an item the store failed.
+func bulkError(change db.MappingChange, err error) string {
+	switch {
+	case errors.Is(err, db.ErrAssetAssigned):
+		return "Asset is already assigned to another employee"
+	case errors.Is(err, db.ErrMappingClosed):
+		return "Asset mapping is not active"
+	case errors.Is(err, db.ErrNotFound) && change.Assign != nil:
+		return "Employee or asset not found"
+	case errors.Is(err, db.ErrNotFound):
+		return "Asset mapping not found"
+	default:
+		return "Failed to apply the change"
+	}
+}
+
+// writeBulkReport replies with the outcome of every item: 200 when all of
+// them were applied, 207 when only some were and 409 when an all-or-nothing
+// request kept nothing.
+func writeBulkReport(w http.ResponseWriter, items []bulkItem, allOrNothing bool) {
+	report := BulkReport{AllOrNothing: allOrNothing, Results: make([]BulkItemResult, len(items))}
+	for _, item := range items {
+		if item.err != "" {
+			report.Failed++
+		}
+	}
+	for i, item := range items {
+		result := &report.Results[i]
+		result.Index = i
+		switch {
+		case item.err != "":
+			result.Status, result.Error = BulkItemFailed, item.err
+		case allOrNothing && report.Failed > 0:
+			result.Status = BulkItemSkipped
+		default:
+			result.Status = BulkItemApplied
+			report.Applied++
+		}
+		if m := item.change.Result; m != nil && result.Status == BulkItemApplied {
+			result.MappingID = m.MappingID
+		} else if item.before != nil {
+			result.MappingID = item.before.MappingID
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	switch {
+	case report.Failed == 0:
+		w.WriteHeader(http.StatusOK)
+	case allOrNothing:
+		w.WriteHeader(http.StatusConflict)
+	default:
+		w.WriteHeader(http.StatusMultiStatus)
+	}
+	json.NewEncoder(w).Encode(report)
+}
@@ -0,0 +1,185 @@
+package controllers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"testing"
+	"time"
+
+	"employee-asset-system/db"
+	"employee-asset-system/models"
+)
+
+// seedMapping adds the mapping id of asset to employee with the given
+// status.
+func seedMapping(t *testing.T, store *db.MemoryStore, id, employee, asset, status string) {
+	t.Helper()
+	err := store.CreateMapping(context.Background(), &models.EmployeeAssetMapping{
+		MappingID:    id,
+		EmployeeID:   employee,
+		AssetID:      asset,
+		AssignedDate: time.Now(),
+		Status:       status,
+		Exclusive:    true,
+	})
+	if err != nil {
+		t.Fatalf("CreateMapping: %v", err)
+	}
+}
+
+// activeMappings returns the number of active mappings in store.
+func activeMappings(t *testing.T, store *db.MemoryStore) int64 {
+	t.Helper()
+	_, total, err := store.ListMappings(context.Background(), db.MappingQuery{Status: models.MappingStatusActive})
+	if err != nil {
+		t.Fatalf("ListMappings: %v", err)
+	}
+	return total
+}
+
+// statuses returns the status of each result of report.
+func statuses(report BulkReport) []string {
+	var out []string
+	for _, result := range report.Results {
+		out = append(out, result.Status)
+	}
+	return out
+}
+
+func TestBulkAssign(t *testing.T) {
+	tests := []struct {
+		name   string
+		body   string
+		code   int
+		status []string
+		active int64 // active mappings afterwards, m3 included
+	}{
+		{
+			name:   "all applied",
+			body:   `{"all_or_nothing":true,"items":[{"employee_id":"e1","asset_id":"a1"},{"employee_id":"e2","asset_id":"a2"}]}`,
+			code:   http.StatusOK,
+			status: []string{BulkItemApplied, BulkItemApplied},
+			active: 3,
+		},
+		{
+			name:   "asset taken, all or nothing",
+			body:   `{"all_or_nothing":true,"items":[{"employee_id":"e1","asset_id":"a1"},{"employee_id":"e1","asset_id":"a3"}]}`,
+			code:   http.StatusConflict,
+			status: []string{BulkItemSkipped, BulkItemFailed},
+			active: 1,
+		},
+		{
+			name:   "asset taken, partial",
+			body:   `{"items":[{"employee_id":"e1","asset_id":"a1"},{"employee_id":"e1","asset_id":"a3"}]}`,
+			code:   http.StatusMultiStatus,
+			status: []string{BulkItemApplied, BulkItemFailed},
+			active: 2,
+		},
+		{
+			name:   "unknown employee, all or nothing",
+			body:   `{"all_or_nothing":true,"items":[{"employee_id":"e1","asset_id":"a1"},{"employee_id":"e9","asset_id":"a2"}]}`,
+			code:   http.StatusConflict,
+			status: []string{BulkItemSkipped, BulkItemFailed},
+			active: 1,
+		},
+		{
+			name:   "same asset twice, all or nothing",
+			body:   `{"all_or_nothing":true,"items":[{"employee_id":"e1","asset_id":"a1"},{"employee_id":"e2","asset_id":"a1"}]}`,
+			code:   http.StatusConflict,
+			status: []string{BulkItemSkipped, BulkItemFailed},
+			active: 1,
+		},
+		{
+			name:   "shared asset twice",
+			body:   `{"all_or_nothing":true,"items":[{"employee_id":"e1","asset_id":"s1"},{"employee_id":"e2","asset_id":"s1"}]}`,
+			code:   http.StatusOK,
+			status: []string{BulkItemApplied, BulkItemApplied},
+			active: 3,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, store := newTestServer(t)
+			seedMapping(t, store, "m3", "e2", "a3", models.MappingStatusActive)
+
+			w := serve(s.BulkAssign, "POST", "/api/mapping/bulk/assign", tt.body)
+			if w.Code != tt.code {
+				t.Fatalf("status code = %d, want %d: %s", w.Code, tt.code, w.Body)
+			}
+			var report BulkReport
+			if err := json.NewDecoder(w.Body).Decode(&report); err != nil {
+				t.Fatalf("decoding the report: %v", err)
+			}
+			if got := statuses(report); !reflect.DeepEqual(got, tt.status) {
+				t.Errorf("item statuses = %v, want %v", got, tt.status)
+			}
+			if got := activeMappings(t, store); got != tt.active {
+				t.Errorf("active mappings = %d, want %d", got, tt.active)
+			}
+		})
+	}
+}
+
+func TestBulkReturn(t *testing.T) {
+	tests := []struct {
+		name   string
+		body   string
+		code   int
+		status []string
+		active int64 // active mappings afterwards, out of m1 and m2
+	}{
+		{
+			name:   "all applied",
+			body:   `{"all_or_nothing":true,"items":[{"mapping_id":"m1"},{"employee_id":"e2","asset_id":"a2"}]}`,
+			code:   http.StatusOK,
+			status: []string{BulkItemApplied, BulkItemApplied},
+			active: 0,
+		},
+		{
+			name:   "closed mapping, all or nothing",
+			body:   `{"all_or_nothing":true,"items":[{"mapping_id":"m1"},{"mapping_id":"m3"}]}`,
+			code:   http.StatusConflict,
+			status: []string{BulkItemSkipped, BulkItemFailed},
+			active: 2,
+		},
+		{
+			name:   "closed mapping, partial",
+			body:   `{"items":[{"mapping_id":"m1"},{"mapping_id":"m3"}]}`,
+			code:   http.StatusMultiStatus,
+			status: []string{BulkItemApplied, BulkItemFailed},
+			active: 1,
+		},
+		{
+			name:   "not assigned, all or nothing",
+			body:   `{"all_or_nothing":true,"items":[{"mapping_id":"m1"},{"employee_id":"e1","asset_id":"a2"}]}`,
+			code:   http.StatusConflict,
+			status: []string{BulkItemSkipped, BulkItemFailed},
+			active: 2,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, store := newTestServer(t)
+			seedMapping(t, store, "m1", "e1", "a1", models.MappingStatusActive)
+			seedMapping(t, store, "m2", "e2", "a2", models.MappingStatusActive)
+			seedMapping(t, store, "m3", "e2", "a3", models.MappingStatusReturned)
+
+			w := serve(s.BulkReturn, "POST", "/api/mapping/bulk/return", tt.body)
+			if w.Code != tt.code {
+				t.Fatalf("status code = %d, want %d: %s", w.Code, tt.code, w.Body)
+			}
+			var report BulkReport
+			if err := json.NewDecoder(w.Body).Decode(&report); err != nil {
+				t.Fatalf("decoding the report: %v", err)
+			}
+			if got := statuses(report); !reflect.DeepEqual(got, tt.status) {
+				t.Errorf("item statuses = %v, want %v", got, tt.status)
+			}
+			if got := activeMappings(t, store); got != tt.active {
+				t.Errorf("active mappings = %d, want %d", got, tt.active)
+			}
+		})
+	}
+}
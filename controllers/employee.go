@@ -1,18 +1,18 @@
 package controllers
 
 import (
+	"context"
 	"employee-asset-system/db"
 	"employee-asset-system/models"
 	"employee-asset-system/utils"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
 	"time"
 
 	"github.com/google/uuid"
 	"github.com/gorilla/mux"
-	"go.mongodb.org/mongo-driver/bson"
-	"go.mongodb.org/mongo-driver/mongo"
 )
 
 // CreateEmployee godoc
@@ -21,88 +21,194 @@ import (
 // @Tags Employees
 // @Accept json
 // @Produce json
-// @Param employee body models.Employee true "Employee data"
+// @Param employee body models.EmployeePatch true "Employee data"
 // @Success 201 {object} map[string]string
+// @Header 201 {string} ETag "Version of the employee"
 // @Failure 400 {object} map[string]string
+// @Failure 422 {object} ValidationErrorResponse
 // @Failure 500 {object} map[string]string
 // @Router /employees [post]
-func CreateEmployee(w http.ResponseWriter, r *http.Request) {
-	var employee models.Employee
-	if err := json.NewDecoder(r.Body).Decode(&employee); err != nil {
-		http.Error(w, "Invalid input", http.StatusBadRequest)
+func (s *Server) CreateEmployee(w http.ResponseWriter, r *http.Request) {
+	var patch models.EmployeePatch
+	if !readPatch(w, r, nil, &patch, func() models.ValidationErrors { return patch.Validate(true) }) {
 		return
 	}
 
+	var employee models.Employee
+	patch.Apply(&employee)
+	if len(employee.Roles) == 0 {
+		employee.Roles = []string{models.RoleEmployee}
+	}
+	if patch.Password != nil {
+		employee.Password = utils.HashPassword(*patch.Password)
+	}
+
 	employee.EmpID = uuid.New().String()
 	employee.CreatedAt = time.Now()
 	employee.UpdatedAt = time.Now()
+	employee.CreatedBy = actorID(r)
+	employee.UpdatedBy = employee.CreatedBy
 
-	employee.Password = utils.HashPassword(employee.Password)
-
-	_, err := db.Database.Collection("employee").InsertOne(r.Context(), employee)
-	if err != nil {
+	if err := s.Employees.CreateEmployee(r.Context(), &employee); err != nil {
 		http.Error(w, "Failed to create employee", http.StatusInternalServerError)
 		return
 	}
+	after := auditSnapshot(&employee)
+	s.audit(r, models.AuditActionCreate, models.AuditEntityEmployee, employee.EmpID, nil, after)
+	s.recordVersion(r, models.AuditEntityEmployee, employee.EmpID, models.AuditActionCreate, nil, after, 0)
 
+	w.Header().Set("ETag", etag(employee.Version))
 	w.WriteHeader(http.StatusCreated)
 	json.NewEncoder(w).Encode(map[string]string{"message": "Employee created successfully"})
 }
 
 // EditEmployee godoc
-// @Summary Edit an employee's details
-// @Description Updates an employee's information by ID
+// @Summary Replace an employee's details
+// @Description Replaces every editable field of an employee. Fields missing from the body are cleared, except the password, which is only changed when given. Only the fields of EmployeePatch may be set; any other key is rejected.
 // @Tags Employees
 // @Accept json
 // @Produce json
 // @Param employeeId path string true "Employee ID"
-// @Param data body map[string]interface{} true "Updated data"
+// @Param data body models.EmployeePatch true "Employee data"
+// @Param If-Match header string false "ETag the client last read; the write is refused with 412 when the record has changed since"
 // @Success 200 {object} map[string]string
+// @Header 200 {string} ETag "Version of the employee"
 // @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 412 {object} map[string]string
+// @Failure 422 {object} ValidationErrorResponse
 // @Failure 500 {object} map[string]string
-// @Router /employees/{employeeId} [put]
-func EditEmployee(w http.ResponseWriter, r *http.Request) {
+// @Router /employee/editemployee/{employeeId} [put]
+func (s *Server) EditEmployee(w http.ResponseWriter, r *http.Request) {
+	s.updateEmployee(w, r)
+}
+
+// PatchEmployee godoc
+// @Summary Partially update an employee
+// @Description Applies a JSON Merge Patch (RFC 7386) or JSON Patch (RFC 6902) to the editable fields of an employee, chosen by Content-Type. Removing a field clears it. A failed test operation is rejected with 409.
+// @Tags Employees
+// @Accept application/merge-patch+json,application/json-patch+json
+// @Produce json
+// @Param employeeId path string true "Employee ID"
+// @Param patch body object true "Merge patch object or JSON Patch operation array"
+// @Param If-Match header string false "ETag the client last read; the write is refused with 412 when the record has changed since"
+// @Success 200 {object} map[string]string
+// @Header 200 {string} ETag "Version of the employee"
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Failure 412 {object} map[string]string
+// @Failure 415 {object} map[string]string
+// @Failure 422 {object} ValidationErrorResponse
+// @Failure 500 {object} map[string]string
+// @Router /employee/editemployee/{employeeId} [patch]
+func (s *Server) PatchEmployee(w http.ResponseWriter, r *http.Request) {
+	s.updateEmployee(w, r)
+}
+
+// updateEmployee replaces the editable fields of an employee with the body
+// of a PUT request or the result of applying the body of a PATCH request.
+func (s *Server) updateEmployee(w http.ResponseWriter, r *http.Request) {
 	employeeID := mux.Vars(r)["employeeId"]
 
-	var updatedData bson.M
-	if err := json.NewDecoder(r.Body).Decode(&updatedData); err != nil {
-		http.Error(w, "Invalid input", http.StatusBadRequest)
+	employee, err := s.Employees.GetEmployee(r.Context(), employeeID)
+	if err != nil {
+		writeStoreError(w, err, "Employee not found", "Failed to update employee")
+		return
+	}
+	if !checkIfMatch(w, r, employee.Version) {
+		return
+	}
+	before := auditSnapshot(employee)
+
+	var fields models.EmployeePatch
+	current := models.NewEmployeePatch(employee)
+	if !readPatch(w, r, &current, &fields, func() models.ValidationErrors { return fields.Validate(true) }) {
 		return
 	}
+	fields.Replace(employee)
+	if len(employee.Roles) == 0 {
+		employee.Roles = []string{models.RoleEmployee}
+	}
+	if fields.Password != nil {
+		employee.Password = utils.HashPassword(*fields.Password)
+	}
 
-	updatedData["updated_at"] = time.Now()
-	filter := bson.M{"emp_id": employeeID}
-	update := bson.M{"$set": updatedData}
+	employee.UpdatedAt = time.Now()
+	employee.UpdatedBy = actorID(r)
 
-	_, err := db.Database.Collection("employee").UpdateOne(r.Context(), filter, update)
-	if err != nil {
-		http.Error(w, "Failed to update employee", http.StatusInternalServerError)
+	if err := s.Employees.UpdateEmployee(r.Context(), employee); err != nil {
+		writeWriteError(w, r, err, "Employee not found", "Failed to update employee")
 		return
 	}
+	after := auditSnapshot(employee)
+	s.audit(r, models.AuditActionUpdate, models.AuditEntityEmployee, employeeID, before, after)
+	s.recordVersion(r, models.AuditEntityEmployee, employeeID, models.AuditActionUpdate, before, after, 0)
 
+	w.Header().Set("ETag", etag(employee.Version))
 	w.WriteHeader(http.StatusOK)
 	json.NewEncoder(w).Encode(map[string]string{"message": "Employee updated successfully"})
 }
 
 // DeleteEmployee godoc
 // @Summary Delete an employee
-// @Description Deletes an employee from the database by ID
+// @Description Soft deletes an employee by ID. It can be restored until it is purged. The delete is refused while the employee has active asset mappings unless cascade is set, which returns them first with the given reason. Closed mappings are kept as history.
 // @Tags Employees
 // @Produce json
 // @Param employeeId path string true "Employee ID"
+// @Param cascade query bool false "Return the employee's active mappings instead of refusing the delete"
+// @Param reason query string false "Reason recorded on mappings closed by cascade"
+// @Param If-Match header string false "ETag the client last read; the write is refused with 412 when the record has changed since"
 // @Success 200 {object} map[string]string
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Failure 412 {object} map[string]string
 // @Failure 500 {object} map[string]string
 // @Router /employees/{employeeId} [delete]
-func DeleteEmployee(w http.ResponseWriter, r *http.Request) {
+func (s *Server) DeleteEmployee(w http.ResponseWriter, r *http.Request) {
 	employeeID := mux.Vars(r)["employeeId"]
 
-	filter := bson.M{"emp_id": employeeID}
-	_, err := db.Database.Collection("employee").DeleteOne(r.Context(), filter)
+	cascade, err := cascadeReturn(r, "employee deleted")
 	if err != nil {
-		http.Error(w, "Failed to delete employee", http.StatusInternalServerError)
+		http.Error(w, "Invalid cascade parameter", http.StatusBadRequest)
 		return
 	}
 
+	// Remember what a cascade is about to close so it can be audited.
+	var closed []models.EmployeeAssetMapping
+	if cascade != nil {
+		closed, err = s.Mappings.ListMappingsByEmployee(r.Context(), employeeID, models.MappingStatusActive)
+		if err != nil {
+			http.Error(w, "Failed to delete employee", http.StatusInternalServerError)
+			return
+		}
+	}
+
+	deletion := models.Deletion{DeletedAt: time.Now(), DeletedBy: actorID(r), Cascade: cascade}
+	if r.Header.Get("If-Match") != "" {
+		current, err := s.Employees.GetEmployee(r.Context(), employeeID)
+		if err != nil {
+			writeStoreError(w, err, "Employee not found", "Failed to delete employee")
+			return
+		}
+		if !checkIfMatch(w, r, current.Version) {
+			return
+		}
+		deletion.IfVersion = current.Version
+	}
+	if err := s.Employees.DeleteEmployee(r.Context(), employeeID, deletion); err != nil {
+		if errors.Is(err, db.ErrActiveMappings) {
+			http.Error(w, "Employee still holds assets; return them first or delete with cascade=true", http.StatusConflict)
+			return
+		}
+		writeWriteError(w, r, err, "Employee not found", "Failed to delete employee")
+		return
+	}
+	s.auditCascade(r, closed)
+	s.audit(r, models.AuditActionDelete, models.AuditEntityEmployee, employeeID,
+		auditSnapshot(tombstone{}), auditSnapshot(tombstone{&deletion.DeletedAt, deletion.DeletedBy}))
+
 	w.WriteHeader(http.StatusOK)
 	json.NewEncoder(w).Encode(map[string]string{"message": "Employee deleted successfully"})
 }
@@ -113,18 +219,24 @@ func DeleteEmployee(w http.ResponseWriter, r *http.Request) {
 // @Tags Employees
 // @Produce json
 // @Param employeeId path string true "Employee ID"
+// @Param If-None-Match header string false "ETag of the copy the client holds"
 // @Success 200 {object} models.Employee
+// @Success 304 "Not modified"
+// @Header 200 {string} ETag "Version of the employee"
 // @Failure 404 {object} map[string]string
 // @Router /employees/{employeeId} [get]
-func GetEmployeeById(w http.ResponseWriter, r *http.Request) {
+func (s *Server) GetEmployeeById(w http.ResponseWriter, r *http.Request) {
 	employeeID := mux.Vars(r)["employeeId"]
 
-	var employee models.Employee
-	err := db.Database.Collection("employee").FindOne(r.Context(), bson.M{"emp_id": employeeID}).Decode(&employee)
+	employee, err := s.Employees.GetEmployee(r.Context(), employeeID)
 	if err != nil {
-		http.Error(w, "Employee not found", http.StatusNotFound)
+		writeStoreError(w, err, "Employee not found", "Failed to fetch employee")
 		return
 	}
+	if !checkIfNoneMatch(w, r, employee.Version) {
+		return
+	}
+	employee.Password = "" // never hand out the hash
 
 	w.WriteHeader(http.StatusOK)
 	json.NewEncoder(w).Encode(employee)
@@ -132,62 +244,131 @@ func GetEmployeeById(w http.ResponseWriter, r *http.Request) {
 
 // GetAllEmployees godoc
 // @Summary Get all employees with asset count
-// @Description Fetches all employees and their asset counts
+// @Description Lists employees a page at a time with the assets currently assigned to them: their count, a breakdown by asset type, their total purchase cost by currency, and when the employee was last assigned an asset. Pass the NextPageToken of a page as page_token, with the same sort, to fetch the one after it.
 // @Tags Employees
 // @Produce json
+// @Param name query string false "Only employees whose first or last name starts with this, ignoring case"
+// @Param role query string false "Only employees holding this role"
+// @Param created_after query string false "Only employees created after this RFC 3339 time"
+// @Param created_before query string false "Only employees created before this RFC 3339 time"
+// @Param sort query string false "Sort field (created_at, updated_at, first_name, last_name, employee_email, emp_id), prefixed with - for descending order"
+// @Param page_size query int false "Employees per page (default 50, at most 200)"
+// @Param page_token query string false "Token of the page to fetch"
 // @Success 200 {object} models.EmployeeList
+// @Failure 400 {object} map[string]string
 // @Failure 500 {object} map[string]string
 // @Router /employees [get]
-func GetAllEmployees(w http.ResponseWriter, r *http.Request) {
-
-	coll := db.Database.Collection("employee")
-	// Define aggregation pipeline
-	pipeline := mongo.Pipeline{
-		{
-			{"$lookup", bson.D{
-				{"from", "mapping"},
-				{"localField", "emp_id"},
-				{"foreignField", "employee_id"},
-				{"as", "assets"},
-			}},
-		},
-		{
-			{"$addFields", bson.D{
-				{"asset_count", bson.D{{"$size", "$assets"}}},
-			}},
-		},
-		{
-			{"$project", bson.D{
-				{"emp_id", 1},
-				{"first_name", 1},
-				{"last_name", 1},
-				{"gender", 1},
-				{"phone_number", 1},
-				{"employee_email", 1},
-				{"address", 1},
-				{"blood_group", 1},
-				{"emergency_contact_number", 1},
-				{"asset_count", 1},
-			}},
-		},
-	}
-
-	cursor, err := coll.Find(r.Context(), pipeline)
+func (s *Server) GetAllEmployees(w http.ResponseWriter, r *http.Request) {
+	q, ok := readEmployeeQuery(w, r)
+	if !ok {
+		return
+	}
+
+	employees, total, err := s.Employees.Dashboard(r.Context(), q)
 	if err != nil {
 		fmt.Println(err)
-		http.Error(w, "Failed to fetch employees", http.StatusInternalServerError)
+		writeListError(w, err, "Failed to fetch employees")
 		return
 	}
-	defer cursor.Close(r.Context())
 
-	var employees []models.DashboardEmployee
-	if err := cursor.All(r.Context(), &employees); err != nil {
-		http.Error(w, "Failed to parse aggregation results", http.StatusInternalServerError)
-		return
+	data := models.EmployeeList{Employees: employees, Total: total}
+	if pageSize := q.Limit - 1; len(employees) > pageSize {
+		data.Employees = employees[:pageSize]
+		data.NextPageToken = nextPageToken(q.ListOptions, db.EmployeeCursor(&employees[pageSize-1], q.Sort))
+	}
+	if data.Employees == nil {
+		data.Employees = []models.DashboardEmployee{}
+	}
+	for i := range data.Employees {
+		if data.Employees[i].AssetsByType == nil {
+			data.Employees[i].AssetsByType = map[string]int{}
+		}
+		if data.Employees[i].AssignedValue == nil {
+			data.Employees[i].AssignedValue = map[string]float64{}
+		}
 	}
-	var data models.EmployeeList
-	data.Employees = employees
 
 	w.WriteHeader(http.StatusOK)
 	json.NewEncoder(w).Encode(data)
 }
+
+// readEmployeeQuery parses the filters, sort and page of an employee
+// listing. It writes a 400 reply and returns false when one of them is
+// invalid.
+func readEmployeeQuery(w http.ResponseWriter, r *http.Request) (db.EmployeeQuery, bool) {
+	query := r.URL.Query()
+	q := db.EmployeeQuery{
+		NamePrefix: query.Get("name"),
+		Role:       query.Get("role"),
+	}
+	if q.Role != "" && !models.IsValidRole(q.Role) {
+		http.Error(w, "Invalid role", http.StatusBadRequest)
+		return q, false
+	}
+	ok := readTimeParam(w, r, "created_after", &q.CreatedAfter) &&
+		readTimeParam(w, r, "created_before", &q.CreatedBefore) &&
+		readListOptions(w, r, db.EmployeeSortFields, &q.ListOptions)
+	return q, ok
+}
+
+// BootstrapAdmin makes sure the employee with the given email exists and
+// holds the admin role, creating them with password when missing. It lets a
+// fresh deployment sign in before any admin has been assigned.
+func (s *Server) BootstrapAdmin(ctx context.Context, email, password string) error {
+	employee, err := s.Employees.FindEmployeeByIdentifier(ctx, email)
+	if errors.Is(err, db.ErrNotFound) {
+		now := time.Now()
+		return s.Employees.CreateEmployee(ctx, &models.Employee{
+			EmpID:         uuid.New().String(),
+			EmployeeEmail: email,
+			Password:      utils.HashPassword(password),
+			Roles:         []string{models.RoleAdmin},
+			CreatedAt:     now,
+			UpdatedAt:     now,
+		})
+	}
+	if err != nil {
+		return err
+	}
+
+	for _, role := range employee.Roles {
+		if role == models.RoleAdmin {
+			return nil
+		}
+	}
+	employee.Roles = append(employee.Roles, models.RoleAdmin)
+	employee.UpdatedAt = time.Now()
+	return s.Employees.UpdateEmployee(ctx, employee)
+}
+
+func validRoles(roles []string) bool {
+	for _, role := range roles {
+		if !models.IsValidRole(role) {
+			return false
+		}
+	}
+	return true
+}
+
+// RestoreEmployee godoc
+// @Summary Restore a deleted employee
+// @Description Undoes the soft delete of an employee. Mappings closed when it was deleted stay closed.
+// @Tags Employees
+// @Produce json
+// @Param employeeId path string true "Employee ID"
+// @Success 200 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /employee/restoreemployee/{employeeId} [post]
+func (s *Server) RestoreEmployee(w http.ResponseWriter, r *http.Request) {
+	employeeID := mux.Vars(r)["employeeId"]
+
+	if err := s.Employees.RestoreEmployee(r.Context(), employeeID); err != nil {
+		writeStoreError(w, err, "Deleted employee not found", "Failed to restore employee")
+		return
+	}
+	s.audit(r, models.AuditActionRestore, models.AuditEntityEmployee, employeeID, nil, nil)
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]string{"message": "Employee restored successfully"})
+}
@@ -0,0 +1,46 @@
+package controllers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"employee-asset-system/models"
+	"employee-asset-system/utils"
+
+	"github.com/gorilla/mux"
+)
+
+func TestGetEmployeeByIdHidesPassword(t *testing.T) {
+	s, store := newTestServer(t)
+	now := time.Now()
+	err := store.CreateEmployee(context.Background(), &models.Employee{
+		EmpID:     "e3",
+		FirstName: "E3",
+		Password:  utils.HashPassword("secret"),
+		CreatedAt: now,
+		UpdatedAt: now,
+	})
+	if err != nil {
+		t.Fatalf("CreateEmployee: %v", err)
+	}
+	r := mux.NewRouter()
+	r.HandleFunc("/employee/{employeeId}", s.GetEmployeeById)
+
+	w := serve(r.ServeHTTP, "GET", "/employee/e3", "")
+	if w.Code != http.StatusOK {
+		t.Fatalf("status code = %d, want %d: %s", w.Code, http.StatusOK, w.Body)
+	}
+	var body map[string]interface{}
+	if err := json.NewDecoder(w.Body).Decode(&body); err != nil {
+		t.Fatalf("decoding the employee: %v", err)
+	}
+	if body["emp_id"] != "e3" {
+		t.Errorf("emp_id = %v, want e3", body["emp_id"])
+	}
+	if hash, ok := body["password"]; ok {
+		t.Errorf("response includes the password hash %v", hash)
+	}
+}
@@ -0,0 +1,70 @@
+package controllers
+
+import (
+	"employee-asset-system/db"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// etag returns the entity tag of a record at version.
+func etag(version int64) string {
+	return `"` + strconv.FormatInt(version, 10) + `"`
+}
+
+// etagListMatches reports whether the If-Match or If-None-Match header value
+// header lists tag or is "*". Weak tags only match when weak comparison is
+// allowed, as it is for If-None-Match.
+func etagListMatches(header, tag string, weak bool) bool {
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" {
+			return true
+		}
+		if weak {
+			candidate = strings.TrimPrefix(candidate, "W/")
+		}
+		if candidate == tag {
+			return true
+		}
+	}
+	return false
+}
+
+// checkIfMatch enforces the If-Match header of a write to a record that is
+// at version. It replies 412 and returns false when the client holds a
+// stale copy.
+func checkIfMatch(w http.ResponseWriter, r *http.Request, version int64) bool {
+	header := r.Header.Get("If-Match")
+	if header == "" || etagListMatches(header, etag(version), false) {
+		return true
+	}
+	w.Header().Set("ETag", etag(version))
+	http.Error(w, "Record has been modified; fetch it again and retry", http.StatusPreconditionFailed)
+	return false
+}
+
+// checkIfNoneMatch sets the ETag of a record at version on the reply to a
+// GET. It replies 304 and returns false when the client's copy, named by
+// If-None-Match, is current.
+func checkIfNoneMatch(w http.ResponseWriter, r *http.Request, version int64) bool {
+	w.Header().Set("ETag", etag(version))
+	header := r.Header.Get("If-None-Match")
+	if header != "" && etagListMatches(header, etag(version), true) {
+		w.WriteHeader(http.StatusNotModified)
+		return false
+	}
+	return true
+}
+
+// writeWriteError is writeStoreError for conditional writes: a write that
+// lost a race with another one is reported as 412 when the client asked for
+// it with If-Match, and as 409 otherwise.
+func writeWriteError(w http.ResponseWriter, r *http.Request, err error, notFound, failed string) {
+	if errors.Is(err, db.ErrStaleVersion) && r.Header.Get("If-Match") != "" {
+		http.Error(w, "Record has been modified; fetch it again and retry", http.StatusPreconditionFailed)
+		return
+	}
+	writeStoreError(w, err, notFound, failed)
+}
@@ -0,0 +1,67 @@
+package controllers
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/gorilla/mux"
+)
+
+func TestAssetConditionalRequests(t *testing.T) {
+	s, _ := newTestServer(t)
+	r := mux.NewRouter()
+	r.HandleFunc("/asset/{assetId}", s.GetAssetById).Methods("GET")
+	r.HandleFunc("/asset/{assetId}", s.PatchAsset).Methods("PATCH")
+	r.HandleFunc("/asset/{assetId}", s.DeleteAsset).Methods("DELETE")
+
+	// The steps run in order against the same asset, which starts at
+	// version 1.
+	steps := []struct {
+		name   string
+		method string
+		body   string
+		header []string
+		code   int
+		etag   string
+	}{
+		{name: "get", method: "GET", code: http.StatusOK, etag: `"1"`},
+		{name: "get current copy", method: "GET", header: []string{"If-None-Match", `"1"`}, code: http.StatusNotModified, etag: `"1"`},
+		{name: "get weak current copy", method: "GET", header: []string{"If-None-Match", `W/"1"`}, code: http.StatusNotModified, etag: `"1"`},
+		{
+			name:   "patch current version",
+			method: "PATCH",
+			body:   `{"asset_name":"Renamed"}`,
+			header: []string{"Content-Type", mergePatchType, "If-Match", `"1"`},
+			code:   http.StatusOK,
+			etag:   `"2"`,
+		},
+		{
+			name:   "patch stale version",
+			method: "PATCH",
+			body:   `{"asset_name":"Lost update"}`,
+			header: []string{"Content-Type", mergePatchType, "If-Match", `"1"`},
+			code:   http.StatusPreconditionFailed,
+		},
+		{
+			name:   "patch any version",
+			method: "PATCH",
+			body:   `{"asset_name":"Renamed again"}`,
+			header: []string{"Content-Type", mergePatchType, "If-Match", "*"},
+			code:   http.StatusOK,
+			etag:   `"3"`,
+		},
+		{name: "get stale copy", method: "GET", header: []string{"If-None-Match", `"1", "2"`}, code: http.StatusOK, etag: `"3"`},
+		{name: "delete stale version", method: "DELETE", header: []string{"If-Match", `"2"`}, code: http.StatusPreconditionFailed},
+		{name: "delete current version", method: "DELETE", header: []string{"If-Match", `"3"`}, code: http.StatusOK},
+		{name: "get deleted", method: "GET", code: http.StatusNotFound},
+	}
+	for _, step := range steps {
+		w := serve(r.ServeHTTP, step.method, "/asset/a1", step.body, step.header...)
+		if w.Code != step.code {
+			t.Fatalf("%s: status code = %d, want %d: %s", step.name, w.Code, step.code, w.Body)
+		}
+		if got := w.Header().Get("ETag"); step.etag != "" && got != step.etag {
+			t.Errorf("%s: ETag = %s, want %s", step.name, got, step.etag)
+		}
+	}
+}
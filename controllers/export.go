@@ -0,0 +1,359 @@
+package controllers
+
+import (
+	"employee-asset-system/db"
+	"employee-asset-system/models"
+	"employee-asset-system/utils"
+	"encoding/csv"
+	"log"
+	"mime"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// exportBatchSize is how many records an export fetches from the store at
+// a time. Each batch is written out before the next is fetched.
+const exportBatchSize = 500
+
+// Export formats.
+const (
+	exportCSV  = "csv"
+	exportXLSX = "xlsx"
+)
+
+// exportColumn is a column of an export. Numeric columns are written as
+// numbers in XLSX.
+type exportColumn struct {
+	name    string
+	numeric bool
+}
+
+var employeeExportColumns = []exportColumn{
+	{name: "emp_id"}, {name: "first_name"}, {name: "last_name"}, {name: "gender"},
+	{name: "phone_number"}, {name: "employee_email"}, {name: "address"}, {name: "blood_group"},
+	{name: "emergency_contact_number"}, {name: "department"}, {name: "asset_count", numeric: true},
+	{name: "assets_by_type"}, {name: "assigned_value"}, {name: "last_assigned_at"},
+	{name: "created_at"}, {name: "updated_at"},
+}
+
+func employeeExportRow(e *models.DashboardEmployee) []string {
+	byType := make([]string, 0, len(e.AssetsByType))
+	for assetType, n := range e.AssetsByType {
+		byType = append(byType, assetType+": "+strconv.Itoa(n))
+	}
+	values := make([]string, 0, len(e.AssignedValue))
+	for currency, value := range e.AssignedValue {
+		values = append(values, currency+" "+strconv.FormatFloat(value, 'f', 2, 64))
+	}
+	sort.Strings(byType)
+	sort.Strings(values)
+	return []string{
+		e.EmpId, e.FirstName, e.LastName, e.Gender,
+		e.PhoneNumber, e.EmployeeEmail, e.Address, e.BloodGroup,
+		e.EmergencyContactNumber, e.Department, strconv.Itoa(e.AssetCount),
+		strings.Join(byType, "; "), strings.Join(values, "; "), exportTime(e.LastAssignedAt),
+		exportTime(&e.CreatedAt), exportTime(&e.UpdatedAt),
+	}
+}
+
+var assetExportColumns = []exportColumn{
+	{name: "asset_id"}, {name: "asset_name"}, {name: "asset_type"}, {name: "shared"},
+	{name: "purchase_cost", numeric: true}, {name: "currency"},
+	{name: "asset_tag"}, {name: "serial_number"}, {name: "manufacturer"}, {name: "model"}, {name: "vendor"},
+	{name: "invoice_number"}, {name: "purchase_date"}, {name: "warranty_expiry"},
+	{name: "created_at"}, {name: "created_by"}, {name: "updated_at"}, {name: "updated_by"},
+}
+
+func assetExportRow(a *models.Asset) []string {
+	return []string{
+		a.AssetID, a.AssetName, a.AssetType, strconv.FormatBool(a.Shared),
+		strconv.FormatFloat(a.PurchaseCost, 'f', -1, 64), a.Currency,
+		a.AssetTag, a.SerialNumber, a.Manufacturer, a.Model, a.Vendor,
+		a.InvoiceNumber, exportDate(a.PurchaseDate), exportDate(a.WarrantyExpiry),
+		exportTime(&a.CreatedAt), a.CreatedBy, exportTime(&a.UpdatedAt), a.UpdatedBy,
+	}
+}
+
+var mappingExportColumns = []exportColumn{
+	{name: "mapping_id"}, {name: "employee_id"}, {name: "asset_id"}, {name: "status"},
+	{name: "exclusive"}, {name: "assigned_date"}, {name: "assigned_by"}, {name: "returned_date"},
+	{name: "returned_by"}, {name: "return_condition"}, {name: "close_reason"}, {name: "notes"},
+}
+
+func mappingExportRow(m *models.EmployeeAssetMapping) []string {
+	return []string{
+		m.MappingID, m.EmployeeID, m.AssetID, m.Status,
+		strconv.FormatBool(m.Exclusive), exportTime(&m.AssignedDate), m.AssignedBy, exportTime(m.ReturnedDate),
+		m.ReturnedBy, m.ReturnCondition, m.CloseReason, m.Notes,
+	}
+}
+
+// exportTime formats t in UTC, or returns "" for a nil or zero time.
+func exportTime(t *time.Time) string {
+	if t == nil || t.IsZero() {
+		return ""
+	}
+	return t.UTC().Format(time.RFC3339)
+}
+
+// exportDate formats the date t, or returns "" for a nil one.
+func exportDate(t *time.Time) string {
+	if t == nil {
+		return ""
+	}
+	return t.UTC().Format(models.DateLayout)
+}
+
+// exportFormat picks the format of an export from the format query
+// parameter or else the Accept header, defaulting to CSV. It writes an
+// error reply and returns false when neither format is acceptable.
+func exportFormat(w http.ResponseWriter, r *http.Request) (string, bool) {
+	switch format := r.URL.Query().Get("format"); format {
+	case exportCSV, exportXLSX:
+		return format, true
+	case "":
+	default:
+		http.Error(w, "Invalid format; use csv or xlsx", http.StatusBadRequest)
+		return "", false
+	}
+	accept := r.Header.Get("Accept")
+	if accept == "" {
+		return exportCSV, true
+	}
+	for _, part := range strings.Split(accept, ",") {
+		mediaType, _, err := mime.ParseMediaType(strings.TrimSpace(part))
+		if err != nil {
+			continue
+		}
+		switch mediaType {
+		case "text/csv", "text/*", "*/*":
+			return exportCSV, true
+		case utils.XLSXMediaType:
+			return exportXLSX, true
+		}
+	}
+	http.Error(w, "Not acceptable; exports are text/csv or "+utils.XLSXMediaType, http.StatusNotAcceptable)
+	return "", false
+}
+
+// rowWriter writes the rows of an export in one format.
+type rowWriter interface {
+	Write(record []string) error
+	Flush() error
+	Close() error
+}
+
+// csvRows writes CSV, guarding cells against formula injection.
+type csvRows struct{ w *csv.Writer }
+
+func (c csvRows) Write(record []string) error {
+	safe := make([]string, len(record))
+	for i, value := range record {
+		safe[i] = csvSafe(value)
+	}
+	return c.w.Write(safe)
+}
+
+func (c csvRows) Flush() error {
+	c.w.Flush()
+	return c.w.Error()
+}
+
+func (c csvRows) Close() error { return c.Flush() }
+
+// csvSafe prefixes value with a quote when a spreadsheet would otherwise
+// evaluate it as a formula. Numbers, such as phone numbers starting with +,
+// are left alone.
+func csvSafe(value string) string {
+	if value == "" {
+		return value
+	}
+	switch value[0] {
+	case '=', '@', '\t', '\r':
+		return "'" + value
+	case '+', '-':
+		if _, err := strconv.ParseFloat(value, 64); err != nil {
+			return "'" + value
+		}
+	}
+	return value
+}
+
+// startExport sets the headers of an export named name and returns the
+// writer of its rows, the header row already written.
+func startExport(w http.ResponseWriter, format, name string, columns []exportColumn) (rowWriter, error) {
+	header := make([]string, len(columns))
+	numeric := make([]bool, len(columns))
+	for i, column := range columns {
+		header[i], numeric[i] = column.name, column.numeric
+	}
+	filename := name + "-" + time.Now().UTC().Format("20060102") + "." + format
+	w.Header().Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": filename}))
+
+	var rows rowWriter
+	if format == exportXLSX {
+		w.Header().Set("Content-Type", utils.XLSXMediaType)
+		w.WriteHeader(http.StatusOK)
+		xlsx, err := utils.NewXLSXWriter(w, name)
+		if err != nil {
+			return nil, err
+		}
+		xlsx.Numeric = numeric
+		rows = xlsx
+	} else {
+		w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+		w.WriteHeader(http.StatusOK)
+		rows = csvRows{csv.NewWriter(w)}
+	}
+	return rows, rows.Write(header)
+}
+
+// streamExport writes every record fetch returns as an export, a batch at a
+// time. fetch lists the records after opts.After, which is advanced to the
+// cursor of the last record of each batch. Failures before the first batch
+// get an error reply; later ones can only cut the export short, and are
+// logged.
+func streamExport[T any](w http.ResponseWriter, r *http.Request, name string, columns []exportColumn,
+	opts *db.ListOptions, fetch func() ([]T, error), cursor func(*T, string) db.Cursor, row func(*T) []string) {
+	format, ok := exportFormat(w, r)
+	if !ok {
+		return
+	}
+	opts.Limit = exportBatchSize
+	batch, err := fetch()
+	if err != nil {
+		writeListError(w, err, "Failed to export "+name)
+		return
+	}
+
+	rows, err := startExport(w, format, name, columns)
+	for err == nil {
+		for i := range batch {
+			if err = rows.Write(row(&batch[i])); err != nil {
+				break
+			}
+		}
+		if err == nil {
+			err = rows.Flush()
+		}
+		if err != nil || len(batch) < exportBatchSize {
+			break
+		}
+		if flusher, ok := w.(http.Flusher); ok {
+			flusher.Flush()
+		}
+		last := cursor(&batch[len(batch)-1], opts.Sort)
+		opts.After = &last
+		batch, err = fetch()
+	}
+	if err == nil {
+		err = rows.Close()
+	}
+	if err != nil {
+		log.Printf("export %s: %v", name, err)
+	}
+}
+
+// ExportEmployees godoc
+// @Summary Export employees
+// @Description Streams the employees of the dashboard, with the assets they hold, as CSV or XLSX. Takes the filters and sort of the dashboard; every matching employee is exported. The format is chosen by the format parameter or else the Accept header, and defaults to CSV.
+// @Tags Export
+// @Produce text/csv
+// @Produce application/vnd.openxmlformats-officedocument.spreadsheetml.sheet
+// @Param format query string false "csv or xlsx"
+// @Param name query string false "Only employees whose first or last name starts with this, ignoring case"
+// @Param role query string false "Only employees holding this role"
+// @Param created_after query string false "Only employees created after this RFC 3339 time"
+// @Param created_before query string false "Only employees created before this RFC 3339 time"
+// @Param sort query string false "Sort field (created_at, updated_at, first_name, last_name, employee_email, emp_id), prefixed with - for descending order"
+// @Success 200 {file} file
+// @Failure 400 {object} map[string]string
+// @Failure 406 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /export/employees [get]
+func (s *Server) ExportEmployees(w http.ResponseWriter, r *http.Request) {
+	q, ok := readEmployeeQuery(w, r)
+	if !ok {
+		return
+	}
+	streamExport(w, r, "employees", employeeExportColumns, &q.ListOptions,
+		func() ([]models.DashboardEmployee, error) {
+			employees, _, err := s.Employees.Dashboard(r.Context(), q)
+			return employees, err
+		}, db.EmployeeCursor, employeeExportRow)
+}
+
+// ExportAssets godoc
+// @Summary Export assets
+// @Description Streams the assets as CSV or XLSX. Takes the filters and sort of the asset list; every matching asset is exported. The format is chosen by the format parameter or else the Accept header, and defaults to CSV.
+// @Tags Export
+// @Produce text/csv
+// @Produce application/vnd.openxmlformats-officedocument.spreadsheetml.sheet
+// @Param format query string false "csv or xlsx"
+// @Param asset_type query string false "Only assets of this type"
+// @Param name query string false "Only assets whose name starts with this, ignoring case"
+// @Param shared query bool false "Only shared or only exclusive assets"
+// @Param created_after query string false "Only assets created after this RFC 3339 time"
+// @Param created_before query string false "Only assets created before this RFC 3339 time"
+// @Param serial_number query string false "Only the asset with this serial number"
+// @Param manufacturer query string false "Only assets of this manufacturer, ignoring case"
+// @Param model query string false "Only assets of this model, ignoring case"
+// @Param vendor query string false "Only assets bought from this vendor, ignoring case"
+// @Param invoice_number query string false "Only assets on this invoice"
+// @Param purchased_after query string false "Only assets purchased after this date or RFC 3339 time"
+// @Param purchased_before query string false "Only assets purchased before this date or RFC 3339 time"
+// @Param warranty_expires_after query string false "Only assets whose warranty expires after this date or RFC 3339 time"
+// @Param warranty_expires_before query string false "Only assets whose warranty expires before this date or RFC 3339 time"
+// @Param sort query string false "Sort field (created_at, updated_at, asset_name, asset_type, asset_id), prefixed with - for descending order"
+// @Success 200 {file} file
+// @Failure 400 {object} map[string]string
+// @Failure 406 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /export/assets [get]
+func (s *Server) ExportAssets(w http.ResponseWriter, r *http.Request) {
+	q, ok := readAssetQuery(w, r)
+	if !ok {
+		return
+	}
+	streamExport(w, r, "assets", assetExportColumns, &q.ListOptions,
+		func() ([]models.Asset, error) {
+			assets, _, err := s.Assets.ListAssets(r.Context(), q)
+			return assets, err
+		}, db.AssetCursor, assetExportRow)
+}
+
+// ExportMappings godoc
+// @Summary Export the mapping history
+// @Description Streams asset mappings, active and closed, as CSV or XLSX. Takes the filters and sort of the mapping lists; every matching mapping is exported. The format is chosen by the format parameter or else the Accept header, and defaults to CSV.
+// @Tags Export
+// @Produce text/csv
+// @Produce application/vnd.openxmlformats-officedocument.spreadsheetml.sheet
+// @Param format query string false "csv or xlsx"
+// @Param employee_id query string false "Only mappings of this employee"
+// @Param asset_id query string false "Only mappings of this asset"
+// @Param status query string false "Only mappings with this status (active, returned, lost, damaged)"
+// @Param assigned_after query string false "Only mappings assigned after this RFC 3339 time"
+// @Param assigned_before query string false "Only mappings assigned before this RFC 3339 time"
+// @Param sort query string false "Sort field (assigned_date, status, mapping_id), prefixed with - for descending order"
+// @Success 200 {file} file
+// @Failure 400 {object} map[string]string
+// @Failure 406 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /export/mappings [get]
+func (s *Server) ExportMappings(w http.ResponseWriter, r *http.Request) {
+	q := db.MappingQuery{
+		EmployeeID: r.URL.Query().Get("employee_id"),
+		AssetID:    r.URL.Query().Get("asset_id"),
+	}
+	if !readMappingQuery(w, r, &q) {
+		return
+	}
+	streamExport(w, r, "mappings", mappingExportColumns, &q.ListOptions,
+		func() ([]models.EmployeeAssetMapping, error) {
+			mappings, _, err := s.Mappings.ListMappings(r.Context(), q)
+			return mappings, err
+		}, db.MappingCursor, mappingExportRow)
+}
@@ -0,0 +1,316 @@
+package controllers
+
+import (
+	"employee-asset-system/db"
+	"employee-asset-system/models"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+// unversionedFields are left out of version snapshots: the password hash is
+// never exposed or rolled back, deletion is undone by restoring instead, and
+// the record version only serves optimistic locking.
+var unversionedFields = []string{"password", "deleted_at", "deleted_by", "version"}
+
+// versionSnapshot derives the snapshot stored for a version from a snapshot
+// taken with auditSnapshot.
+func versionSnapshot(snapshot map[string]interface{}) map[string]interface{} {
+	if snapshot == nil {
+		return nil
+	}
+	versioned := make(map[string]interface{}, len(snapshot))
+	for field, value := range snapshot {
+		versioned[field] = value
+	}
+	for _, field := range unversionedFields {
+		delete(versioned, field)
+	}
+	return versioned
+}
+
+// recordVersion stores after as the next version of a record. A record
+// written before history was kept has none yet; before is then stored first
+// as its baseline so that the change shows up in the history. Like audit,
+// failures are logged because the write itself has already happened.
+func (s *Server) recordVersion(r *http.Request, entityType, entityID, action string, before, after map[string]interface{}, rolledBackTo int) {
+	if before != nil {
+		versions, err := s.Versions.ListVersions(r.Context(), entityType, entityID)
+		if err != nil {
+			log.Printf("Failed to read history of %s %s: %v", entityType, entityID, err)
+			return
+		}
+		if len(versions) == 0 {
+			baseline := &models.RecordVersion{
+				EntityType: entityType,
+				EntityID:   entityID,
+				Action:     models.VersionActionBaseline,
+				Snapshot:   versionSnapshot(before),
+			}
+			// The baseline is attributed to whoever wrote the record last.
+			baseline.ChangedAt, _ = time.Parse(time.RFC3339Nano, stringField(before, "updated_at"))
+			baseline.ChangedBy = stringField(before, "updated_by")
+			if err := s.Versions.AppendVersion(r.Context(), baseline); err != nil {
+				log.Printf("Failed to record baseline of %s %s: %v", entityType, entityID, err)
+				return
+			}
+		}
+	}
+
+	version := &models.RecordVersion{
+		EntityType:   entityType,
+		EntityID:     entityID,
+		Action:       action,
+		RolledBackTo: rolledBackTo,
+		ChangedAt:    time.Now(),
+		ChangedBy:    actorID(r),
+		Snapshot:     versionSnapshot(after),
+	}
+	if err := s.Versions.AppendVersion(r.Context(), version); err != nil {
+		log.Printf("Failed to record version of %s %s: %v", entityType, entityID, err)
+	}
+}
+
+func stringField(snapshot map[string]interface{}, field string) string {
+	value, _ := snapshot[field].(string)
+	return value
+}
+
+// HistoryEntry is one version of a record together with what it changed
+// compared to the version before it.
+type HistoryEntry struct {
+	Version      int                           `json:"version"`
+	Action       string                        `json:"action"`
+	RolledBackTo int                           `json:"rolled_back_to,omitempty"`
+	ChangedAt    time.Time                     `json:"changed_at"`
+	ChangedBy    string                        `json:"changed_by"`
+	Changes      map[string]models.FieldChange `json:"changes"`
+	Snapshot     map[string]interface{}        `json:"snapshot"`
+}
+
+// writeHistory replies with the versions of a record, oldest first.
+func (s *Server) writeHistory(w http.ResponseWriter, r *http.Request, entityType, entityID string) {
+	versions, err := s.Versions.ListVersions(r.Context(), entityType, entityID)
+	if err != nil {
+		http.Error(w, "Failed to fetch history", http.StatusInternalServerError)
+		return
+	}
+
+	history := make([]HistoryEntry, len(versions))
+	var previous map[string]interface{}
+	for i, v := range versions {
+		changes := auditDiff(previous, v.Snapshot)
+		// Who changed the record and when is already part of the entry.
+		delete(changes, "updated_at")
+		delete(changes, "updated_by")
+		history[i] = HistoryEntry{
+			Version:      v.Version,
+			Action:       v.Action,
+			RolledBackTo: v.RolledBackTo,
+			ChangedAt:    v.ChangedAt,
+			ChangedBy:    v.ChangedBy,
+			Changes:      changes,
+			Snapshot:     v.Snapshot,
+		}
+		previous = v.Snapshot
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(history)
+}
+
+// rollbackVersion parses the {version} path parameter and loads that version
+// of a record, replying with an error and returning nil when it cannot.
+func (s *Server) rollbackVersion(w http.ResponseWriter, r *http.Request, entityType, entityID string) *models.RecordVersion {
+	number, err := strconv.Atoi(mux.Vars(r)["version"])
+	if err != nil {
+		http.Error(w, "Invalid version", http.StatusBadRequest)
+		return nil
+	}
+	version, err := s.Versions.GetVersion(r.Context(), entityType, entityID, number)
+	if errors.Is(err, db.ErrNotFound) {
+		http.Error(w, "Version not found", http.StatusNotFound)
+		return nil
+	}
+	if err != nil {
+		http.Error(w, "Failed to fetch version", http.StatusInternalServerError)
+		return nil
+	}
+	return version
+}
+
+// decodeSnapshot fills out from a version snapshot.
+func decodeSnapshot(snapshot map[string]interface{}, out interface{}) error {
+	data, err := json.Marshal(snapshot)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, out)
+}
+
+// GetEmployeeHistory godoc
+// @Summary Get the change history of an employee
+// @Description Lists every version of an employee record, oldest first, with the fields each version changed
+// @Tags Employees
+// @Produce json
+// @Param employeeId path string true "Employee ID"
+// @Success 200 {array} HistoryEntry
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /employee/{employeeId}/history [get]
+func (s *Server) GetEmployeeHistory(w http.ResponseWriter, r *http.Request) {
+	employeeID := mux.Vars(r)["employeeId"]
+
+	if _, err := s.Employees.GetEmployee(r.Context(), employeeID); err != nil {
+		writeStoreError(w, err, "Employee not found", "Failed to fetch history")
+		return
+	}
+	s.writeHistory(w, r, models.AuditEntityEmployee, employeeID)
+}
+
+// RollbackEmployee godoc
+// @Summary Roll an employee back to a previous version
+// @Description Restores the fields of an employee as they were in the given version. The password is left unchanged. The rollback is itself recorded as a new version.
+// @Tags Employees
+// @Produce json
+// @Param employeeId path string true "Employee ID"
+// @Param version path int true "Version to restore"
+// @Param If-Match header string false "ETag the client last read; the write is refused with 412 when the record has changed since"
+// @Success 200 {object} map[string]string
+// @Header 200 {string} ETag "Version of the employee"
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 412 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /employee/{employeeId}/rollback/{version} [post]
+func (s *Server) RollbackEmployee(w http.ResponseWriter, r *http.Request) {
+	employeeID := mux.Vars(r)["employeeId"]
+
+	current, err := s.Employees.GetEmployee(r.Context(), employeeID)
+	if err != nil {
+		writeStoreError(w, err, "Employee not found", "Failed to roll back employee")
+		return
+	}
+	if !checkIfMatch(w, r, current.Version) {
+		return
+	}
+	version := s.rollbackVersion(w, r, models.AuditEntityEmployee, employeeID)
+	if version == nil {
+		return
+	}
+
+	var employee models.Employee
+	if err := decodeSnapshot(version.Snapshot, &employee); err != nil {
+		http.Error(w, "Failed to roll back employee", http.StatusInternalServerError)
+		return
+	}
+	if !validRoles(employee.Roles) {
+		http.Error(w, "Invalid role", http.StatusBadRequest)
+		return
+	}
+
+	// Identity, credentials and provenance are never rolled back.
+	employee.ID = current.ID
+	employee.EmpID = employeeID
+	employee.Password = current.Password
+	employee.CreatedAt = current.CreatedAt
+	employee.CreatedBy = current.CreatedBy
+	employee.Version = current.Version
+	employee.UpdatedAt = time.Now()
+	employee.UpdatedBy = actorID(r)
+
+	if err := s.Employees.UpdateEmployee(r.Context(), &employee); err != nil {
+		writeWriteError(w, r, err, "Employee not found", "Failed to roll back employee")
+		return
+	}
+	before, after := auditSnapshot(current), auditSnapshot(&employee)
+	s.audit(r, models.AuditActionRollback, models.AuditEntityEmployee, employeeID, before, after)
+	s.recordVersion(r, models.AuditEntityEmployee, employeeID, models.AuditActionRollback, before, after, version.Version)
+
+	w.Header().Set("ETag", etag(employee.Version))
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]string{"message": "Employee rolled back successfully"})
+}
+
+// GetAssetHistory godoc
+// @Summary Get the change history of an asset
+// @Description Lists every version of an asset record, oldest first, with the fields each version changed
+// @Tags Assets
+// @Produce json
+// @Param assetId path string true "Asset ID"
+// @Success 200 {array} HistoryEntry
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /asset/{assetId}/history [get]
+func (s *Server) GetAssetHistory(w http.ResponseWriter, r *http.Request) {
+	assetID := mux.Vars(r)["assetId"]
+
+	if _, err := s.Assets.GetAsset(r.Context(), assetID); err != nil {
+		writeStoreError(w, err, "Asset not found", "Failed to fetch history")
+		return
+	}
+	s.writeHistory(w, r, models.AuditEntityAsset, assetID)
+}
+
+// RollbackAsset godoc
+// @Summary Roll an asset back to a previous version
+// @Description Restores the fields of an asset as they were in the given version. The rollback is itself recorded as a new version.
+// @Tags Assets
+// @Produce json
+// @Param assetId path string true "Asset ID"
+// @Param version path int true "Version to restore"
+// @Param If-Match header string false "ETag the client last read; the write is refused with 412 when the record has changed since"
+// @Success 200 {object} map[string]string
+// @Header 200 {string} ETag "Version of the asset"
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 412 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /asset/{assetId}/rollback/{version} [post]
+func (s *Server) RollbackAsset(w http.ResponseWriter, r *http.Request) {
+	assetID := mux.Vars(r)["assetId"]
+
+	current, err := s.Assets.GetAsset(r.Context(), assetID)
+	if err != nil {
+		writeStoreError(w, err, "Asset not found", "Failed to roll back asset")
+		return
+	}
+	if !checkIfMatch(w, r, current.Version) {
+		return
+	}
+	version := s.rollbackVersion(w, r, models.AuditEntityAsset, assetID)
+	if version == nil {
+		return
+	}
+
+	var asset models.Asset
+	if err := decodeSnapshot(version.Snapshot, &asset); err != nil {
+		http.Error(w, "Failed to roll back asset", http.StatusInternalServerError)
+		return
+	}
+
+	asset.ID = current.ID
+	asset.AssetID = assetID
+	asset.CreatedAt = current.CreatedAt
+	asset.CreatedBy = current.CreatedBy
+	asset.Version = current.Version
+	asset.UpdatedAt = time.Now()
+	asset.UpdatedBy = actorID(r)
+
+	if err := s.Assets.UpdateAsset(r.Context(), &asset); err != nil {
+		writeWriteError(w, r, err, "Asset not found", "Failed to roll back asset")
+		return
+	}
+	before, after := auditSnapshot(current), auditSnapshot(&asset)
+	s.audit(r, models.AuditActionRollback, models.AuditEntityAsset, assetID, before, after)
+	s.recordVersion(r, models.AuditEntityAsset, assetID, models.AuditActionRollback, before, after, version.Version)
+
+	w.Header().Set("ETag", etag(asset.Version))
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]string{"message": "Asset rolled back successfully"})
+}
@@ -0,0 +1,387 @@
+package controllers
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"employee-asset-system/db"
+	"employee-asset-system/models"
+	"employee-asset-system/utils"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Import limits.
+const (
+	maxImportBytes = 16 << 20
+	maxImportRows  = 10000
+)
+
+// Import formats.
+const (
+	ImportCSV   = "csv"
+	ImportJSONL = "jsonl"
+)
+
+// errInvalidImport wraps the problems that make a whole import unreadable,
+// as opposed to the errors of single rows, which are reported per row.
+var errInvalidImport = errors.New("invalid import")
+
+// importRow is a record read from an import: the line it starts on, the
+// patch it decoded to and what was wrong with it.
+type importRow[P any] struct {
+	line  int
+	patch P
+	errs  models.ValidationErrors
+}
+
+// ImportRowError lists what is wrong with one row of an import.
+type ImportRowError struct {
+	Line   int                     `json:"line"` // line of the file the row starts on
+	Errors models.ValidationErrors `json:"errors"`
+}
+
+// ImportReport is the outcome of a bulk import. Imports are all or nothing:
+// when any row has errors nothing is created.
+type ImportReport struct {
+	DryRun  bool             `json:"dry_run"`
+	Rows    int              `json:"rows"`
+	Created int              `json:"created"`
+	IDs     []string         `json:"ids,omitempty"` // of the records created, in row order
+	Errors  []ImportRowError `json:"errors"`
+}
+
+func (report *ImportReport) addErrors(line int, errs models.ValidationErrors) {
+	if len(errs) > 0 {
+		report.Errors = append(report.Errors, ImportRowError{Line: line, Errors: errs})
+	}
+}
+
+// importFormat picks the format of an import body from the format query
+// parameter or else the Content-Type header.
+func importFormat(r *http.Request) (string, bool) {
+	if format := r.URL.Query().Get("format"); format != "" {
+		return format, format == ImportCSV || format == ImportJSONL
+	}
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	switch mediaType {
+	case "text/csv":
+		return ImportCSV, true
+	case "application/jsonl", "application/x-ndjson", "application/x-jsonlines":
+		return ImportJSONL, true
+	}
+	return "", false
+}
+
+// readImport reads the rows of an import in format into patches of type P.
+// CSV imports start with a header row naming the json fields of P; empty
+// cells are left unset and list fields are separated by semicolons. JSON
+// Lines imports hold one JSON object per line. Rows that cannot be decoded
+// are returned with their errors.
+func readImport[P any](body io.Reader, format string) ([]importRow[P], error) {
+	var rows []importRow[P]
+	var err error
+	switch format {
+	case ImportCSV:
+		rows, err = readCSVImport[P](body)
+	case ImportJSONL:
+		rows, err = readJSONLImport[P](body)
+	default:
+		return nil, fmt.Errorf("%w: unknown format %q", errInvalidImport, format)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if len(rows) == 0 {
+		return nil, fmt.Errorf("%w: no rows", errInvalidImport)
+	}
+	return rows, nil
+}
+
+func readCSVImport[P any](body io.Reader) ([]importRow[P], error) {
+	reader := csv.NewReader(body)
+	reader.TrimLeadingSpace = true
+	header, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("%w: cannot read the header row: %w", errInvalidImport, err)
+	}
+	var probe P
+	fields := patchFields(&probe)
+	seen := make(map[string]bool, len(header))
+	for i, column := range header {
+		column = strings.TrimSpace(strings.TrimPrefix(column, "\ufeff")) // byte order mark
+		if _, ok := fields[column]; !ok {
+			return nil, fmt.Errorf("%w: unknown column %q", errInvalidImport, column)
+		}
+		if seen[column] {
+			return nil, fmt.Errorf("%w: duplicate column %q", errInvalidImport, column)
+		}
+		seen[column], header[i] = true, column
+	}
+
+	var rows []importRow[P]
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			return rows, nil
+		}
+		line, _ := reader.FieldPos(0)
+		if err != nil && !errors.Is(err, csv.ErrFieldCount) {
+			return nil, fmt.Errorf("%w: %w", errInvalidImport, err)
+		}
+		if len(rows) == maxImportRows {
+			return nil, fmt.Errorf("%w: more than %d rows", errInvalidImport, maxImportRows)
+		}
+		row := importRow[P]{line: line}
+		if err != nil {
+			row.errs.Add("row", fmt.Sprintf("has %d fields instead of %d", len(record), len(header)))
+		} else {
+			row.errs = decodeCSVRecord(header, record, fields, &row.patch)
+		}
+		rows = append(rows, row)
+	}
+}
+
+// decodeCSVRecord decodes the cells of record, named by header, into the
+// patch dst whose fields are fields.
+func decodeCSVRecord(header, record []string, fields map[string]reflect.Value, dst interface{}) models.ValidationErrors {
+	doc := make(map[string]interface{}, len(record))
+	for i, value := range record {
+		if value == "" {
+			continue
+		}
+		switch fields[header[i]].Type().Elem().Kind() {
+		case reflect.String:
+			doc[header[i]] = value
+		case reflect.Slice:
+			items := []string{}
+			for _, item := range strings.Split(value, ";") {
+				if item = strings.TrimSpace(item); item != "" {
+					items = append(items, item)
+				}
+			}
+			doc[header[i]] = items
+		default: // numbers and booleans
+			doc[header[i]] = json.RawMessage(strconv.Quote(value))
+			if json.Valid([]byte(value)) {
+				doc[header[i]] = json.RawMessage(value)
+			}
+		}
+	}
+	data, _ := json.Marshal(doc)
+	errs, _ := decodePatch(data, dst)
+	return errs
+}
+
+func readJSONLImport[P any](body io.Reader) ([]importRow[P], error) {
+	scanner := bufio.NewScanner(body)
+	scanner.Buffer(make([]byte, 64<<10), 1<<20)
+	var rows []importRow[P]
+	for line := 1; scanner.Scan(); line++ {
+		text := bytes.TrimSpace(scanner.Bytes())
+		if len(text) == 0 {
+			continue
+		}
+		if len(rows) == maxImportRows {
+			return nil, fmt.Errorf("%w: more than %d rows", errInvalidImport, maxImportRows)
+		}
+		row := importRow[P]{line: line}
+		errs, err := decodePatch(text, &row.patch)
+		if err != nil {
+			row.errs.Add("row", "must be a JSON object")
+		} else {
+			row.errs = errs
+		}
+		rows = append(rows, row)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("%w: %w", errInvalidImport, err)
+	}
+	return rows, nil
+}
+
+// RunEmployeeImport creates the employees read from body, a CSV or JSON Lines
+// import of models.EmployeePatch rows, on behalf of the caller of r. Every
+// row is validated and checked for emails and phone numbers used by another
+// row or an existing employee; only when none has errors, and unless dryRun
+// is set, are all of them created at once. Errors wrapping errInvalidImport
+// mean body could not be read as an import at all.
+func (s *Server) RunEmployeeImport(r *http.Request, body io.Reader, format string, dryRun bool) (*ImportReport, error) {
+	rows, err := readImport[models.EmployeePatch](body, format)
+	if err != nil {
+		return nil, err
+	}
+	report := &ImportReport{DryRun: dryRun, Rows: len(rows), Errors: []ImportRowError{}}
+
+	for i := range rows {
+		if len(rows[i].errs) == 0 {
+			rows[i].errs = rows[i].patch.Validate(true)
+		}
+	}
+	taken, err := s.takenIdentifiers(r.Context(), rows)
+	if err != nil {
+		return nil, err
+	}
+	emails := make(map[string]int)
+	phones := make(map[string]int)
+	for i := range rows {
+		row := &rows[i]
+		if email := row.patch.EmployeeEmail; email != nil && *email != "" {
+			checkUnique("employee_email", "email:"+strings.ToLower(*email), row.line, emails, taken, &row.errs)
+		}
+		if phone := row.patch.PhoneNumber; phone != nil && *phone != "" {
+			checkUnique("phone_number", "phone:"+*phone, row.line, phones, taken, &row.errs)
+		}
+		report.addErrors(row.line, row.errs)
+	}
+	if dryRun || len(report.Errors) > 0 {
+		return report, nil
+	}
+
+	employees := make([]models.Employee, len(rows))
+	for i, row := range rows {
+		employee := &employees[i]
+		row.patch.Apply(employee)
+		if len(employee.Roles) == 0 {
+			employee.Roles = []string{models.RoleEmployee}
+		}
+		if row.patch.Password != nil {
+			employee.Password = utils.HashPassword(*row.patch.Password)
+		}
+		employee.EmpID = uuid.New().String()
+		employee.CreatedAt = time.Now()
+		employee.UpdatedAt = employee.CreatedAt
+		employee.CreatedBy = actorID(r)
+		employee.UpdatedBy = employee.CreatedBy
+	}
+	if err := s.Employees.CreateEmployees(r.Context(), employees); err != nil {
+		return nil, err
+	}
+	for i := range employees {
+		after := auditSnapshot(&employees[i])
+		s.audit(r, models.AuditActionCreate, models.AuditEntityEmployee, employees[i].EmpID, nil, after)
+		s.recordVersion(r, models.AuditEntityEmployee, employees[i].EmpID, models.AuditActionCreate, nil, after, 0)
+		report.IDs = append(report.IDs, employees[i].EmpID)
+	}
+	report.Created = len(employees)
+	return report, nil
+}
+
+// takenIdentifiers looks up, in one go, which of the emails and phone
+// numbers of rows existing employees use. Emails are lowercased and keyed
+// "email:", phone numbers keyed "phone:".
+func (s *Server) takenIdentifiers(ctx context.Context, rows []importRow[models.EmployeePatch]) (map[string]bool, error) {
+	var emails, phones []string
+	for _, row := range rows {
+		if email := row.patch.EmployeeEmail; email != nil && *email != "" {
+			emails = append(emails, strings.ToLower(*email))
+		}
+		if phone := row.patch.PhoneNumber; phone != nil && *phone != "" {
+			phones = append(phones, *phone)
+		}
+	}
+	existing, err := s.Employees.EmployeesByIdentifier(ctx, emails, phones)
+	if err != nil {
+		return nil, err
+	}
+	taken := make(map[string]bool, 2*len(existing))
+	for _, employee := range existing {
+		taken["email:"+strings.ToLower(employee.EmployeeEmail)] = true
+		taken["phone:"+employee.PhoneNumber] = true
+	}
+	return taken, nil
+}
+
+// checkUnique records in errs when key, the normalised value of field, is
+// already used by an earlier row of the import, tracked in seen, or by an
+// existing employee, listed in taken.
+func checkUnique(field, key string, line int, seen map[string]int, taken map[string]bool, errs *models.ValidationErrors) {
+	if first, ok := seen[key]; ok {
+		errs.Add(field, fmt.Sprintf("is also used on line %d", first))
+		return
+	}
+	seen[key] = line
+	if taken[key] {
+		errs.Add(field, "is already used by another employee")
+	}
+}
+
+// ImportEmployees godoc
+// @Summary Import employees in bulk
+// @Description Creates employees from a CSV file, whose header row names the fields of EmployeePatch (roles separated by semicolons), or from JSON Lines of EmployeePatch objects. Every row is validated and checked for emails and phone numbers used twice or by an existing employee. The import is all or nothing: with any row in error nothing is created and the errors are reported per line with 422. A standalone MongoDB server has no transactions to run it in and replies 501. A dry run only reports the errors.
+// @Tags Employees
+// @Accept text/csv,application/jsonl
+// @Produce json
+// @Param format query string false "csv or jsonl; defaults to the Content-Type"
+// @Param dry_run query bool false "Only validate the rows"
+// @Param body body string true "CSV or JSON Lines rows"
+// @Success 200 {object} ImportReport "Dry run report"
+// @Success 201 {object} ImportReport
+// @Failure 400 {object} map[string]string
+// @Failure 413 {object} map[string]string
+// @Failure 415 {object} map[string]string
+// @Failure 422 {object} ImportReport
+// @Failure 500 {object} map[string]string
+// @Failure 501 {object} map[string]string
+// @Router /employee/import [post]
+func (s *Server) ImportEmployees(w http.ResponseWriter, r *http.Request) {
+	s.serveImport(w, r, s.RunEmployeeImport)
+}
+
+// serveImport runs an import of the request body with run and replies with
+// its report.
+func (s *Server) serveImport(w http.ResponseWriter, r *http.Request,
+	run func(r *http.Request, body io.Reader, format string, dryRun bool) (*ImportReport, error)) {
+	format, ok := importFormat(r)
+	if !ok {
+		http.Error(w, "Unsupported import format; send text/csv or application/jsonl", http.StatusUnsupportedMediaType)
+		return
+	}
+	dryRun := false
+	if value := r.URL.Query().Get("dry_run"); value != "" {
+		var err error
+		if dryRun, err = strconv.ParseBool(value); err != nil {
+			http.Error(w, "Invalid dry_run", http.StatusBadRequest)
+			return
+		}
+	}
+
+	report, err := run(r, http.MaxBytesReader(w, r.Body, maxImportBytes), format, dryRun)
+	var tooLarge *http.MaxBytesError
+	switch {
+	case errors.As(err, &tooLarge):
+		http.Error(w, "Import too large", http.StatusRequestEntityTooLarge)
+		return
+	case errors.Is(err, errInvalidImport):
+		http.Error(w, "Invalid import: "+strings.TrimPrefix(err.Error(), errInvalidImport.Error()+": "), http.StatusBadRequest)
+		return
+	case errors.Is(err, db.ErrNoTransactions):
+		http.Error(w, "Imports are not supported by this database", http.StatusNotImplemented)
+		return
+	case err != nil:
+		http.Error(w, "Failed to import", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	switch {
+	case len(report.Errors) > 0 && !dryRun:
+		w.WriteHeader(http.StatusUnprocessableEntity)
+	case dryRun:
+		w.WriteHeader(http.StatusOK)
+	default:
+		w.WriteHeader(http.StatusCreated)
+	}
+	json.NewEncoder(w).Encode(report)
+}
@@ -0,0 +1,142 @@
+package controllers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"testing"
+
+	"employee-asset-system/db"
+)
+
+// employeeCount returns the number of live employees in store.
+func employeeCount(t *testing.T, store *db.MemoryStore) int64 {
+	t.Helper()
+	_, total, err := store.Dashboard(context.Background(), db.EmployeeQuery{})
+	if err != nil {
+		t.Fatalf("Dashboard: %v", err)
+	}
+	return total
+}
+
+func TestImportEmployees(t *testing.T) {
+	const header = "first_name,last_name,employee_email,phone_number,roles\n"
+	tests := []struct {
+		name        string
+		contentType string
+		query       string
+		body        string
+		code        int
+		errors      map[int][]string // fields in error by line
+		created     int
+	}{
+		{
+			name:        "valid csv",
+			contentType: "text/csv",
+			body:        header + "Ada,Lovelace,ada@example.com,+15551230001,admin;employee\nAlan,Turing,alan@example.com,,\n",
+			code:        http.StatusCreated,
+			created:     2,
+		},
+		{
+			name:        "dry run",
+			contentType: "text/csv",
+			query:       "?dry_run=true",
+			body:        header + "Ada,Lovelace,ada@example.com,,\n",
+			code:        http.StatusOK,
+		},
+		{
+			name:        "invalid fields",
+			contentType: "text/csv",
+			body:        header + "Ada,,ada@example.com,,\nAlan,Turing,not-an-email,12345,\nGrace,Hopper,,,pilot\n",
+			code:        http.StatusUnprocessableEntity,
+			errors:      map[int][]string{2: {"last_name"}, 3: {"phone_number", "employee_email"}, 4: {"roles"}},
+		},
+		{
+			name:        "duplicates within the file",
+			contentType: "text/csv",
+			body:        header + "Ada,Lovelace,Ada@Example.com,+15551230001,\nAda,King,ada@example.COM,+15551230001,\n",
+			code:        http.StatusUnprocessableEntity,
+			errors:      map[int][]string{3: {"employee_email", "phone_number"}},
+		},
+		{
+			name:        "used by existing employees",
+			contentType: "text/csv",
+			body:        header + "Ada,Lovelace,E1@EXAMPLE.COM,+15550000002,\n",
+			code:        http.StatusUnprocessableEntity,
+			errors:      map[int][]string{2: {"employee_email", "phone_number"}},
+		},
+		{
+			name:        "wrong number of fields",
+			contentType: "text/csv",
+			body:        header + "Ada,Lovelace,,,\nAlan,Turing\n",
+			code:        http.StatusUnprocessableEntity,
+			errors:      map[int][]string{3: {"row"}},
+		},
+		{
+			name:        "valid jsonl",
+			contentType: "application/jsonl",
+			body:        `{"first_name":"Ada","last_name":"Lovelace","roles":["manager"]}` + "\n\n" + `{"first_name":"Alan","last_name":"Turing"}` + "\n",
+			code:        http.StatusCreated,
+			created:     2,
+		},
+		{
+			name:        "invalid jsonl",
+			contentType: "application/jsonl",
+			body:        `{"first_name":"Ada","last_name":"Lovelace"}` + "\n[1]\n\n" + `{"first_name":"Alan","last_name":"Turing","gender":"robot"}` + "\n",
+			code:        http.StatusUnprocessableEntity,
+			errors:      map[int][]string{2: {"row"}, 4: {"gender"}},
+		},
+		{
+			name:        "format from the query",
+			contentType: "text/plain",
+			query:       "?format=jsonl",
+			body:        `{"first_name":"Ada","last_name":"Lovelace"}`,
+			code:        http.StatusCreated,
+			created:     1,
+		},
+		{name: "unknown column", contentType: "text/csv", body: "first_name,salary\nAda,1\n", code: http.StatusBadRequest},
+		{name: "duplicate column", contentType: "text/csv", body: "first_name,first_name\nAda,Ada\n", code: http.StatusBadRequest},
+		{name: "no rows", contentType: "text/csv", body: header, code: http.StatusBadRequest},
+		{name: "bad dry_run", contentType: "text/csv", query: "?dry_run=maybe", body: header, code: http.StatusBadRequest},
+		{name: "unsupported format", contentType: "application/json", body: "{}", code: http.StatusUnsupportedMediaType},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, store := newTestServer(t)
+			before := employeeCount(t, store)
+
+			w := serve(s.ImportEmployees, "POST", "/api/employee/import"+tt.query, tt.body, "Content-Type", tt.contentType)
+			if w.Code != tt.code {
+				t.Fatalf("status code = %d, want %d: %s", w.Code, tt.code, w.Body)
+			}
+			if got := employeeCount(t, store) - before; got != int64(tt.created) {
+				t.Errorf("%d employees created, want %d", got, tt.created)
+			}
+			if w.Code >= http.StatusBadRequest && w.Code != http.StatusUnprocessableEntity {
+				return
+			}
+
+			var report ImportReport
+			if err := json.NewDecoder(w.Body).Decode(&report); err != nil {
+				t.Fatalf("decoding the report: %v", err)
+			}
+			if report.Created != tt.created || len(report.IDs) != tt.created {
+				t.Errorf("report created %d with IDs %v, want %d", report.Created, report.IDs, tt.created)
+			}
+			got := make(map[int][]string)
+			for _, rowErr := range report.Errors {
+				for _, fieldErr := range rowErr.Errors {
+					got[rowErr.Line] = append(got[rowErr.Line], fieldErr.Field)
+				}
+			}
+			want := tt.errors
+			if want == nil {
+				want = map[int][]string{}
+			}
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("errors by line = %v, want %v", got, want)
+			}
+		})
+	}
+}
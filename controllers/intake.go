@@ -0,0 +1,255 @@
+package controllers
+
+import (
+	"context"
+	"crypto/rand"
+	"employee-asset-system/db"
+	"employee-asset-system/models"
+	"encoding/base32"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Intake limits.
+const (
+	maxIntakeBytes = 1 << 20
+	maxIntakeUnits = 1000 // serial numbers of a single manifest
+	// maxTagAttempts bounds how often the asset tags of an intake are
+	// generated again after one of them turned out to be taken.
+	maxTagAttempts = 3
+)
+
+// defaultTagPrefix starts the asset tags of manifests that name no prefix.
+const defaultTagPrefix = "AT"
+
+var tagPrefix = regexp.MustCompile(`^[A-Z0-9]{1,10}$`)
+
+// IntakeManifest describes a shipment of identical assets, one per serial
+// number.
+type IntakeManifest struct {
+	AssetType      string   `json:"asset_type"`
+	AssetName      string   `json:"asset_name"` // defaults to the manufacturer, or else the vendor, and model
+	Manufacturer   string   `json:"manufacturer"`
+	Model          string   `json:"model"`
+	Vendor         string   `json:"vendor"`
+	InvoiceNumber  string   `json:"invoice_number"`
+	PurchaseDate   string   `json:"purchase_date"`   // YYYY-MM-DD
+	WarrantyExpiry string   `json:"warranty_expiry"` // YYYY-MM-DD
+	PurchaseCost   float64  `json:"purchase_cost"`   // per unit
+	Currency       string   `json:"currency"`
+	Shared         bool     `json:"shared"`
+	TagPrefix      string   `json:"tag_prefix"` // defaults to AT
+	SerialNumbers  []string `json:"serial_numbers"`
+}
+
+// Validate checks m, trimming its serial numbers, and returns the asset
+// fields shared by every unit. The purchase details are checked like those
+// of AssetPatch.
+func (m *IntakeManifest) Validate() (models.AssetPatch, models.ValidationErrors) {
+	var errs models.ValidationErrors
+	for _, f := range []struct {
+		field string
+		value *string
+	}{{"vendor", &m.Vendor}, {"model", &m.Model}} {
+		if *f.value = strings.TrimSpace(*f.value); *f.value == "" {
+			errs.Add(f.field, "is required")
+		}
+	}
+	if m.TagPrefix != "" && !tagPrefix.MatchString(m.TagPrefix) {
+		errs.Add("tag_prefix", "must be 1 to 10 uppercase letters or digits")
+	}
+
+	name := m.AssetName
+	if strings.TrimSpace(name) == "" {
+		maker := m.Manufacturer
+		if maker == "" {
+			maker = m.Vendor
+		}
+		name = maker + " " + m.Model
+	}
+	patch := models.AssetPatch{
+		AssetName:      &name,
+		AssetType:      &m.AssetType,
+		Shared:         &m.Shared,
+		PurchaseCost:   &m.PurchaseCost,
+		Currency:       &m.Currency,
+		Manufacturer:   &m.Manufacturer,
+		Model:          &m.Model,
+		Vendor:         &m.Vendor,
+		InvoiceNumber:  &m.InvoiceNumber,
+		PurchaseDate:   &m.PurchaseDate,
+		WarrantyExpiry: &m.WarrantyExpiry,
+	}
+	errs = append(errs, patch.Validate(true)...)
+
+	switch {
+	case len(m.SerialNumbers) == 0:
+		errs.Add("serial_numbers", "is required")
+	case len(m.SerialNumbers) > maxIntakeUnits:
+		errs.Add("serial_numbers", fmt.Sprintf("must list at most %d units", maxIntakeUnits))
+	}
+	seen := make(map[string]bool, len(m.SerialNumbers))
+	for i, serial := range m.SerialNumbers {
+		serial = strings.TrimSpace(serial)
+		m.SerialNumbers[i] = serial
+		switch {
+		case !models.IsValidSerialNumber(serial):
+			errs.Add("serial_numbers", fmt.Sprintf("has an invalid serial number at index %d", i))
+		case seen[serial]:
+			errs.Add("serial_numbers", "lists "+serial+" more than once")
+		}
+		seen[serial] = true
+	}
+	return patch, errs
+}
+
+// IntakeUnit identifies one asset of an intake.
+type IntakeUnit struct {
+	AssetID      string `json:"asset_id"`
+	AssetTag     string `json:"asset_tag"`
+	SerialNumber string `json:"serial_number"`
+}
+
+// IntakeReport lists the assets an intake created and the units it skipped
+// because an asset with their serial number already exists.
+type IntakeReport struct {
+	Created  []IntakeUnit `json:"created"`
+	Existing []IntakeUnit `json:"existing"`
+}
+
+// newAssetTag returns a random asset tag starting with prefix.
+func newAssetTag(prefix string) (string, error) {
+	b := make([]byte, 5)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return prefix + "-" + base32.StdEncoding.EncodeToString(b), nil
+}
+
+// createWithTags gives each of assets a new tag starting with prefix and
+// creates them. Tags are random, so when the store reports a conflict, which
+// may be a tag already taken, they are generated again a few times before
+// the conflict is returned.
+func (s *Server) createWithTags(ctx context.Context, assets []models.Asset, prefix string) error {
+	var err error
+	for attempt := 0; attempt < maxTagAttempts; attempt++ {
+		for i := range assets {
+			if assets[i].AssetTag, err = newAssetTag(prefix); err != nil {
+				return err
+			}
+		}
+		if err = s.Assets.CreateAssets(ctx, assets); !errors.Is(err, db.ErrConflict) {
+			return err
+		}
+	}
+	return err
+}
+
+// IntakeAssets godoc
+// @Summary Receive a shipment of assets
+// @Description Creates one asset per serial number of a purchase manifest, each with a generated asset tag. Serial numbers that already belong to an asset, deleted ones included, are skipped and reported under existing. Replies 409 when every unit already exists. The units are created all or nothing, so a standalone MongoDB server, which has no transactions, replies 501.
+// @Tags Assets
+// @Accept json
+// @Produce json
+// @Param manifest body IntakeManifest true "Purchase manifest"
+// @Success 201 {object} IntakeReport
+// @Failure 400 {object} map[string]string
+// @Failure 409 {object} IntakeReport
+// @Failure 413 {object} map[string]string
+// @Failure 422 {object} ValidationErrorResponse
+// @Failure 500 {object} map[string]string
+// @Failure 501 {object} map[string]string
+// @Router /asset/intake [post]
+func (s *Server) IntakeAssets(w http.ResponseWriter, r *http.Request) {
+	doc, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxIntakeBytes))
+	var tooLarge *http.MaxBytesError
+	switch {
+	case errors.As(err, &tooLarge):
+		http.Error(w, "Manifest too large", http.StatusRequestEntityTooLarge)
+		return
+	case err != nil:
+		http.Error(w, "Invalid input", http.StatusBadRequest)
+		return
+	}
+	var manifest IntakeManifest
+	errs, err := decodePatch(doc, &manifest)
+	if err != nil {
+		http.Error(w, "Invalid input", http.StatusBadRequest)
+		return
+	}
+	var fields models.AssetPatch
+	if len(errs) == 0 {
+		fields, errs = manifest.Validate()
+	}
+	if len(errs) > 0 {
+		writeValidationErrors(w, errs)
+		return
+	}
+
+	existing, err := s.Assets.AssetsBySerial(r.Context(), manifest.SerialNumbers)
+	if err != nil {
+		http.Error(w, "Failed to look up serial numbers", http.StatusInternalServerError)
+		return
+	}
+	report := IntakeReport{Created: []IntakeUnit{}, Existing: []IntakeUnit{}}
+	known := make(map[string]bool, len(existing))
+	for _, asset := range existing {
+		known[asset.SerialNumber] = true
+		report.Existing = append(report.Existing, IntakeUnit{asset.AssetID, asset.AssetTag, asset.SerialNumber})
+	}
+
+	prefix := manifest.TagPrefix
+	if prefix == "" {
+		prefix = defaultTagPrefix
+	}
+	now := time.Now()
+	var assets []models.Asset
+	for _, serial := range manifest.SerialNumbers {
+		if known[serial] {
+			continue
+		}
+		asset := models.Asset{
+			AssetID:      uuid.New().String(),
+			SerialNumber: serial,
+			CreatedAt:    now,
+			UpdatedAt:    now,
+			CreatedBy:    actorID(r),
+			UpdatedBy:    actorID(r),
+		}
+		fields.Apply(&asset)
+		assets = append(assets, asset)
+	}
+
+	if len(assets) == 0 {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusConflict)
+		json.NewEncoder(w).Encode(report)
+		return
+	}
+	err = s.createWithTags(r.Context(), assets, prefix)
+	switch {
+	case errors.Is(err, db.ErrNoTransactions):
+		http.Error(w, "Intake is not supported by this database", http.StatusNotImplemented)
+		return
+	case err != nil:
+		writeStoreError(w, err, "", "Failed to create assets")
+		return
+	}
+	for i := range assets {
+		after := auditSnapshot(&assets[i])
+		s.audit(r, models.AuditActionCreate, models.AuditEntityAsset, assets[i].AssetID, nil, after)
+		s.recordVersion(r, models.AuditEntityAsset, assets[i].AssetID, models.AuditActionCreate, nil, after, 0)
+		report.Created = append(report.Created, IntakeUnit{assets[i].AssetID, assets[i].AssetTag, assets[i].SerialNumber})
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(report)
+}
@@ -0,0 +1,116 @@
+package controllers
+
+import (
+	"employee-asset-system/db"
+	"employee-asset-system/models"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	defaultPageSize = 50
+	maxPageSize     = 200
+)
+
+// pageToken is what the opaque page tokens handed to clients encode: the
+// cursor of the last record of a page and the order it was listed in, which
+// the next page must keep.
+type pageToken struct {
+	Sort string `json:"s"`
+	Desc bool   `json:"d,omitempty"`
+	db.Cursor
+}
+
+// readListOptions parses the page_size, page_token and sort query parameters
+// into opts. sort is one of fields, prefixed with "-" for descending order,
+// and defaults to the first of them. It writes a 400 reply and returns false
+// when one of them is invalid. opts.Limit is set one past the page size so
+// the caller can tell whether there is a next page.
+func readListOptions(w http.ResponseWriter, r *http.Request, fields []string, opts *db.ListOptions) bool {
+	query := r.URL.Query()
+
+	pageSize := defaultPageSize
+	if value := query.Get("page_size"); value != "" {
+		var err error
+		if pageSize, err = strconv.Atoi(value); err != nil || pageSize < 1 || pageSize > maxPageSize {
+			http.Error(w, "Invalid page_size", http.StatusBadRequest)
+			return false
+		}
+	}
+	opts.Limit = pageSize + 1
+
+	opts.Sort = fields[0]
+	if value := query.Get("sort"); value != "" {
+		opts.Desc = strings.HasPrefix(value, "-")
+		opts.Sort = strings.TrimPrefix(value, "-")
+		if !isOneOf(opts.Sort, fields) {
+			http.Error(w, "Invalid sort; use one of "+strings.Join(fields, ", ")+", prefixed with - for descending order", http.StatusBadRequest)
+			return false
+		}
+	}
+
+	if value := query.Get("page_token"); value != "" {
+		var token pageToken
+		data, err := base64.RawURLEncoding.DecodeString(value)
+		if err == nil {
+			err = json.Unmarshal(data, &token)
+		}
+		if err != nil || token.Sort != opts.Sort || token.Desc != opts.Desc {
+			http.Error(w, "Invalid page_token", http.StatusBadRequest)
+			return false
+		}
+		opts.After = &token.Cursor
+	}
+	return true
+}
+
+// nextPageToken returns the token of the page following the one ending with
+// the record whose cursor is last.
+func nextPageToken(opts db.ListOptions, last db.Cursor) string {
+	data, _ := json.Marshal(pageToken{Sort: opts.Sort, Desc: opts.Desc, Cursor: last})
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// readTimeParam parses the RFC 3339 time or date in the query parameter name
+// into t, leaving t zero when the parameter is absent. It writes a 400 reply and
+// returns false when the time is invalid.
+func readTimeParam(w http.ResponseWriter, r *http.Request, name string, t *time.Time) bool {
+	value := r.URL.Query().Get(name)
+	if value == "" {
+		return true
+	}
+	if date, ok := models.ParseDate(value); ok {
+		*t = date
+		return true
+	}
+	var err error
+	if *t, err = time.Parse(time.RFC3339, value); err != nil {
+		http.Error(w, "Invalid "+name+" time", http.StatusBadRequest)
+		return false
+	}
+	return true
+}
+
+// writeListError replies 400 for a page token the store rejected and 500
+// for anything else.
+func writeListError(w http.ResponseWriter, err error, failed string) {
+	if errors.Is(err, db.ErrInvalidCursor) || errors.Is(err, db.ErrInvalidSort) {
+		http.Error(w, "Invalid page_token", http.StatusBadRequest)
+		return
+	}
+	http.Error(w, failed, http.StatusInternalServerError)
+}
+
+func isOneOf(value string, values []string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -4,98 +4,402 @@ import (
 	"employee-asset-system/db"
 	"employee-asset-system/models"
 	"encoding/json"
-	"fmt"
+	"errors"
+	"io"
 	"net/http"
 	"time"
 
 	"github.com/google/uuid"
 	"github.com/gorilla/mux"
-	"go.mongodb.org/mongo-driver/bson"
 )
 
+// AssignRequest is the body of AssignAssetMapping. Everything else about the
+// mapping is set by the server.
+type AssignRequest struct {
+	EmployeeID   string     `json:"employee_id"`
+	AssetID      string     `json:"asset_id"`
+	AssignedDate *time.Time `json:"assigned_date,omitempty"` // defaults to now
+	Notes        string     `json:"notes,omitempty"`
+}
+
 // AssignAssetMapping godoc
 // @Summary Assign an asset to an employee
-// @Description Assigns a new asset to an employee
+// @Description Checks an asset out to an employee by creating an active mapping. Unless the asset is shared, it must not already be checked out. The assignment date defaults to now and may not lie in the future.
 // @Tags Asset Mapping
 // @Accept json
 // @Produce json
-// @Param mapping body models.EmployeeAssetMapping true "Asset mapping data"
+// @Param mapping body AssignRequest true "Employee and asset to map"
 // @Success 201 {object} map[string]string
+// @Header 201 {string} ETag "Version of the mapping"
 // @Failure 400 {object} map[string]string
+// @Failure 409 {object} map[string]string
 // @Failure 500 {object} map[string]string
 // @Router /asset-mapping [post]
-func AssignAssetMapping(w http.ResponseWriter, r *http.Request) {
-	var mapping models.EmployeeAssetMapping
-	if err := json.NewDecoder(r.Body).Decode(&mapping); err != nil {
+func (s *Server) AssignAssetMapping(w http.ResponseWriter, r *http.Request) {
+	var req AssignRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 		http.Error(w, "Invalid input", http.StatusBadRequest)
 		return
 	}
-	fmt.Println("here    ", mapping.EmployeeID)
-
-	mapping.MappingID = uuid.New().String()
-	mapping.AssignedDate = time.Now()
-	mapping.Status = "active"
+	now := time.Now()
+	if req.AssignedDate != nil && req.AssignedDate.After(now) {
+		http.Error(w, "assigned_date may not be in the future", http.StatusBadRequest)
+		return
+	}
 
-	_, err := db.Database.Collection("mapping").InsertOne(r.Context(), mapping)
+	if _, err := s.Employees.GetEmployee(r.Context(), req.EmployeeID); err != nil {
+		if errors.Is(err, db.ErrNotFound) {
+			http.Error(w, "Employee not found", http.StatusBadRequest)
+			return
+		}
+		http.Error(w, "Failed to assign asset mapping", http.StatusInternalServerError)
+		return
+	}
+	asset, err := s.Assets.GetAsset(r.Context(), req.AssetID)
 	if err != nil {
+		if errors.Is(err, db.ErrNotFound) {
+			http.Error(w, "Asset not found", http.StatusBadRequest)
+			return
+		}
 		http.Error(w, "Failed to assign asset mapping", http.StatusInternalServerError)
 		return
 	}
 
+	mapping := models.EmployeeAssetMapping{
+		MappingID:    uuid.New().String(),
+		EmployeeID:   req.EmployeeID,
+		AssetID:      req.AssetID,
+		AssignedDate: now,
+		AssignedBy:   actorID(r),
+		Status:       models.MappingStatusActive,
+		Notes:        req.Notes,
+		Exclusive:    !asset.Shared,
+	}
+	if req.AssignedDate != nil {
+		mapping.AssignedDate = *req.AssignedDate
+	}
+
+	if err := s.Mappings.CreateMapping(r.Context(), &mapping); err != nil {
+		if errors.Is(err, db.ErrNotFound) {
+			// Deleted since it was looked up.
+			http.Error(w, "Employee or asset not found", http.StatusBadRequest)
+			return
+		}
+		if errors.Is(err, db.ErrConflict) {
+			http.Error(w, "Asset is already assigned to another employee", http.StatusConflict)
+			return
+		}
+		http.Error(w, "Failed to assign asset mapping", http.StatusInternalServerError)
+		return
+	}
+	s.audit(r, models.AuditActionAssign, models.AuditEntityMapping, mapping.MappingID, nil, auditSnapshot(&mapping))
+
+	w.Header().Set("ETag", etag(mapping.Version))
 	w.WriteHeader(http.StatusCreated)
 	json.NewEncoder(w).Encode(map[string]string{"message": "Asset mapping assigned successfully"})
 }
 
+// MappingPage is one page of GetAllAssetsMappedToEmployee results.
+type MappingPage struct {
+	Mappings      []models.EmployeeAssetMapping `json:"mappings"`
+	NextPageToken string                        `json:"next_page_token,omitempty"` // empty on the last page
+	Total         int64                         `json:"total"`
+}
+
 // GetAllAssetsMappedToEmployee godoc
 // @Summary Get all assets mapped to an employee
-// @Description Fetches all asset mappings for a specific employee
+// @Description Lists the asset mappings of an employee a page at a time, including returned ones unless filtered by status. Pass the next_page_token of a page as page_token, with the same sort, to fetch the one after it.
 // @Tags Asset Mapping
 // @Produce json
 // @Param employeeId path string true "Employee ID"
-// @Success 200 {array} models.EmployeeAssetMapping
+// @Param status query string false "Only mappings with this status (active, returned, lost, damaged)"
+// @Param assigned_after query string false "Only mappings assigned after this RFC 3339 time"
+// @Param assigned_before query string false "Only mappings assigned before this RFC 3339 time"
+// @Param sort query string false "Sort field (assigned_date, status, mapping_id), prefixed with - for descending order"
+// @Param page_size query int false "Mappings per page (default 50, at most 200)"
+// @Param page_token query string false "Token of the page to fetch"
+// @Success 200 {object} MappingPage
+// @Failure 400 {object} map[string]string
 // @Failure 500 {object} map[string]string
 // @Router /asset-mapping/employee/{employeeId} [get]
-func GetAllAssetsMappedToEmployee(w http.ResponseWriter, r *http.Request) {
-	employeeID := mux.Vars(r)["employeeId"]
+func (s *Server) GetAllAssetsMappedToEmployee(w http.ResponseWriter, r *http.Request) {
+	q := db.MappingQuery{EmployeeID: mux.Vars(r)["employeeId"]}
+	if !readMappingQuery(w, r, &q) {
+		return
+	}
 
-	cursor, err := db.Database.Collection("mapping").Find(r.Context(), bson.M{"employee_id": employeeID})
+	mappings, total, err := s.Mappings.ListMappings(r.Context(), q)
 	if err != nil {
-		http.Error(w, "Failed to fetch mappings", http.StatusInternalServerError)
+		writeListError(w, err, "Failed to fetch mappings")
 		return
 	}
-	defer cursor.Close(r.Context())
+	page := MappingPage{Mappings: mappings, Total: total}
+	if pageSize := q.Limit - 1; len(mappings) > pageSize {
+		page.Mappings = mappings[:pageSize]
+		page.NextPageToken = nextPageToken(q.ListOptions, db.MappingCursor(&mappings[pageSize-1], q.Sort))
+	}
+	if page.Mappings == nil {
+		page.Mappings = []models.EmployeeAssetMapping{}
+	}
 
-	var mappings []models.EmployeeAssetMapping
-	for cursor.Next(r.Context()) {
-		var mapping models.EmployeeAssetMapping
-		if err := cursor.Decode(&mapping); err == nil {
-			mappings = append(mappings, mapping)
-		}
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(page)
+}
+
+// readMappingQuery parses the status and date filters, sort and page of a
+// mapping listing into q. It writes a 400 reply and returns false when one
+// of them is invalid.
+func readMappingQuery(w http.ResponseWriter, r *http.Request, q *db.MappingQuery) bool {
+	q.Status = r.URL.Query().Get("status")
+	return readTimeParam(w, r, "assigned_after", &q.AssignedAfter) &&
+		readTimeParam(w, r, "assigned_before", &q.AssignedBefore) &&
+		readListOptions(w, r, db.MappingSortFields, &q.ListOptions)
+}
+
+// GetMappingById godoc
+// @Summary Get an asset mapping by ID
+// @Description Fetches a single asset mapping together with its ETag
+// @Tags Asset Mapping
+// @Produce json
+// @Param mappingId path string true "Mapping ID"
+// @Param If-None-Match header string false "ETag of the copy the client holds"
+// @Success 200 {object} models.EmployeeAssetMapping
+// @Success 304 "Not modified"
+// @Header 200 {string} ETag "Version of the mapping"
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /mapping/mapping/{mappingId} [get]
+func (s *Server) GetMappingById(w http.ResponseWriter, r *http.Request) {
+	mappingID := mux.Vars(r)["mappingId"]
+
+	mapping, err := s.Mappings.GetMapping(r.Context(), mappingID)
+	if err != nil {
+		writeStoreError(w, err, "Asset mapping not found", "Failed to fetch asset mapping")
+		return
+	}
+	if !checkIfNoneMatch(w, r, mapping.Version) {
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(mapping)
+}
+
+// GetAssetMappingHistory godoc
+// @Summary Get the assignment history of an asset
+// @Description Fetches every mapping of an asset, oldest first, showing who held it and how it came back
+// @Tags Asset Mapping
+// @Produce json
+// @Param assetId path string true "Asset ID"
+// @Param status query string false "Only mappings with this status (active, returned, lost, damaged)"
+// @Success 200 {array} models.EmployeeAssetMapping
+// @Failure 500 {object} map[string]string
+// @Router /mapping/assethistory/{assetId} [get]
+func (s *Server) GetAssetMappingHistory(w http.ResponseWriter, r *http.Request) {
+	assetID := mux.Vars(r)["assetId"]
+
+	mappings, err := s.Mappings.ListMappingsByAsset(r.Context(), assetID, r.URL.Query().Get("status"))
+	if err != nil {
+		http.Error(w, "Failed to fetch mappings", http.StatusInternalServerError)
+		return
 	}
 
 	w.WriteHeader(http.StatusOK)
 	json.NewEncoder(w).Encode(mappings)
 }
 
+// ReturnRequest is the body of ReturnAssetMapping.
+type ReturnRequest struct {
+	Status          string `json:"status"` // returned (default), lost or damaged
+	ReturnCondition string `json:"return_condition"`
+	Reason          string `json:"reason"`
+}
+
+// ReturnAssetMapping godoc
+// @Summary Return an assigned asset
+// @Description Closes an active mapping, recording when and by whom the asset was returned, its condition and whether it came back, was lost or damaged
+// @Tags Asset Mapping
+// @Accept json
+// @Produce json
+// @Param mappingId path string true "Mapping ID"
+// @Param request body ReturnRequest false "Return details"
+// @Param If-Match header string false "ETag the client last read; the write is refused with 412 when the record has changed since"
+// @Success 200 {object} models.EmployeeAssetMapping
+// @Header 200 {string} ETag "Version of the mapping"
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Failure 412 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /mapping/returnasset/{mappingId} [post]
+func (s *Server) ReturnAssetMapping(w http.ResponseWriter, r *http.Request) {
+	mappingID := mux.Vars(r)["mappingId"]
+
+	// The body is optional; an empty one records a plain return.
+	var req ReturnRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && !errors.Is(err, io.EOF) {
+		http.Error(w, "Invalid input", http.StatusBadRequest)
+		return
+	}
+	if req.Status == "" {
+		req.Status = models.MappingStatusReturned
+	}
+	if !models.IsClosedMappingStatus(req.Status) {
+		http.Error(w, "Invalid status", http.StatusBadRequest)
+		return
+	}
+
+	before, err := s.Mappings.GetMapping(r.Context(), mappingID)
+	if err != nil {
+		writeStoreError(w, err, "Asset mapping not found", "Failed to return asset")
+		return
+	}
+	if !checkIfMatch(w, r, before.Version) {
+		return
+	}
+
+	ret := models.MappingReturn{
+		Status:       req.Status,
+		ReturnedDate: time.Now(),
+		ReturnedBy:   actorID(r),
+		Condition:    req.ReturnCondition,
+		Reason:       req.Reason,
+	}
+	if r.Header.Get("If-Match") != "" {
+		ret.IfVersion = before.Version
+	}
+	mapping, err := s.Mappings.ReturnMapping(r.Context(), mappingID, ret)
+	if err != nil {
+		writeWriteError(w, r, err, "Asset mapping not found", "Failed to return asset")
+		return
+	}
+	s.audit(r, models.AuditActionReturn, models.AuditEntityMapping, mappingID, auditSnapshot(before), auditSnapshot(mapping))
+
+	w.Header().Set("ETag", etag(mapping.Version))
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(mapping)
+}
+
 // RemoveAssetMapping godoc
 // @Summary Remove an asset mapping
-// @Description Deletes a specific asset mapping by its ID
+// @Description Marks a specific asset mapping as returned. The mapping is kept as history.
 // @Tags Asset Mapping
 // @Produce json
 // @Param mappingId path string true "Mapping ID"
+// @Param If-Match header string false "ETag the client last read; the write is refused with 412 when the record has changed since"
 // @Success 200 {object} map[string]string
+// @Header 200 {string} ETag "Version of the mapping"
+// @Failure 404 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Failure 412 {object} map[string]string
 // @Failure 500 {object} map[string]string
 // @Router /asset-mapping/{mappingId} [delete]
-func RemoveAssetMapping(w http.ResponseWriter, r *http.Request) {
+func (s *Server) RemoveAssetMapping(w http.ResponseWriter, r *http.Request) {
 	mappingID := mux.Vars(r)["mappingId"]
 
-	filter := bson.M{"mapping_id": mappingID}
-	_, err := db.Database.Collection("mapping").DeleteOne(r.Context(), filter)
+	before, err := s.Mappings.GetMapping(r.Context(), mappingID)
+	if err != nil {
+		writeStoreError(w, err, "Asset mapping not found", "Failed to remove asset mapping")
+		return
+	}
+	if !checkIfMatch(w, r, before.Version) {
+		return
+	}
+
+	ret := models.MappingReturn{
+		Status:       models.MappingStatusReturned,
+		ReturnedDate: time.Now(),
+		ReturnedBy:   actorID(r),
+	}
+	if r.Header.Get("If-Match") != "" {
+		ret.IfVersion = before.Version
+	}
+	mapping, err := s.Mappings.ReturnMapping(r.Context(), mappingID, ret)
 	if err != nil {
-		http.Error(w, "Failed to remove asset mapping", http.StatusInternalServerError)
+		writeWriteError(w, r, err, "Asset mapping not found", "Failed to remove asset mapping")
 		return
 	}
+	s.audit(r, models.AuditActionReturn, models.AuditEntityMapping, mappingID, auditSnapshot(before), auditSnapshot(mapping))
 
+	w.Header().Set("ETag", etag(mapping.Version))
 	w.WriteHeader(http.StatusOK)
 	json.NewEncoder(w).Encode(map[string]string{"message": "Asset mapping removed successfully"})
 }
+
+// EditMapping godoc
+// @Summary Replace the editable details of an asset mapping
+// @Description Replaces the notes of a mapping and, once it is closed, its return condition and close reason. Fields missing from the body are cleared. The status only changes by returning the asset.
+// @Tags Asset Mapping
+// @Accept json
+// @Produce json
+// @Param mappingId path string true "Mapping ID"
+// @Param data body models.MappingPatch true "Mapping data"
+// @Param If-Match header string false "ETag the client last read; the write is refused with 412 when the record has changed since"
+// @Success 200 {object} map[string]string
+// @Header 200 {string} ETag "Version of the mapping"
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Failure 412 {object} map[string]string
+// @Failure 422 {object} ValidationErrorResponse
+// @Failure 500 {object} map[string]string
+// @Router /mapping/editmapping/{mappingId} [put]
+func (s *Server) EditMapping(w http.ResponseWriter, r *http.Request) {
+	s.updateMapping(w, r)
+}
+
+// PatchMapping godoc
+// @Summary Partially update an asset mapping
+// @Description Applies a JSON Merge Patch (RFC 7386) or JSON Patch (RFC 6902) to the editable fields of a mapping, chosen by Content-Type. Removing a field clears it. A failed test operation is rejected with 409.
+// @Tags Asset Mapping
+// @Accept application/merge-patch+json,application/json-patch+json
+// @Produce json
+// @Param mappingId path string true "Mapping ID"
+// @Param patch body object true "Merge patch object or JSON Patch operation array"
+// @Param If-Match header string false "ETag the client last read; the write is refused with 412 when the record has changed since"
+// @Success 200 {object} map[string]string
+// @Header 200 {string} ETag "Version of the mapping"
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Failure 412 {object} map[string]string
+// @Failure 415 {object} map[string]string
+// @Failure 422 {object} ValidationErrorResponse
+// @Failure 500 {object} map[string]string
+// @Router /mapping/editmapping/{mappingId} [patch]
+func (s *Server) PatchMapping(w http.ResponseWriter, r *http.Request) {
+	s.updateMapping(w, r)
+}
+
+// updateMapping replaces the editable fields of a mapping with the body of a
+// PUT request or the result of applying the body of a PATCH request.
+func (s *Server) updateMapping(w http.ResponseWriter, r *http.Request) {
+	mappingID := mux.Vars(r)["mappingId"]
+
+	mapping, err := s.Mappings.GetMapping(r.Context(), mappingID)
+	if err != nil {
+		writeStoreError(w, err, "Asset mapping not found", "Failed to update asset mapping")
+		return
+	}
+	if !checkIfMatch(w, r, mapping.Version) {
+		return
+	}
+	before := auditSnapshot(mapping)
+
+	var fields models.MappingPatch
+	current := models.NewMappingPatch(mapping)
+	if !readPatch(w, r, &current, &fields, func() models.ValidationErrors { return fields.Validate(mapping) }) {
+		return
+	}
+	fields.Replace(mapping)
+
+	if err := s.Mappings.UpdateMapping(r.Context(), mapping); err != nil {
+		writeWriteError(w, r, err, "Asset mapping not found", "Failed to update asset mapping")
+		return
+	}
+	s.audit(r, models.AuditActionUpdate, models.AuditEntityMapping, mappingID, before, auditSnapshot(mapping))
+
+	w.Header().Set("ETag", etag(mapping.Version))
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]string{"message": "Asset mapping updated successfully"})
+}
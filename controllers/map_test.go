@@ -0,0 +1,74 @@
+package controllers
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"employee-asset-system/models"
+)
+
+func TestAssignAssetMapping(t *testing.T) {
+	assigned := time.Date(2026, 3, 2, 9, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name     string
+		body     string
+		code     int
+		assigned time.Time // zero for now
+		notes    string
+	}{
+		{
+			name: "server fields are ignored",
+			body: `{"employee_id":"e1","asset_id":"a1","notes":"spare charger","id":"65f000000000000000000000",
+				"mapping_id":"m9","status":"lost","close_reason":"x","version":7,"exclusive":false,"assigned_by":"e2"}`,
+			code:  http.StatusCreated,
+			notes: "spare charger",
+		},
+		{
+			name:     "backdated",
+			body:     `{"employee_id":"e1","asset_id":"a1","assigned_date":"2026-03-02T09:00:00Z"}`,
+			code:     http.StatusCreated,
+			assigned: assigned,
+		},
+		{name: "future date", body: `{"employee_id":"e1","asset_id":"a1","assigned_date":"2999-01-01T00:00:00Z"}`, code: http.StatusBadRequest},
+		{name: "unknown employee", body: `{"employee_id":"e9","asset_id":"a1"}`, code: http.StatusBadRequest},
+		{name: "unknown asset", body: `{"employee_id":"e1","asset_id":"a9"}`, code: http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, store := newTestServer(t)
+			before := time.Now()
+
+			w := serve(s.AssignAssetMapping, "POST", "/api/mapping/assignassetmapping", tt.body)
+			if w.Code != tt.code {
+				t.Fatalf("status code = %d, want %d: %s", w.Code, tt.code, w.Body)
+			}
+			mappings, err := store.ListMappingsByAsset(context.Background(), "a1", "")
+			if err != nil {
+				t.Fatalf("ListMappingsByAsset: %v", err)
+			}
+			if tt.code != http.StatusCreated {
+				if len(mappings) != 0 {
+					t.Errorf("%d mappings created, want none", len(mappings))
+				}
+				return
+			}
+			if len(mappings) != 1 {
+				t.Fatalf("%d mappings created, want 1", len(mappings))
+			}
+
+			m := mappings[0]
+			if m.MappingID == "m9" || !m.ID.IsZero() || m.Status != models.MappingStatusActive || m.CloseReason != "" ||
+				m.Version != 1 || !m.Exclusive || m.AssignedBy != "" {
+				t.Errorf("mapping takes fields from the request: %+v", m)
+			}
+			if m.EmployeeID != "e1" || m.Notes != tt.notes {
+				t.Errorf("employee %s with notes %q, want e1 with %q", m.EmployeeID, m.Notes, tt.notes)
+			}
+			if tt.assigned.IsZero() && m.AssignedDate.Before(before) || !tt.assigned.IsZero() && !m.AssignedDate.Equal(tt.assigned) {
+				t.Errorf("assigned date = %v, want %v", m.AssignedDate, tt.assigned)
+			}
+		})
+	}
+}
@@ -0,0 +1,51 @@
+package controllers
+
+import (
+	"employee-asset-system/utils"
+	"encoding/json"
+	"errors"
+	"mime"
+	"net/http"
+)
+
+// Media types accepted by the PATCH routes.
+const (
+	mergePatchType = "application/merge-patch+json" // RFC 7386
+	jsonPatchType  = "application/json-patch+json"  // RFC 6902
+)
+
+// applyPatch applies the PATCH request body patch to the JSON encoding of
+// current, picking the patch format from the Content-Type header, and
+// returns the patched document. When the patch cannot be applied it writes
+// the error reply and returns a non-nil error.
+func applyPatch(w http.ResponseWriter, r *http.Request, current interface{}, patch []byte) ([]byte, error) {
+	doc, err := json.Marshal(current)
+	if err != nil {
+		http.Error(w, "Failed to apply patch", http.StatusInternalServerError)
+		return nil, err
+	}
+
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	switch mediaType {
+	case mergePatchType:
+		doc, err = utils.MergePatch(doc, patch)
+	case jsonPatchType:
+		doc, err = utils.JSONPatch(doc, patch)
+	default:
+		w.Header().Set("Accept-Patch", mergePatchType+", "+jsonPatchType)
+		http.Error(w, "Unsupported patch format; use "+mergePatchType+" or "+jsonPatchType, http.StatusUnsupportedMediaType)
+		return nil, errors.New("unsupported patch format")
+	}
+
+	switch {
+	case err == nil:
+		return doc, nil
+	case errors.Is(err, utils.ErrMalformedPatch):
+		http.Error(w, "Invalid patch: "+err.Error(), http.StatusBadRequest)
+	case errors.Is(err, utils.ErrPatchTestFailed):
+		http.Error(w, err.Error(), http.StatusConflict)
+	default:
+		writeUnprocessable(w, "Patch could not be applied: "+err.Error(), nil)
+	}
+	return nil, err
+}
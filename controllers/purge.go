@@ -0,0 +1,54 @@
+package controllers
+
+import (
+	"employee-asset-system/models"
+	"encoding/json"
+	"net/http"
+	"time"
+)
+
+// PurgeResponse reports what PurgeDeleted removed.
+type PurgeResponse struct {
+	DeletedBefore   time.Time `json:"deleted_before"`
+	EmployeesPurged int64     `json:"employees_purged"`
+	AssetsPurged    int64     `json:"assets_purged"`
+	TokensPurged    int64     `json:"refresh_tokens_purged"` // expired refresh tokens
+}
+
+// PurgeDeleted godoc
+// @Summary Purge deleted records
+// @Description Permanently removes employees and assets that were deleted longer ago than the configured retention period. Records that asset mappings still reference are kept so that the assignment history stays complete. Expired refresh tokens are removed as well.
+// @Tags Maintenance
+// @Produce json
+// @Success 200 {object} PurgeResponse
+// @Failure 500 {object} map[string]string
+// @Router /admin/purge [post]
+func (s *Server) PurgeDeleted(w http.ResponseWriter, r *http.Request) {
+	resp := PurgeResponse{DeletedBefore: time.Now().Add(-s.PurgeRetention)}
+
+	var err error
+	resp.EmployeesPurged, err = s.Employees.PurgeEmployees(r.Context(), resp.DeletedBefore)
+	if err != nil {
+		http.Error(w, "Failed to purge employees", http.StatusInternalServerError)
+		return
+	}
+	resp.AssetsPurged, err = s.Assets.PurgeAssets(r.Context(), resp.DeletedBefore)
+	if err != nil {
+		http.Error(w, "Failed to purge assets", http.StatusInternalServerError)
+		return
+	}
+	resp.TokensPurged, err = s.Tokens.PurgeRefreshTokens(r.Context(), time.Now())
+	if err != nil {
+		http.Error(w, "Failed to purge refresh tokens", http.StatusInternalServerError)
+		return
+	}
+	// A purge removes records in bulk, so it is recorded as a single entry
+	// per entity type.
+	s.audit(r, models.AuditActionPurge, models.AuditEntityEmployee, "",
+		nil, map[string]interface{}{"deleted_before": resp.DeletedBefore, "purged": resp.EmployeesPurged})
+	s.audit(r, models.AuditActionPurge, models.AuditEntityAsset, "",
+		nil, map[string]interface{}{"deleted_before": resp.DeletedBefore, "purged": resp.AssetsPurged})
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(resp)
+}
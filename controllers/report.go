@@ -0,0 +1,214 @@
+package controllers
+
+import (
+	"employee-asset-system/db"
+	"employee-asset-system/models"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+const (
+	defaultTopHolders = 10
+	maxTopHolders     = 100
+)
+
+// ReportPeriod echoes the date range a report covers. Open bounds are
+// left out.
+type ReportPeriod struct {
+	From *time.Time `json:"from,omitempty"`
+	To   *time.Time `json:"to,omitempty"`
+}
+
+// AssetReport counts the assets created in a period.
+type AssetReport struct {
+	ReportPeriod
+	Total      int64 `json:"total"`
+	Assigned   int64 `json:"assigned"`
+	Unassigned int64 `json:"unassigned"`
+	// ByType and ByStatus total Counts over the other dimension.
+	ByType   map[string]int64          `json:"by_type"`
+	ByStatus map[string]int64          `json:"by_status"`
+	Counts   []models.AssetStatusCount `json:"counts"`
+}
+
+// DepartmentReport counts the assignments made in a period by department.
+type DepartmentReport struct {
+	ReportPeriod
+	Departments []models.DepartmentAssignments `json:"departments"`
+}
+
+// AssignmentDurationReport is how long the assignments made in a period and
+// closed since lasted on average.
+type AssignmentDurationReport struct {
+	ReportPeriod
+	models.AssignmentDuration
+	AverageDurationDays float64 `json:"average_duration_days"`
+}
+
+// TopHoldersReport lists the employees holding the most assets.
+type TopHoldersReport struct {
+	ReportPeriod
+	Holders []models.AssetHolder `json:"holders"`
+}
+
+// readReportRange reads the from and to parameters of a report. It replies
+// 400 and returns false when they are malformed or out of order.
+func readReportRange(w http.ResponseWriter, r *http.Request) (db.ReportRange, ReportPeriod, bool) {
+	var rng db.ReportRange
+	if !readTimeParam(w, r, "from", &rng.From) || !readTimeParam(w, r, "to", &rng.To) {
+		return rng, ReportPeriod{}, false
+	}
+	if !rng.From.IsZero() && !rng.To.IsZero() && rng.From.After(rng.To) {
+		http.Error(w, "from must not be after to", http.StatusBadRequest)
+		return rng, ReportPeriod{}, false
+	}
+	var period ReportPeriod
+	if !rng.From.IsZero() {
+		period.From = &rng.From
+	}
+	if !rng.To.IsZero() {
+		period.To = &rng.To
+	}
+	return rng, period, true
+}
+
+// AssetReport godoc
+// @Summary Report assets by type and status
+// @Description Counts the live assets created in the period by type and status. An asset is assigned while it has an active mapping; otherwise it is lost or damaged if its latest mapping was closed as such, and available if not.
+// @Tags Reports
+// @Produce json
+// @Param from query string false "Only assets created at or after this RFC 3339 time"
+// @Param to query string false "Only assets created at or before this RFC 3339 time"
+// @Success 200 {object} AssetReport
+// @Failure 400 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /reports/assets [get]
+func (s *Server) AssetReport(w http.ResponseWriter, r *http.Request) {
+	rng, period, ok := readReportRange(w, r)
+	if !ok {
+		return
+	}
+	counts, err := s.Reports.AssetStatusCounts(r.Context(), rng)
+	if err != nil {
+		http.Error(w, "Failed to build asset report", http.StatusInternalServerError)
+		return
+	}
+
+	report := AssetReport{
+		ReportPeriod: period,
+		ByType:       map[string]int64{},
+		ByStatus:     map[string]int64{},
+		Counts:       append([]models.AssetStatusCount{}, counts...),
+	}
+	for _, c := range counts {
+		report.Total += c.Count
+		report.ByType[c.AssetType] += c.Count
+		report.ByStatus[c.Status] += c.Count
+		if c.Status == models.AssetStatusAssigned {
+			report.Assigned += c.Count
+		}
+	}
+	report.Unassigned = report.Total - report.Assigned
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(report)
+}
+
+// DepartmentReport godoc
+// @Summary Report assignments by department
+// @Description Counts the assignments made in the period by the department of the employee, and how many of them are still active. Employees without a department are grouped under an empty name.
+// @Tags Reports
+// @Produce json
+// @Param from query string false "Only assignments made at or after this RFC 3339 time"
+// @Param to query string false "Only assignments made at or before this RFC 3339 time"
+// @Success 200 {object} DepartmentReport
+// @Failure 400 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /reports/departments [get]
+func (s *Server) DepartmentReport(w http.ResponseWriter, r *http.Request) {
+	rng, period, ok := readReportRange(w, r)
+	if !ok {
+		return
+	}
+	departments, err := s.Reports.DepartmentAssignments(r.Context(), rng)
+	if err != nil {
+		http.Error(w, "Failed to build department report", http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(DepartmentReport{
+		ReportPeriod: period,
+		Departments:  append([]models.DepartmentAssignments{}, departments...),
+	})
+}
+
+// AssignmentDurationReport godoc
+// @Summary Report the average assignment duration
+// @Description Averages how long the assignments made in the period lasted, over those that have been closed since.
+// @Tags Reports
+// @Produce json
+// @Param from query string false "Only assignments made at or after this RFC 3339 time"
+// @Param to query string false "Only assignments made at or before this RFC 3339 time"
+// @Success 200 {object} AssignmentDurationReport
+// @Failure 400 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /reports/assignment-duration [get]
+func (s *Server) AssignmentDurationReport(w http.ResponseWriter, r *http.Request) {
+	rng, period, ok := readReportRange(w, r)
+	if !ok {
+		return
+	}
+	duration, err := s.Reports.AssignmentDuration(r.Context(), rng)
+	if err != nil {
+		http.Error(w, "Failed to build assignment duration report", http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(AssignmentDurationReport{
+		ReportPeriod:        period,
+		AssignmentDuration:  duration,
+		AverageDurationDays: duration.AverageDurationSeconds / (24 * 60 * 60),
+	})
+}
+
+// TopHoldersReport godoc
+// @Summary Report the top asset holders
+// @Description Lists the employees holding the most assets through active assignments made in the period, most first.
+// @Tags Reports
+// @Produce json
+// @Param from query string false "Only assignments made at or after this RFC 3339 time"
+// @Param to query string false "Only assignments made at or before this RFC 3339 time"
+// @Param limit query int false "Number of employees (default 10, at most 100)"
+// @Success 200 {object} TopHoldersReport
+// @Failure 400 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /reports/top-holders [get]
+func (s *Server) TopHoldersReport(w http.ResponseWriter, r *http.Request) {
+	rng, period, ok := readReportRange(w, r)
+	if !ok {
+		return
+	}
+	limit := defaultTopHolders
+	if value := r.URL.Query().Get("limit"); value != "" {
+		var err error
+		if limit, err = strconv.Atoi(value); err != nil || limit < 1 || limit > maxTopHolders {
+			http.Error(w, "Invalid limit", http.StatusBadRequest)
+			return
+		}
+	}
+	holders, err := s.Reports.TopHolders(r.Context(), rng, limit)
+	if err != nil {
+		http.Error(w, "Failed to build top holders report", http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(TopHoldersReport{
+		ReportPeriod: period,
+		Holders:      append([]models.AssetHolder{}, holders...),
+	})
+}
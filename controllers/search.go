@@ -0,0 +1,102 @@
+package controllers
+
+import (
+	"employee-asset-system/db"
+	"employee-asset-system/models"
+	"employee-asset-system/utils"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+const (
+	defaultSearchLimit = 20
+	maxSearchLimit     = 100
+)
+
+// SearchResult is one hit of Search.
+type SearchResult struct {
+	Type  string  `json:"type"` // employee or asset
+	ID    string  `json:"id"`
+	Title string  `json:"title"`
+	Score float64 `json:"score"`
+	// Highlights holds the fields that matched, HTML escaped, with the
+	// matching words wrapped in <mark> tags.
+	Highlights map[string]string `json:"highlights"`
+}
+
+// SearchResponse is the body of a Search reply.
+type SearchResponse struct {
+	Query   string         `json:"query"`
+	Results []SearchResult `json:"results"`
+}
+
+// Search godoc
+// @Summary Search employees and assets
+// @Description Finds employees by name, email, phone number and address and assets by name, type, asset tag, serial number, manufacturer, model, vendor and invoice number, best matches first. Every word of the query must match. In prefix mode words only need to start with the query words, for typeahead.
+// @Tags Search
+// @Produce json
+// @Param q query string true "Words to search for"
+// @Param type query string false "Only this entity type (employee, asset)"
+// @Param prefix query bool false "Match the start of words"
+// @Param limit query int false "Maximum number of results (default 20, at most 100)"
+// @Success 200 {object} SearchResponse
+// @Failure 400 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /search [get]
+func (s *Server) Search(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	q := db.SearchQuery{Text: query.Get("q"), Limit: defaultSearchLimit}
+	terms := db.SearchTerms(q.Text)
+	if len(terms) == 0 {
+		http.Error(w, "Missing search query", http.StatusBadRequest)
+		return
+	}
+	switch entityType := query.Get("type"); entityType {
+	case "":
+	case models.AuditEntityEmployee, models.AuditEntityAsset:
+		q.Types = []string{entityType}
+	default:
+		http.Error(w, "Invalid type", http.StatusBadRequest)
+		return
+	}
+	var err error
+	if value := query.Get("prefix"); value != "" {
+		if q.Prefix, err = strconv.ParseBool(value); err != nil {
+			http.Error(w, "Invalid prefix", http.StatusBadRequest)
+			return
+		}
+	}
+	if value := query.Get("limit"); value != "" {
+		if q.Limit, err = strconv.Atoi(value); err != nil || q.Limit < 1 || q.Limit > maxSearchLimit {
+			http.Error(w, "Invalid limit", http.StatusBadRequest)
+			return
+		}
+	}
+
+	hits, err := s.SearchIndex.Search(r.Context(), q)
+	if err != nil {
+		http.Error(w, "Failed to search", http.StatusInternalServerError)
+		return
+	}
+
+	results := make([]SearchResult, 0, len(hits))
+	for _, hit := range hits {
+		result := SearchResult{Type: hit.Type, ID: hit.ID, Score: hit.Score, Highlights: map[string]string{}}
+		if hit.Type == models.AuditEntityEmployee {
+			result.Title = strings.TrimSpace(hit.Fields["first_name"] + " " + hit.Fields["last_name"])
+		} else {
+			result.Title = hit.Fields["asset_name"]
+		}
+		for field, value := range hit.Fields {
+			if highlighted, ok := utils.Highlight(value, terms, q.Prefix); ok {
+				result.Highlights[field] = highlighted
+			}
+		}
+		results = append(results, result)
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(SearchResponse{Query: q.Text, Results: results})
+}
@@ -0,0 +1,108 @@
+package controllers
+
+import (
+	"employee-asset-system/db"
+	"employee-asset-system/middleware"
+	"employee-asset-system/models"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// Defaults used by NewServer.
+const (
+	DefaultAccessTokenTTL  = 15 * time.Minute
+	DefaultRefreshTokenTTL = 7 * 24 * time.Hour
+	DefaultPurgeRetention  = 30 * 24 * time.Hour
+)
+
+// Server carries the dependencies shared by every HTTP handler.
+type Server struct {
+	Employees db.EmployeeStore
+	Assets    db.AssetStore
+	Mappings  db.MappingStore
+	Tokens    db.TokenStore
+	Audit     db.AuditStore
+	Versions  db.VersionStore
+	Keys      *middleware.KeySet
+
+	SearchIndex db.SearchStore
+	Reports     db.ReportStore
+
+	AccessTokenTTL  time.Duration
+	RefreshTokenTTL time.Duration
+	// PurgeRetention is how long deleted records are kept before a purge
+	// may remove them for good.
+	PurgeRetention time.Duration
+}
+
+// NewServer wires every handler dependency to the given store and signs
+// tokens with keys.
+func NewServer(store db.Store, keys *middleware.KeySet) *Server {
+	return &Server{
+		Employees: store,
+		Assets:    store,
+		Mappings:  store,
+		Tokens:    store,
+		Audit:     store,
+		Versions:  store,
+		Keys:      keys,
+
+		SearchIndex: store,
+		Reports:     store,
+
+		AccessTokenTTL:  DefaultAccessTokenTTL,
+		RefreshTokenTTL: DefaultRefreshTokenTTL,
+		PurgeRetention:  DefaultPurgeRetention,
+	}
+}
+
+// actorID returns the emp_id of the authenticated caller, or "" for requests
+// that did not pass through AuthMiddleware.
+func actorID(r *http.Request) string {
+	if principal, ok := middleware.PrincipalFromContext(r.Context()); ok {
+		return principal.EmpID
+	}
+	return ""
+}
+
+// writeStoreError replies 404 with notFound when err is db.ErrNotFound, 409
+// when the store rejected a write with db.ErrConflict and 500 with failed
+// otherwise.
+func writeStoreError(w http.ResponseWriter, err error, notFound, failed string) {
+	switch {
+	case errors.Is(err, db.ErrNotFound):
+		http.Error(w, notFound, http.StatusNotFound)
+	case errors.Is(err, db.ErrConflict):
+		http.Error(w, err.Error(), http.StatusConflict)
+	default:
+		http.Error(w, failed, http.StatusInternalServerError)
+	}
+}
+
+// cascadeReturn reads the ?cascade= and ?reason= parameters of a delete
+// request. It returns nil unless cascade is true, in which case the active
+// mappings of the deleted record are returned with the given reason, or
+// defaultReason when none was given.
+func cascadeReturn(r *http.Request, defaultReason string) (*models.MappingReturn, error) {
+	query := r.URL.Query()
+	if query.Get("cascade") == "" {
+		return nil, nil
+	}
+	cascade, err := strconv.ParseBool(query.Get("cascade"))
+	if err != nil || !cascade {
+		return nil, err
+	}
+
+	reason := query.Get("reason")
+	if reason == "" {
+		reason = defaultReason
+	}
+	return &models.MappingReturn{
+		Status:       models.MappingStatusReturned,
+		ReturnedDate: time.Now(),
+		ReturnedBy:   actorID(r),
+		Reason:       reason,
+	}, nil
+}
@@ -0,0 +1,60 @@
+package controllers
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"employee-asset-system/db"
+	"employee-asset-system/models"
+)
+
+// newTestServer returns a Server backed by a MemoryStore holding employees
+// e1 and e2, exclusive assets a1 to a3 and the shared asset s1.
+func newTestServer(t *testing.T) (*Server, *db.MemoryStore) {
+	t.Helper()
+	ctx := context.Background()
+	store := db.NewMemoryStore()
+	now := time.Now()
+	for i, id := range []string{"e1", "e2"} {
+		err := store.CreateEmployee(ctx, &models.Employee{
+			EmpID:         id,
+			FirstName:     strings.ToUpper(id),
+			EmployeeEmail: id + "@example.com",
+			PhoneNumber:   "+1555000000" + string(rune('1'+i)),
+			CreatedAt:     now,
+			UpdatedAt:     now,
+		})
+		if err != nil {
+			t.Fatalf("CreateEmployee: %v", err)
+		}
+	}
+	for _, id := range []string{"a1", "a2", "a3", "s1"} {
+		err := store.CreateAsset(ctx, &models.Asset{
+			AssetID:   id,
+			AssetName: id,
+			AssetType: "laptop",
+			Shared:    strings.HasPrefix(id, "s"),
+			CreatedAt: now,
+			UpdatedAt: now,
+		})
+		if err != nil {
+			t.Fatalf("CreateAsset: %v", err)
+		}
+	}
+	return NewServer(store, nil), store
+}
+
+// serve runs handler on a request with the given body and header pairs.
+func serve(handler http.HandlerFunc, method, target, body string, header ...string) *httptest.ResponseRecorder {
+	r := httptest.NewRequest(method, target, strings.NewReader(body))
+	for i := 0; i+1 < len(header); i += 2 {
+		r.Header.Set(header[i], header[i+1])
+	}
+	w := httptest.NewRecorder()
+	handler(w, r)
+	return w
+}
@@ -0,0 +1,112 @@
+package controllers
+
+import (
+	"employee-asset-system/models"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// errInvalidInput is returned by decodePatch for bodies that are not a JSON
+// object.
+var errInvalidInput = errors.New("body must be a JSON object")
+
+// decodePatch decodes the JSON object doc into the patch struct dst. Only
+// keys matching one of dst's json tags are accepted; unknown keys and values
+// of the wrong type are reported as field errors. It returns errInvalidInput
+// when doc is not a JSON object at all.
+func decodePatch(doc []byte, dst interface{}) (models.ValidationErrors, error) {
+	var body map[string]json.RawMessage
+	if err := json.Unmarshal(doc, &body); err != nil || body == nil {
+		return nil, errInvalidInput
+	}
+
+	fields := patchFields(dst)
+	keys := make([]string, 0, len(body))
+	for key := range body {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var errs models.ValidationErrors
+	for _, key := range keys {
+		field, ok := fields[key]
+		if !ok {
+			errs.Add(key, "is not an editable field")
+			continue
+		}
+		if err := json.Unmarshal(body[key], field.Addr().Interface()); err != nil {
+			errs.Add(key, "has the wrong type")
+		}
+	}
+	return errs, nil
+}
+
+// patchFields maps the json names of the fields of the struct pointed to by
+// dst to the fields themselves.
+func patchFields(dst interface{}) map[string]reflect.Value {
+	v := reflect.ValueOf(dst).Elem()
+	fields := make(map[string]reflect.Value, v.NumField())
+	for i := 0; i < v.NumField(); i++ {
+		name := strings.Split(v.Type().Field(i).Tag.Get("json"), ",")[0]
+		if name != "" && name != "-" {
+			fields[name] = v.Field(i)
+		}
+	}
+	return fields
+}
+
+// readPatch decodes the request body into dst and validates it with validate.
+// For PATCH requests the body is a patch, which is applied to current, the
+// patch struct holding the fields of the stored record, first. It writes an
+// error reply and returns false when the body is rejected.
+func readPatch(w http.ResponseWriter, r *http.Request, current, dst interface{}, validate func() models.ValidationErrors) bool {
+	doc, err := io.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, "Invalid input", http.StatusBadRequest)
+		return false
+	}
+	if r.Method == http.MethodPatch {
+		if doc, err = applyPatch(w, r, current, doc); err != nil {
+			return false
+		}
+	}
+
+	errs, err := decodePatch(doc, dst)
+	if err != nil {
+		http.Error(w, "Invalid input", http.StatusBadRequest)
+		return false
+	}
+	if len(errs) == 0 {
+		errs = validate()
+	}
+	if len(errs) > 0 {
+		writeValidationErrors(w, errs)
+		return false
+	}
+	return true
+}
+
+// ValidationErrorResponse is the body of a 422 reply.
+type ValidationErrorResponse struct {
+	Message string                  `json:"message"`
+	Errors  models.ValidationErrors `json:"errors"`
+}
+
+// writeValidationErrors replies 422 listing every offending field.
+func writeValidationErrors(w http.ResponseWriter, errs models.ValidationErrors) {
+	writeUnprocessable(w, "Validation failed", errs)
+}
+
+func writeUnprocessable(w http.ResponseWriter, message string, errs models.ValidationErrors) {
+	if errs == nil {
+		errs = models.ValidationErrors{}
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusUnprocessableEntity)
+	json.NewEncoder(w).Encode(ValidationErrorResponse{Message: message, Errors: errs})
+}
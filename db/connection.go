@@ -2,6 +2,7 @@ package db
 
 import (
 	"context"
+	"fmt"
 	"log"
 	"time"
 
@@ -9,13 +10,11 @@ import (
 	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
-var Database *mongo.Database // Global variable to hold the database instance
-
-// InitializeDatabase connects to MongoDB and assigns the Database instance.
-func InitializeDatabase(uri string, dbName string) error {
+// InitializeDatabase connects to MongoDB and returns the named database.
+func InitializeDatabase(uri string, dbName string) (*mongo.Database, error) {
 	client, err := mongo.NewClient(options.Client().ApplyURI(uri))
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	// Create a context with a timeout
@@ -25,17 +24,50 @@ func InitializeDatabase(uri string, dbName string) error {
 	// Connect to MongoDB
 	err = client.Connect(ctx)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	// Ping the MongoDB server
 	err = client.Ping(ctx, nil)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	// Assign the connected database to the global variable
-	Database = client.Database(dbName)
 	log.Println("Successfully connected to MongoDB!")
-	return nil
+	return client.Database(dbName), nil
+}
+
+// Open connects to the backend named by driver and returns it as a Store.
+// url may be left empty for MongoDB and SQLite, which then use a local
+// default; dbName is only used by MongoDB.
+func Open(ctx context.Context, driver, url, dbName string) (Store, error) {
+	switch driver {
+	case "mongo":
+		if url == "" {
+			url = "mongodb://localhost:27017"
+		}
+		database, err := InitializeDatabase(url, dbName)
+		if err != nil {
+			return nil, err
+		}
+		store := NewMongoStore(database)
+		if err := store.EnsureIndexes(ctx); err != nil {
+			return nil, err
+		}
+		return store, nil
+	case "postgres":
+		if url == "" {
+			return nil, fmt.Errorf("the postgres driver needs a connection URL")
+		}
+		return OpenPostgres(ctx, url)
+	case "sqlite":
+		if url == "" {
+			url = "employee-asset.db"
+		}
+		return OpenSQLite(ctx, url)
+	case "memory":
+		return NewMemoryStore(), nil
+	default:
+		return nil, fmt.Errorf("unknown database driver %q", driver)
+	}
 }
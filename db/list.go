@@ -0,0 +1,182 @@
+package db
+
+import (
+	"errors"
+	"time"
+
+	"employee-asset-system/models"
+)
+
+// Errors returned by list queries given a sort field they do not support or a
+// cursor that does not fit their sort field.
+var (
+	ErrInvalidSort   = errors.New("invalid sort field")
+	ErrInvalidCursor = errors.New("invalid cursor")
+)
+
+// Cursor is the position of a record in a sorted listing: its value of the
+// sort field and its ID, which breaks ties.
+type Cursor struct {
+	Key string `json:"k"`
+	ID  string `json:"id"`
+}
+
+// ListOptions order and page a listing. Records are sorted by Sort and then
+// by their ID, so a page that starts After the cursor of the last record of
+// the previous one neither skips nor repeats records, even while records are
+// added or removed.
+type ListOptions struct {
+	Sort  string // one of the listing's sort fields; empty for the first one
+	Desc  bool
+	After *Cursor // nil for the first page
+	Limit int     // 0 means no limit
+}
+
+// Fields listings can be sorted by, default first. The names are those of
+// the columns and of the JSON fields of the records.
+var (
+	EmployeeSortFields = []string{"created_at", "updated_at", "first_name", "last_name", "employee_email", "emp_id"}
+	AssetSortFields    = []string{"created_at", "updated_at", "asset_name", "asset_type", "asset_id"}
+	MappingSortFields  = []string{"assigned_date", "status", "mapping_id"}
+)
+
+// EmployeeQuery selects employees for the dashboard. Zero fields do not
+// filter.
+type EmployeeQuery struct {
+	NamePrefix    string // first or last name starts with it, ignoring case
+	Role          string
+	CreatedAfter  time.Time
+	CreatedBefore time.Time
+	ListOptions
+}
+
+// AssetQuery selects assets. Zero fields do not filter.
+type AssetQuery struct {
+	AssetType             string
+	NamePrefix            string // asset name starts with it, ignoring case
+	Shared                *bool
+	CreatedAfter          time.Time
+	CreatedBefore         time.Time
+	SerialNumber          string
+	InvoiceNumber         string
+	Manufacturer          string // ignoring case, as are Model and Vendor
+	Model                 string
+	Vendor                string
+	PurchasedAfter        time.Time
+	PurchasedBefore       time.Time
+	WarrantyExpiresAfter  time.Time
+	WarrantyExpiresBefore time.Time
+	ListOptions
+}
+
+// MappingQuery selects mappings. Zero fields do not filter.
+type MappingQuery struct {
+	EmployeeID     string
+	AssetID        string
+	Status         string
+	AssignedAfter  time.Time
+	AssignedBefore time.Time
+	ListOptions
+}
+
+// cursorTimeLayout formats times in cursors. It is fixed width so that keys
+// of time fields sort as strings too.
+const cursorTimeLayout = "2006-01-02T15:04:05.000000000Z"
+
+func timeKey(t time.Time) string {
+	return t.UTC().Format(cursorTimeLayout)
+}
+
+// isTimeField reports whether the sort field holds times.
+func isTimeField(field string) bool {
+	switch field {
+	case "created_at", "updated_at", "assigned_date":
+		return true
+	}
+	return false
+}
+
+// sortField returns the field opts sort by, defaulting to the first of
+// fields. Since it ends up in queries, it must be one of fields.
+func sortField(opts ListOptions, fields []string) (string, error) {
+	if opts.Sort == "" {
+		return fields[0], nil
+	}
+	for _, field := range fields {
+		if field == opts.Sort {
+			return field, nil
+		}
+	}
+	return "", ErrInvalidSort
+}
+
+// cursorValue returns the sort field value of cursor as the type the field
+// is stored as.
+func cursorValue(cursor *Cursor, field string) (interface{}, error) {
+	if !isTimeField(field) {
+		return cursor.Key, nil
+	}
+	t, err := time.Parse(cursorTimeLayout, cursor.Key)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+	return t, nil
+}
+
+// EmployeeCursor returns the cursor of e in a listing sorted by sort.
+func EmployeeCursor(e *models.DashboardEmployee, sort string) Cursor {
+	return Cursor{Key: employeeSortKey(e, sort), ID: e.EmpId}
+}
+
+func employeeSortKey(e *models.DashboardEmployee, sort string) string {
+	switch sort {
+	case "updated_at":
+		return timeKey(e.UpdatedAt)
+	case "first_name":
+		return e.FirstName
+	case "last_name":
+		return e.LastName
+	case "employee_email":
+		return e.EmployeeEmail
+	case "emp_id":
+		return e.EmpId
+	default:
+		return timeKey(e.CreatedAt)
+	}
+}
+
+// AssetCursor returns the cursor of a in a listing sorted by sort.
+func AssetCursor(a *models.Asset, sort string) Cursor {
+	return Cursor{Key: assetSortKey(a, sort), ID: a.AssetID}
+}
+
+func assetSortKey(a *models.Asset, sort string) string {
+	switch sort {
+	case "updated_at":
+		return timeKey(a.UpdatedAt)
+	case "asset_name":
+		return a.AssetName
+	case "asset_type":
+		return a.AssetType
+	case "asset_id":
+		return a.AssetID
+	default:
+		return timeKey(a.CreatedAt)
+	}
+}
+
+// MappingCursor returns the cursor of m in a listing sorted by sort.
+func MappingCursor(m *models.EmployeeAssetMapping, sort string) Cursor {
+	return Cursor{Key: mappingSortKey(m, sort), ID: m.MappingID}
+}
+
+func mappingSortKey(m *models.EmployeeAssetMapping, sort string) string {
+	switch sort {
+	case "status":
+		return m.Status
+	case "mapping_id":
+		return m.MappingID
+	default:
+		return timeKey(m.AssignedDate)
+	}
+}
@@ -0,0 +1,888 @@
+package db
+
+import (
+	"context"
+	"employee-asset-system/models"
+	"fmt"
+	"maps"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// MemoryStore is an in-process Store intended for tests and local
+// development. Records are kept in maps keyed by their public ID.
+type MemoryStore struct {
+	mu        sync.RWMutex
+	employees map[string]models.Employee
+	assets    map[string]models.Asset
+	mappings  map[string]models.EmployeeAssetMapping
+
+	refreshTokens map[string]models.RefreshToken
+	audit         []models.AuditEntry
+	versions      map[string][]models.RecordVersion // keyed by entity type and ID
+}
+
+// NewMemoryStore returns an empty MemoryStore.
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		employees: make(map[string]models.Employee),
+		assets:    make(map[string]models.Asset),
+		mappings:  make(map[string]models.EmployeeAssetMapping),
+
+		refreshTokens: make(map[string]models.RefreshToken),
+		versions:      make(map[string][]models.RecordVersion),
+	}
+}
+
+func (s *MemoryStore) CreateEmployee(ctx context.Context, employee *models.Employee) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	employee.Version = 1
+	s.employees[employee.EmpID] = *employee
+	return nil
+}
+
+func (s *MemoryStore) CreateEmployees(ctx context.Context, employees []models.Employee) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i := range employees {
+		employees[i].Version = 1
+		s.employees[employees[i].EmpID] = employees[i]
+	}
+	return nil
+}
+
+func (s *MemoryStore) GetEmployee(ctx context.Context, empID string) (*models.Employee, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	employee, ok := s.employees[empID]
+	if !ok || employee.DeletedAt != nil {
+		return nil, ErrNotFound
+	}
+	return &employee, nil
+}
+
+func (s *MemoryStore) FindEmployeeByIdentifier(ctx context.Context, identifier string) (*models.Employee, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	for _, employee := range s.employees {
+		if employee.DeletedAt != nil {
+			continue
+		}
+		if employee.PhoneNumber == identifier || employee.EmployeeEmail == identifier {
+			return &employee, nil
+		}
+	}
+	return nil, ErrNotFound
+}
+
+func (s *MemoryStore) EmployeesByIdentifier(ctx context.Context, emails, phones []string) ([]models.Employee, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	wanted := make(map[string]bool, len(emails)+len(phones))
+	for _, email := range emails {
+		wanted["email:"+email] = true
+	}
+	for _, phone := range phones {
+		wanted["phone:"+phone] = true
+	}
+	var employees []models.Employee
+	for _, employee := range s.employees {
+		if employee.DeletedAt != nil {
+			continue
+		}
+		if (employee.EmployeeEmail != "" && wanted["email:"+strings.ToLower(employee.EmployeeEmail)]) ||
+			(employee.PhoneNumber != "" && wanted["phone:"+employee.PhoneNumber]) {
+			employees = append(employees, employee)
+		}
+	}
+	return employees, nil
+}
+
+func (s *MemoryStore) UpdateEmployee(ctx context.Context, employee *models.Employee) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	current, ok := s.employees[employee.EmpID]
+	if !ok || current.DeletedAt != nil {
+		return ErrNotFound
+	}
+	if current.Version != employee.Version {
+		return ErrStaleVersion
+	}
+	employee.Version++
+	s.employees[employee.EmpID] = *employee
+	return nil
+}
+
+func (s *MemoryStore) DeleteEmployee(ctx context.Context, empID string, del models.Deletion) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	employee, ok := s.employees[empID]
+	if !ok || employee.DeletedAt != nil {
+		return ErrNotFound
+	}
+	if del.IfVersion != 0 && employee.Version != del.IfVersion {
+		return ErrStaleVersion
+	}
+	err := s.closeMappings(func(m models.EmployeeAssetMapping) bool { return m.EmployeeID == empID }, del.Cascade)
+	if err != nil {
+		return err
+	}
+	deletedAt := del.DeletedAt
+	employee.DeletedAt = &deletedAt
+	employee.DeletedBy = del.DeletedBy
+	employee.Version++
+	s.employees[empID] = employee
+	return nil
+}
+
+func (s *MemoryStore) RestoreEmployee(ctx context.Context, empID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	employee, ok := s.employees[empID]
+	if !ok || employee.DeletedAt == nil {
+		return ErrNotFound
+	}
+	employee.DeletedAt = nil
+	employee.DeletedBy = ""
+	employee.Version++
+	s.employees[empID] = employee
+	return nil
+}
+
+func (s *MemoryStore) PurgeEmployees(ctx context.Context, deletedBefore time.Time) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var purged int64
+	referenced := s.referenced(func(m models.EmployeeAssetMapping) string { return m.EmployeeID })
+	for id, employee := range s.employees {
+		if employee.DeletedAt != nil && employee.DeletedAt.Before(deletedBefore) && !referenced[id] {
+			delete(s.employees, id)
+			purged++
+		}
+	}
+	return purged, nil
+}
+
+func (s *MemoryStore) Dashboard(ctx context.Context, q EmployeeQuery) ([]models.DashboardEmployee, int64, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	active := make(map[string][]models.EmployeeAssetMapping)
+	lastAssigned := make(map[string]time.Time)
+	for _, mapping := range s.mappings {
+		if mapping.Status == models.MappingStatusActive {
+			active[mapping.EmployeeID] = append(active[mapping.EmployeeID], mapping)
+		}
+		if mapping.AssignedDate.After(lastAssigned[mapping.EmployeeID]) {
+			lastAssigned[mapping.EmployeeID] = mapping.AssignedDate
+		}
+	}
+
+	var employees []models.DashboardEmployee
+	for _, e := range s.employees {
+		if e.DeletedAt != nil ||
+			(q.NamePrefix != "" && !hasPrefixFold(e.FirstName, q.NamePrefix) && !hasPrefixFold(e.LastName, q.NamePrefix)) ||
+			(q.Role != "" && !hasRole(e.Roles, q.Role)) ||
+			!inRange(e.CreatedAt, q.CreatedAfter, q.CreatedBefore) {
+			continue
+		}
+		d := models.DashboardEmployee{
+			EmpId:                  e.EmpID,
+			FirstName:              e.FirstName,
+			LastName:               e.LastName,
+			Gender:                 e.Gender,
+			PhoneNumber:            e.PhoneNumber,
+			EmployeeEmail:          e.EmployeeEmail,
+			Address:                e.Address,
+			BloodGroup:             e.BloodGroup,
+			EmergencyContactNumber: e.EmergencyContactNumber,
+			Department:             e.Department,
+			AssetCount:             len(active[e.EmpID]),
+			CreatedAt:              e.CreatedAt,
+			UpdatedAt:              e.UpdatedAt,
+		}
+		for _, mapping := range active[e.EmpID] {
+			if asset, ok := s.assets[mapping.AssetID]; ok {
+				addAssigned(&d, asset.AssetType, asset.Currency, 1, asset.PurchaseCost)
+			}
+		}
+		if last, ok := lastAssigned[e.EmpID]; ok {
+			d.LastAssignedAt = &last
+		}
+		employees = append(employees, d)
+	}
+	return pageOf(employees, q.ListOptions, EmployeeSortFields, EmployeeCursor)
+}
+
+func (s *MemoryStore) CreateAsset(ctx context.Context, asset *models.Asset) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.checkAssetKeys([]models.Asset{*asset}); err != nil {
+		return err
+	}
+	asset.Version = 1
+	s.assets[asset.AssetID] = *asset
+	return nil
+}
+
+func (s *MemoryStore) CreateAssets(ctx context.Context, assets []models.Asset) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.checkAssetKeys(assets); err != nil {
+		return err
+	}
+	for i := range assets {
+		assets[i].Version = 1
+		s.assets[assets[i].AssetID] = assets[i]
+	}
+	return nil
+}
+
+// checkAssetKeys enforces the uniqueness of serial numbers and asset tags
+// the other backends have indexes for: it fails with ErrConflict when one of
+// assets, about to be written, shares them with another asset.
+func (s *MemoryStore) checkAssetKeys(assets []models.Asset) error {
+	writing := make(map[string]bool, len(assets))
+	for _, asset := range assets {
+		writing[asset.AssetID] = true
+	}
+	taken := make(map[string]bool)
+	claim := func(asset *models.Asset) bool {
+		for _, key := range []string{"serial:" + asset.SerialNumber, "tag:" + asset.AssetTag} {
+			if strings.HasSuffix(key, ":") {
+				continue
+			}
+			if taken[key] {
+				return false
+			}
+			taken[key] = true
+		}
+		return true
+	}
+	for _, asset := range s.assets {
+		if !writing[asset.AssetID] {
+			claim(&asset)
+		}
+	}
+	for i := range assets {
+		if !claim(&assets[i]) {
+			return fmt.Errorf("%w: serial number or asset tag already exists", ErrConflict)
+		}
+	}
+	return nil
+}
+
+func (s *MemoryStore) AssetsBySerial(ctx context.Context, serials []string) ([]models.Asset, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	wanted := make(map[string]bool, len(serials))
+	for _, serial := range serials {
+		wanted[serial] = true
+	}
+	var assets []models.Asset
+	for _, asset := range s.assets {
+		if asset.SerialNumber != "" && wanted[asset.SerialNumber] {
+			assets = append(assets, asset)
+		}
+	}
+	sort.Slice(assets, func(i, j int) bool { return assets[i].SerialNumber < assets[j].SerialNumber })
+	return assets, nil
+}
+
+func (s *MemoryStore) GetAsset(ctx context.Context, assetID string) (*models.Asset, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	asset, ok := s.assets[assetID]
+	if !ok || asset.DeletedAt != nil {
+		return nil, ErrNotFound
+	}
+	return &asset, nil
+}
+
+func (s *MemoryStore) ListAssets(ctx context.Context, q AssetQuery) ([]models.Asset, int64, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	var assets []models.Asset
+	for _, asset := range s.assets {
+		if asset.DeletedAt != nil ||
+			(q.AssetType != "" && asset.AssetType != q.AssetType) ||
+			(q.NamePrefix != "" && !hasPrefixFold(asset.AssetName, q.NamePrefix)) ||
+			(q.Shared != nil && asset.Shared != *q.Shared) ||
+			!inRange(asset.CreatedAt, q.CreatedAfter, q.CreatedBefore) ||
+			(q.SerialNumber != "" && asset.SerialNumber != q.SerialNumber) ||
+			(q.InvoiceNumber != "" && asset.InvoiceNumber != q.InvoiceNumber) ||
+			(q.Manufacturer != "" && !strings.EqualFold(asset.Manufacturer, q.Manufacturer)) ||
+			(q.Model != "" && !strings.EqualFold(asset.Model, q.Model)) ||
+			(q.Vendor != "" && !strings.EqualFold(asset.Vendor, q.Vendor)) ||
+			!inDateRange(asset.PurchaseDate, q.PurchasedAfter, q.PurchasedBefore) ||
+			!inDateRange(asset.WarrantyExpiry, q.WarrantyExpiresAfter, q.WarrantyExpiresBefore) {
+			continue
+		}
+		assets = append(assets, asset)
+	}
+	return pageOf(assets, q.ListOptions, AssetSortFields, AssetCursor)
+}
+
+func (s *MemoryStore) UpdateAsset(ctx context.Context, asset *models.Asset) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	current, ok := s.assets[asset.AssetID]
+	if !ok || current.DeletedAt != nil {
+		return ErrNotFound
+	}
+	if current.Version != asset.Version {
+		return ErrStaleVersion
+	}
+	if err := s.checkAssetKeys([]models.Asset{*asset}); err != nil {
+		return err
+	}
+	asset.Version++
+	s.assets[asset.AssetID] = *asset
+	return nil
+}
+
+func (s *MemoryStore) DeleteAsset(ctx context.Context, assetID string, del models.Deletion) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	asset, ok := s.assets[assetID]
+	if !ok || asset.DeletedAt != nil {
+		return ErrNotFound
+	}
+	if del.IfVersion != 0 && asset.Version != del.IfVersion {
+		return ErrStaleVersion
+	}
+	err := s.closeMappings(func(m models.EmployeeAssetMapping) bool { return m.AssetID == assetID }, del.Cascade)
+	if err != nil {
+		return err
+	}
+	deletedAt := del.DeletedAt
+	asset.DeletedAt = &deletedAt
+	asset.DeletedBy = del.DeletedBy
+	asset.Version++
+	s.assets[assetID] = asset
+	return nil
+}
+
+func (s *MemoryStore) RestoreAsset(ctx context.Context, assetID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	asset, ok := s.assets[assetID]
+	if !ok || asset.DeletedAt == nil {
+		return ErrNotFound
+	}
+	asset.DeletedAt = nil
+	asset.DeletedBy = ""
+	asset.Version++
+	s.assets[assetID] = asset
+	return nil
+}
+
+func (s *MemoryStore) PurgeAssets(ctx context.Context, deletedBefore time.Time) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var purged int64
+	referenced := s.referenced(func(m models.EmployeeAssetMapping) string { return m.AssetID })
+	for id, asset := range s.assets {
+		if asset.DeletedAt != nil && asset.DeletedAt.Before(deletedBefore) && !referenced[id] {
+			delete(s.assets, id)
+			purged++
+		}
+	}
+	return purged, nil
+}
+
+// referenced returns the keys that key extracts from the mappings. The
+// caller holds s.mu.
+func (s *MemoryStore) referenced(key func(models.EmployeeAssetMapping) string) map[string]bool {
+	keys := make(map[string]bool, len(s.mappings))
+	for _, mapping := range s.mappings {
+		keys[key(mapping)] = true
+	}
+	return keys
+}
+
+// closeMappings closes the active mappings matched by match with cascade, or
+// fails with ErrActiveMappings when cascade is nil. The caller holds s.mu.
+func (s *MemoryStore) closeMappings(match func(models.EmployeeAssetMapping) bool, cascade *models.MappingReturn) error {
+	var active []string
+	for id, mapping := range s.mappings {
+		if mapping.Status == models.MappingStatusActive && match(mapping) {
+			active = append(active, id)
+		}
+	}
+	if len(active) > 0 && cascade == nil {
+		return ErrActiveMappings
+	}
+	for _, id := range active {
+		mapping := s.mappings[id]
+		applyReturn(&mapping, *cascade)
+		mapping.Version++
+		s.mappings[id] = mapping
+	}
+	return nil
+}
+
+func (s *MemoryStore) CreateMapping(ctx context.Context, mapping *models.EmployeeAssetMapping) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.createMapping(mapping)
+}
+
+func (s *MemoryStore) createMapping(mapping *models.EmployeeAssetMapping) error {
+	employee, ok := s.employees[mapping.EmployeeID]
+	if !ok || employee.DeletedAt != nil {
+		return ErrNotFound
+	}
+	asset, ok := s.assets[mapping.AssetID]
+	if !ok || asset.DeletedAt != nil {
+		return ErrNotFound
+	}
+	if mapping.Exclusive && mapping.Status == models.MappingStatusActive {
+		for _, other := range s.mappings {
+			if other.AssetID == mapping.AssetID && other.Exclusive && other.Status == models.MappingStatusActive {
+				return ErrAssetAssigned
+			}
+		}
+	}
+	mapping.Version = 1
+	s.mappings[mapping.MappingID] = *mapping
+	return nil
+}
+
+func (s *MemoryStore) GetMapping(ctx context.Context, mappingID string) (*models.EmployeeAssetMapping, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	mapping, ok := s.mappings[mappingID]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return &mapping, nil
+}
+
+func (s *MemoryStore) ListMappingsByEmployee(ctx context.Context, empID, status string) ([]models.EmployeeAssetMapping, error) {
+	return s.listMappings(func(m models.EmployeeAssetMapping) bool {
+		return m.EmployeeID == empID && (status == "" || m.Status == status)
+	}), nil
+}
+
+func (s *MemoryStore) ListMappingsByAsset(ctx context.Context, assetID, status string) ([]models.EmployeeAssetMapping, error) {
+	return s.listMappings(func(m models.EmployeeAssetMapping) bool {
+		return m.AssetID == assetID && (status == "" || m.Status == status)
+	}), nil
+}
+
+// listMappings returns the mappings accepted by match, oldest first.
+func (s *MemoryStore) listMappings(match func(models.EmployeeAssetMapping) bool) []models.EmployeeAssetMapping {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	var mappings []models.EmployeeAssetMapping
+	for _, mapping := range s.mappings {
+		if match(mapping) {
+			mappings = append(mappings, mapping)
+		}
+	}
+	sort.Slice(mappings, func(i, j int) bool { return mappings[i].AssignedDate.Before(mappings[j].AssignedDate) })
+	return mappings
+}
+
+func (s *MemoryStore) ListMappings(ctx context.Context, q MappingQuery) ([]models.EmployeeAssetMapping, int64, error) {
+	mappings := s.listMappings(func(m models.EmployeeAssetMapping) bool {
+		return (q.EmployeeID == "" || m.EmployeeID == q.EmployeeID) &&
+			(q.AssetID == "" || m.AssetID == q.AssetID) &&
+			(q.Status == "" || m.Status == q.Status) &&
+			inRange(m.AssignedDate, q.AssignedAfter, q.AssignedBefore)
+	})
+	return pageOf(mappings, q.ListOptions, MappingSortFields, MappingCursor)
+}
+
+// pageOf sorts records as opts ask, using cursor to find the position of
+// each, and returns those on the page opts select along with their total.
+func pageOf[T any](records []T, opts ListOptions, fields []string, cursor func(*T, string) Cursor) ([]T, int64, error) {
+	field, err := sortField(opts, fields)
+	if err != nil {
+		return nil, 0, err
+	}
+	before := func(a, b Cursor) bool {
+		if opts.Desc {
+			a, b = b, a
+		}
+		return a.Key < b.Key || (a.Key == b.Key && a.ID < b.ID)
+	}
+	sort.Slice(records, func(i, j int) bool {
+		return before(cursor(&records[i], field), cursor(&records[j], field))
+	})
+
+	total := int64(len(records))
+	if opts.After != nil {
+		if _, err := cursorValue(opts.After, field); err != nil {
+			return nil, 0, err
+		}
+		start := sort.Search(len(records), func(i int) bool {
+			return before(*opts.After, cursor(&records[i], field))
+		})
+		records = records[start:]
+	}
+	if opts.Limit > 0 && len(records) > opts.Limit {
+		records = records[:opts.Limit]
+	}
+	return records, total, nil
+}
+
+func hasPrefixFold(s, prefix string) bool {
+	return strings.HasPrefix(strings.ToLower(s), strings.ToLower(prefix))
+}
+
+func hasRole(roles []string, role string) bool {
+	for _, held := range roles {
+		if held == role {
+			return true
+		}
+	}
+	return false
+}
+
+// inRange reports whether t lies strictly between after and before, either
+// of which may be zero.
+func inRange(t, after, before time.Time) bool {
+	return (after.IsZero() || t.After(after)) && (before.IsZero() || t.Before(before))
+}
+
+// inDateRange is inRange for optional dates, which are out of every range
+// when unset.
+func inDateRange(t *time.Time, after, before time.Time) bool {
+	if after.IsZero() && before.IsZero() {
+		return true
+	}
+	return t != nil && inRange(*t, after, before)
+}
+
+func (s *MemoryStore) ReturnMapping(ctx context.Context, mappingID string, ret models.MappingReturn) (*models.EmployeeAssetMapping, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.returnMapping(mappingID, ret)
+}
+
+func (s *MemoryStore) returnMapping(mappingID string, ret models.MappingReturn) (*models.EmployeeAssetMapping, error) {
+	mapping, ok := s.mappings[mappingID]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	if ret.IfVersion != 0 && mapping.Version != ret.IfVersion {
+		return nil, ErrStaleVersion
+	}
+	if mapping.Status != models.MappingStatusActive {
+		return nil, ErrMappingClosed
+	}
+	applyReturn(&mapping, ret)
+	mapping.Version++
+	s.mappings[mappingID] = mapping
+	return &mapping, nil
+}
+
+// ApplyMappingChanges holds the lock for the whole batch and, when an atomic
+// one fails, puts back the mappings it started from.
+func (s *MemoryStore) ApplyMappingChanges(ctx context.Context, changes []MappingChange, atomic bool) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	saved := maps.Clone(s.mappings)
+	for i := range changes {
+		c := &changes[i]
+		if c.Assign != nil {
+			if c.Err = s.createMapping(c.Assign); c.Err == nil {
+				c.Result = c.Assign
+			}
+		} else {
+			c.Result, c.Err = s.returnMapping(c.MappingID, c.Return)
+		}
+	}
+	if atomic && failed(changes) {
+		s.mappings = saved
+	}
+	return nil
+}
+
+func (s *MemoryStore) UpdateMapping(ctx context.Context, mapping *models.EmployeeAssetMapping) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	current, ok := s.mappings[mapping.MappingID]
+	if !ok {
+		return ErrNotFound
+	}
+	if current.Version != mapping.Version {
+		return ErrStaleVersion
+	}
+	current.Notes = mapping.Notes
+	current.ReturnCondition = mapping.ReturnCondition
+	current.CloseReason = mapping.CloseReason
+	current.Version++
+	mapping.Version = current.Version
+	s.mappings[mapping.MappingID] = current
+	return nil
+}
+
+func (s *MemoryStore) CreateRefreshToken(ctx context.Context, token *models.RefreshToken) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.refreshTokens[token.TokenHash] = *token
+	return nil
+}
+
+func (s *MemoryStore) UseRefreshToken(ctx context.Context, tokenHash string, at time.Time) (*models.RefreshToken, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	token, ok := s.refreshTokens[tokenHash]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	if token.UsedAt != nil {
+		return &token, ErrTokenReused
+	}
+	token.UsedAt = &at
+	s.refreshTokens[tokenHash] = token
+	return &token, nil
+}
+
+func (s *MemoryStore) RevokeTokenFamily(ctx context.Context, familyID string, at time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for hash, token := range s.refreshTokens {
+		if token.FamilyID == familyID && token.RevokedAt == nil {
+			token.RevokedAt = &at
+			s.refreshTokens[hash] = token
+		}
+	}
+	return nil
+}
+
+func (s *MemoryStore) IsTokenFamilyRevoked(ctx context.Context, familyID string) (bool, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	for _, token := range s.refreshTokens {
+		if token.FamilyID == familyID && token.RevokedAt != nil {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (s *MemoryStore) PurgeRefreshTokens(ctx context.Context, expiredBefore time.Time) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var purged int64
+	for hash, token := range s.refreshTokens {
+		if token.ExpiresAt.Before(expiredBefore) {
+			delete(s.refreshTokens, hash)
+			purged++
+		}
+	}
+	return purged, nil
+}
+
+func (s *MemoryStore) AppendAudit(ctx context.Context, entry *models.AuditEntry) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.audit = append(s.audit, *entry)
+	return nil
+}
+
+func (s *MemoryStore) ListAudit(ctx context.Context, q AuditQuery) ([]models.AuditEntry, int64, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	// Entries are appended in order, so walking backwards yields newest first.
+	var matched []models.AuditEntry
+	for i := len(s.audit) - 1; i >= 0; i-- {
+		entry := s.audit[i]
+		switch {
+		case q.ActorID != "" && entry.ActorID != q.ActorID,
+			q.Action != "" && entry.Action != q.Action,
+			q.EntityType != "" && entry.EntityType != q.EntityType,
+			q.EntityID != "" && entry.EntityID != q.EntityID,
+			!q.From.IsZero() && entry.Timestamp.Before(q.From),
+			!q.To.IsZero() && entry.Timestamp.After(q.To):
+			continue
+		}
+		matched = append(matched, entry)
+	}
+
+	total := int64(len(matched))
+	if q.Offset >= len(matched) {
+		return nil, total, nil
+	}
+	matched = matched[q.Offset:]
+	if q.Limit > 0 && q.Limit < len(matched) {
+		matched = matched[:q.Limit]
+	}
+	return matched, total, nil
+}
+
+func (s *MemoryStore) AppendVersion(ctx context.Context, v *models.RecordVersion) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	key := v.EntityType + "/" + v.EntityID
+	v.Version = len(s.versions[key]) + 1
+	s.versions[key] = append(s.versions[key], *v)
+	return nil
+}
+
+func (s *MemoryStore) ListVersions(ctx context.Context, entityType, entityID string) ([]models.RecordVersion, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return append([]models.RecordVersion(nil), s.versions[entityType+"/"+entityID]...), nil
+}
+
+func (s *MemoryStore) GetVersion(ctx context.Context, entityType, entityID string, version int) (*models.RecordVersion, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	versions := s.versions[entityType+"/"+entityID]
+	if version < 1 || version > len(versions) {
+		return nil, ErrNotFound
+	}
+	v := versions[version-1]
+	return &v, nil
+}
+
+func (s *MemoryStore) Search(ctx context.Context, q SearchQuery) ([]SearchHit, error) {
+	terms := SearchTerms(q.Text)
+	if len(terms) == 0 {
+		return nil, nil
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var hits []SearchHit
+	add := func(entityType, id string, values map[string]string, fields []searchField) {
+		if score := scoreFields(values, fields, terms, q.Prefix); score > 0 {
+			hits = append(hits, SearchHit{Type: entityType, ID: id, Score: score, Fields: values})
+		}
+	}
+	if q.searches(models.AuditEntityEmployee) {
+		for _, e := range s.employees {
+			if e.DeletedAt == nil {
+				add(models.AuditEntityEmployee, e.EmpID, employeeSearchValues(&e), employeeSearchFields)
+			}
+		}
+	}
+	if q.searches(models.AuditEntityAsset) {
+		for _, a := range s.assets {
+			if a.DeletedAt == nil {
+				add(models.AuditEntityAsset, a.AssetID, assetSearchValues(&a), assetSearchFields)
+			}
+		}
+	}
+	return rankHits(q.Limit, hits), nil
+}
+
+func (s *MemoryStore) AssetStatusCounts(ctx context.Context, r ReportRange) ([]models.AssetStatusCount, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	assigned := make(map[string]bool)
+	latest := make(map[string]models.EmployeeAssetMapping)
+	for _, m := range s.mappings {
+		if m.Status == models.MappingStatusActive {
+			assigned[m.AssetID] = true
+		}
+		last, ok := latest[m.AssetID]
+		if !ok || m.AssignedDate.After(last.AssignedDate) ||
+			(m.AssignedDate.Equal(last.AssignedDate) && m.MappingID > last.MappingID) {
+			latest[m.AssetID] = m
+		}
+	}
+
+	type key struct{ assetType, status string }
+	counts := make(map[key]int64)
+	for _, a := range s.assets {
+		if a.DeletedAt == nil && r.contains(a.CreatedAt) {
+			counts[key{a.AssetType, assetStatus(assigned[a.AssetID], latest[a.AssetID].Status)}]++
+		}
+	}
+	result := make([]models.AssetStatusCount, 0, len(counts))
+	for k, n := range counts {
+		result = append(result, models.AssetStatusCount{AssetType: k.assetType, Status: k.status, Count: n})
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].AssetType != result[j].AssetType {
+			return result[i].AssetType < result[j].AssetType
+		}
+		return result[i].Status < result[j].Status
+	})
+	return result, nil
+}
+
+func (s *MemoryStore) DepartmentAssignments(ctx context.Context, r ReportRange) ([]models.DepartmentAssignments, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	byDepartment := make(map[string]*models.DepartmentAssignments)
+	for _, m := range s.mappings {
+		if !r.contains(m.AssignedDate) {
+			continue
+		}
+		department := s.employees[m.EmployeeID].Department
+		d, ok := byDepartment[department]
+		if !ok {
+			d = &models.DepartmentAssignments{Department: department}
+			byDepartment[department] = d
+		}
+		d.Assignments++
+		if m.Status == models.MappingStatusActive {
+			d.Active++
+		}
+	}
+	result := make([]models.DepartmentAssignments, 0, len(byDepartment))
+	for _, d := range byDepartment {
+		result = append(result, *d)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Department < result[j].Department })
+	return result, nil
+}
+
+func (s *MemoryStore) AssignmentDuration(ctx context.Context, r ReportRange) (models.AssignmentDuration, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var d durationSum
+	for _, m := range s.mappings {
+		if m.Status != models.MappingStatusActive && m.ReturnedDate != nil && r.contains(m.AssignedDate) {
+			d.add(m.AssignedDate, *m.ReturnedDate)
+		}
+	}
+	return d.report(), nil
+}
+
+func (s *MemoryStore) TopHolders(ctx context.Context, r ReportRange, limit int) ([]models.AssetHolder, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	counts := make(map[string]int64)
+	for _, m := range s.mappings {
+		if m.Status == models.MappingStatusActive && r.contains(m.AssignedDate) {
+			counts[m.EmployeeID]++
+		}
+	}
+	holders := make([]models.AssetHolder, 0, len(counts))
+	for empID, n := range counts {
+		e := s.employees[empID]
+		holders = append(holders, models.AssetHolder{
+			EmployeeID:   empID,
+			FirstName:    e.FirstName,
+			LastName:     e.LastName,
+			Department:   e.Department,
+			ActiveAssets: n,
+		})
+	}
+	sort.Slice(holders, func(i, j int) bool {
+		if holders[i].ActiveAssets != holders[j].ActiveAssets {
+			return holders[i].ActiveAssets > holders[j].ActiveAssets
+		}
+		return holders[i].EmployeeID < holders[j].EmployeeID
+	})
+	if limit > 0 && len(holders) > limit {
+		holders = holders[:limit]
+	}
+	return holders, nil
+}
@@ -0,0 +1,143 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+
+	"employee-asset-system/models"
+)
+
+// seedAssets adds assets a0..a6 to s. Their names, types and creation times
+// repeat so that listings have to break ties by ID.
+func seedAssets(t *testing.T, s *MemoryStore) {
+	t.Helper()
+	base := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	for i := 0; i < 7; i++ {
+		asset := &models.Asset{
+			AssetID:   fmt.Sprintf("a%d", i),
+			AssetName: []string{"Dock", "Laptop", "Monitor"}[i%3],
+			AssetType: []string{"laptop", "monitor"}[i%2],
+			CreatedAt: base.Add(time.Duration(i/2) * time.Hour),
+		}
+		asset.UpdatedAt = asset.CreatedAt
+		if err := s.CreateAsset(context.Background(), asset); err != nil {
+			t.Fatalf("CreateAsset: %v", err)
+		}
+	}
+}
+
+// walkAssets lists every asset q selects a page of limit at a time and
+// returns their IDs in order.
+func walkAssets(t *testing.T, s *MemoryStore, q AssetQuery, limit int) []string {
+	t.Helper()
+	q.Limit = limit
+	var ids []string
+	for page := 0; ; page++ {
+		if page > 10 {
+			t.Fatal("paging does not end")
+		}
+		assets, total, err := s.ListAssets(context.Background(), q)
+		if err != nil {
+			t.Fatalf("ListAssets: %v", err)
+		}
+		if total != 7 {
+			t.Errorf("total = %d, want 7", total)
+		}
+		for i := range assets {
+			ids = append(ids, assets[i].AssetID)
+		}
+		if len(assets) < limit {
+			return ids
+		}
+		sort, _ := sortField(q.ListOptions, AssetSortFields)
+		last := AssetCursor(&assets[len(assets)-1], sort)
+		q.After = &last
+	}
+}
+
+func TestMemoryStoreAssetPaging(t *testing.T) {
+	tests := []struct {
+		sort string
+		desc bool
+		want []string
+	}{
+		{"", false, []string{"a0", "a1", "a2", "a3", "a4", "a5", "a6"}},
+		{"created_at", true, []string{"a6", "a5", "a4", "a3", "a2", "a1", "a0"}},
+		{"asset_name", false, []string{"a0", "a3", "a6", "a1", "a4", "a2", "a5"}},
+		{"asset_type", true, []string{"a5", "a3", "a1", "a6", "a4", "a2", "a0"}},
+		{"asset_id", false, []string{"a0", "a1", "a2", "a3", "a4", "a5", "a6"}},
+	}
+	s := NewMemoryStore()
+	seedAssets(t, s)
+	for _, tt := range tests {
+		for _, limit := range []int{1, 2, 3, 7} {
+			t.Run(fmt.Sprintf("%s desc=%v limit=%d", tt.sort, tt.desc, limit), func(t *testing.T) {
+				got := walkAssets(t, s, AssetQuery{ListOptions: ListOptions{Sort: tt.sort, Desc: tt.desc}}, limit)
+				if !reflect.DeepEqual(got, tt.want) {
+					t.Errorf("paged through %v, want %v", got, tt.want)
+				}
+			})
+		}
+	}
+}
+
+func TestMemoryStorePagingIsStable(t *testing.T) {
+	ctx := context.Background()
+	s := NewMemoryStore()
+	seedAssets(t, s)
+
+	q := AssetQuery{ListOptions: ListOptions{Sort: "asset_name", Limit: 3}}
+	first, _, err := s.ListAssets(ctx, q)
+	if err != nil {
+		t.Fatalf("ListAssets: %v", err)
+	}
+	last := AssetCursor(&first[len(first)-1], "asset_name")
+
+	// A record sorting before the cursor and the removal of one already
+	// listed must not shift the next page.
+	early := &models.Asset{AssetID: "b0", AssetName: "Adapter", CreatedAt: time.Now(), UpdatedAt: time.Now()}
+	if err := s.CreateAsset(ctx, early); err != nil {
+		t.Fatalf("CreateAsset: %v", err)
+	}
+	if err := s.DeleteAsset(ctx, first[0].AssetID, models.Deletion{DeletedAt: time.Now()}); err != nil {
+		t.Fatalf("DeleteAsset: %v", err)
+	}
+
+	q.After = &last
+	next, _, err := s.ListAssets(ctx, q)
+	if err != nil {
+		t.Fatalf("ListAssets: %v", err)
+	}
+	var got []string
+	for i := range next {
+		got = append(got, next[i].AssetID)
+	}
+	if want := []string{"a1", "a4", "a2"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("next page = %v, want %v", got, want)
+	}
+}
+
+func TestMemoryStorePagingErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		opts ListOptions
+		err  error
+	}{
+		{"unknown sort field", ListOptions{Sort: "purchase_cost"}, ErrInvalidSort},
+		{"cursor of another sort field", ListOptions{Sort: "created_at", After: &Cursor{Key: "Laptop", ID: "a1"}}, ErrInvalidCursor},
+	}
+	s := NewMemoryStore()
+	seedAssets(t, s)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, _, err := s.ListAssets(context.Background(), AssetQuery{ListOptions: tt.opts})
+			if !errors.Is(err, tt.err) {
+				t.Errorf("ListAssets: err = %v, want %v", err, tt.err)
+			}
+		})
+	}
+}
@@ -0,0 +1,89 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"io/fs"
+	"path/filepath"
+	"strings"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"employee-asset-system/models"
+)
+
+// migrationsBefore returns the migrations of fsys in dir older than version.
+func migrationsBefore(t *testing.T, fsys fs.FS, dir string, version int) fs.FS {
+	t.Helper()
+	migrations, err := loadMigrations(fsys, dir)
+	if err != nil {
+		t.Fatalf("loadMigrations: %v", err)
+	}
+	older := fstest.MapFS{}
+	for _, m := range migrations {
+		if m.version < version {
+			older[dir+"/"+m.name] = &fstest.MapFile{Data: []byte(m.sql)}
+		}
+	}
+	return older
+}
+
+// openSQLiteAt opens a new SQLite database migrated to just before version
+// and runs setup against it.
+func openSQLiteAt(t *testing.T, version int, setup string) *sql.DB {
+	t.Helper()
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "test.db")
+	conn, err := sql.Open("sqlite", "file:"+path+"?_pragma=foreign_keys(1)")
+	if err != nil {
+		t.Fatalf("sql.Open: %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	if err := migrate(ctx, conn, migrationsBefore(t, sqliteMigrations, "migrations/sqlite", version), "migrations/sqlite"); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	if _, err := conn.ExecContext(ctx, setup); err != nil {
+		t.Fatalf("setup: %v", err)
+	}
+	return conn
+}
+
+func TestSQLiteExclusiveBackfill(t *testing.T) {
+	const employees = `
+		INSERT INTO employees (emp_id, created_at, updated_at) VALUES ('e1', '2026-01-01', '2026-01-01'), ('e2', '2026-01-01', '2026-01-01');
+		INSERT INTO assets (asset_id, created_at, updated_at) VALUES ('a1', '2026-01-01', '2026-01-01'), ('a2', '2026-01-01', '2026-01-01');`
+	ctx := context.Background()
+
+	t.Run("active mappings", func(t *testing.T) {
+		conn := openSQLiteAt(t, 6, employees+`
+			INSERT INTO mappings (mapping_id, employee_id, asset_id, assigned_date, status) VALUES
+				('m1', 'e1', 'a1', '2026-01-02', 'active'),
+				('m2', 'e1', 'a2', '2026-01-02', 'returned'),
+				('m3', 'e2', 'a2', '2026-01-03', 'active');`)
+		if err := migrate(ctx, conn, sqliteMigrations, "migrations/sqlite"); err != nil {
+			t.Fatalf("migrate: %v", err)
+		}
+
+		s := &SQLStore{db: conn, translate: sqliteError, searchSQL: sqliteSearchSQL}
+		for _, asset := range []string{"a1", "a2"} {
+			err := s.CreateMapping(ctx, &models.EmployeeAssetMapping{MappingID: "new-" + asset, EmployeeID: "e2",
+				AssetID: asset, AssignedDate: time.Now(), Status: models.MappingStatusActive, Exclusive: true})
+			if !errors.Is(err, ErrAssetAssigned) {
+				t.Errorf("assigning %s held by an existing mapping: err = %v, want ErrAssetAssigned", asset, err)
+			}
+		}
+	})
+
+	t.Run("asset assigned twice", func(t *testing.T) {
+		conn := openSQLiteAt(t, 6, employees+`
+			INSERT INTO mappings (mapping_id, employee_id, asset_id, assigned_date, status) VALUES
+				('m1', 'e1', 'a1', '2026-01-02', 'active'),
+				('m2', 'e2', 'a1', '2026-01-03', 'active');`)
+		err := migrate(ctx, conn, sqliteMigrations, "migrations/sqlite")
+		if err == nil || !strings.Contains(err.Error(), "0006_exclusive_assignments.sql") {
+			t.Errorf("migrate: err = %v, want the 0006 migration to fail", err)
+		}
+	})
+}
@@ -0,0 +1,6 @@
+-- Returning an asset closes its mapping instead of deleting it.
+ALTER TABLE mappings ADD COLUMN returned_date TIMESTAMPTZ;
+ALTER TABLE mappings ADD COLUMN returned_by TEXT NOT NULL DEFAULT '';
+ALTER TABLE mappings ADD COLUMN return_condition TEXT NOT NULL DEFAULT '';
+
+CREATE INDEX mappings_status_idx ON mappings (status);
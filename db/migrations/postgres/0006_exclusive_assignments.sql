@@ -0,0 +1,26 @@
+-- Shared assets may be checked out to several employees at once; every
+-- other asset can only have one active mapping. The partial unique index
+-- enforces that atomically, even for concurrent checkouts.
+ALTER TABLE assets ADD COLUMN shared BOOLEAN NOT NULL DEFAULT FALSE;
+ALTER TABLE mappings ADD COLUMN exclusive BOOLEAN NOT NULL DEFAULT FALSE;
+
+-- Mappings that are already active fall under the index too.
+UPDATE mappings SET exclusive = NOT assets.shared
+    FROM assets
+    WHERE assets.asset_id = mappings.asset_id AND mappings.status = 'active';
+
+DO $$
+DECLARE
+    duplicated TEXT;
+BEGIN
+    SELECT string_agg(asset_id, ', ' ORDER BY asset_id) INTO duplicated FROM (
+        SELECT asset_id FROM mappings WHERE status = 'active' AND exclusive
+        GROUP BY asset_id HAVING COUNT(*) > 1
+    ) AS assigned;
+    IF duplicated IS NOT NULL THEN
+        RAISE EXCEPTION 'assets with more than one active mapping: %; return all but one mapping of each before upgrading', duplicated;
+    END IF;
+END $$;
+
+CREATE UNIQUE INDEX mappings_active_exclusive_asset_idx ON mappings (asset_id)
+    WHERE status = 'active' AND exclusive;
@@ -0,0 +1,4 @@
+-- Closed mappings are kept as the assignment history of soft deleted
+-- employees and assets. Deletes are refused while active mappings exist, or
+-- close them first with a reason.
+ALTER TABLE mappings ADD COLUMN close_reason TEXT NOT NULL DEFAULT '';
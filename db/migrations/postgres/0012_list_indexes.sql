@@ -0,0 +1,5 @@
+-- Listings page through records in the order of their sort field and ID.
+CREATE INDEX employees_created_at_idx ON employees (created_at, emp_id);
+CREATE INDEX assets_created_at_idx ON assets (created_at, asset_id);
+CREATE INDEX assets_asset_type_idx ON assets (asset_type);
+CREATE INDEX mappings_employee_assigned_idx ON mappings (employee_id, assigned_date, mapping_id);
@@ -0,0 +1,14 @@
+-- Full-text search. The indexed expressions must match pgSearchVector so
+-- that searches can use them.
+CREATE INDEX employees_search_idx ON employees USING GIN ((
+    setweight(to_tsvector('simple', regexp_replace(first_name, '[^[:alnum:]]+', ' ', 'g')), 'A') ||
+    setweight(to_tsvector('simple', regexp_replace(last_name, '[^[:alnum:]]+', ' ', 'g')), 'A') ||
+    setweight(to_tsvector('simple', regexp_replace(employee_email, '[^[:alnum:]]+', ' ', 'g')), 'B') ||
+    setweight(to_tsvector('simple', regexp_replace(phone_number, '[^[:alnum:]]+', ' ', 'g')), 'B') ||
+    setweight(to_tsvector('simple', regexp_replace(address, '[^[:alnum:]]+', ' ', 'g')), 'C')
+));
+
+CREATE INDEX assets_search_idx ON assets USING GIN ((
+    setweight(to_tsvector('simple', regexp_replace(asset_name, '[^[:alnum:]]+', ' ', 'g')), 'A') ||
+    setweight(to_tsvector('simple', regexp_replace(asset_type, '[^[:alnum:]]+', ' ', 'g')), 'B')
+));
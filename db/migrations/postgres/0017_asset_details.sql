@@ -0,0 +1,22 @@
+-- Purchase and warranty details of assets, and the filters over them.
+ALTER TABLE assets ADD COLUMN manufacturer TEXT NOT NULL DEFAULT '';
+ALTER TABLE assets ADD COLUMN invoice_number TEXT NOT NULL DEFAULT '';
+ALTER TABLE assets ADD COLUMN warranty_expiry TIMESTAMPTZ;
+
+CREATE INDEX assets_invoice_number_idx ON assets (invoice_number);
+CREATE INDEX assets_purchase_date_idx ON assets (purchase_date);
+CREATE INDEX assets_warranty_expiry_idx ON assets (warranty_expiry);
+
+-- Search assets by their tags, serial numbers and purchase details too. The
+-- expression must match pgSearchVector.
+DROP INDEX assets_search_idx;
+CREATE INDEX assets_search_idx ON assets USING GIN ((
+    setweight(to_tsvector('simple', regexp_replace(asset_name, '[^[:alnum:]]+', ' ', 'g')), 'A') ||
+    setweight(to_tsvector('simple', regexp_replace(asset_tag, '[^[:alnum:]]+', ' ', 'g')), 'A') ||
+    setweight(to_tsvector('simple', regexp_replace(serial_number, '[^[:alnum:]]+', ' ', 'g')), 'A') ||
+    setweight(to_tsvector('simple', regexp_replace(asset_type, '[^[:alnum:]]+', ' ', 'g')), 'B') ||
+    setweight(to_tsvector('simple', regexp_replace(manufacturer, '[^[:alnum:]]+', ' ', 'g')), 'B') ||
+    setweight(to_tsvector('simple', regexp_replace(model, '[^[:alnum:]]+', ' ', 'g')), 'B') ||
+    setweight(to_tsvector('simple', regexp_replace(vendor, '[^[:alnum:]]+', ' ', 'g')), 'C') ||
+    setweight(to_tsvector('simple', regexp_replace(invoice_number, '[^[:alnum:]]+', ' ', 'g')), 'C')
+));
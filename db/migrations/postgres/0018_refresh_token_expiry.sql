@@ -0,0 +1,2 @@
+-- Expired refresh tokens are purged by their expiry time.
+CREATE INDEX refresh_tokens_expires_at_idx ON refresh_tokens (expires_at);
@@ -0,0 +1,2 @@
+-- Imports compare emails regardless of case.
+CREATE INDEX employees_employee_email_lower_idx ON employees (LOWER(employee_email));
@@ -0,0 +1,37 @@
+CREATE TABLE employees (
+    emp_id                   TEXT PRIMARY KEY,
+    first_name               TEXT NOT NULL DEFAULT '',
+    last_name                TEXT NOT NULL DEFAULT '',
+    gender                   TEXT NOT NULL DEFAULT '',
+    phone_number             TEXT NOT NULL DEFAULT '',
+    employee_email           TEXT NOT NULL DEFAULT '',
+    address                  TEXT NOT NULL DEFAULT '',
+    blood_group              TEXT NOT NULL DEFAULT '',
+    emergency_contact_number TEXT NOT NULL DEFAULT '',
+    password                 TEXT NOT NULL DEFAULT '',
+    created_at               DATETIME NOT NULL,
+    updated_at               DATETIME NOT NULL
+);
+
+CREATE INDEX employees_phone_number_idx ON employees (phone_number);
+CREATE INDEX employees_employee_email_idx ON employees (employee_email);
+
+CREATE TABLE assets (
+    asset_id   TEXT PRIMARY KEY,
+    asset_name TEXT NOT NULL DEFAULT '',
+    asset_type TEXT NOT NULL DEFAULT '',
+    created_at DATETIME NOT NULL,
+    updated_at DATETIME NOT NULL
+);
+
+CREATE TABLE mappings (
+    mapping_id    TEXT PRIMARY KEY,
+    employee_id   TEXT NOT NULL REFERENCES employees (emp_id),
+    asset_id      TEXT NOT NULL REFERENCES assets (asset_id),
+    assigned_date DATETIME NOT NULL,
+    status        TEXT NOT NULL DEFAULT '',
+    notes         TEXT NOT NULL DEFAULT ''
+);
+
+CREATE INDEX mappings_employee_id_idx ON mappings (employee_id);
+CREATE INDEX mappings_asset_id_idx ON mappings (asset_id);
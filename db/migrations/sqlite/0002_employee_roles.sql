@@ -0,0 +1,2 @@
+-- Roles are stored as a comma separated list, e.g. 'admin,manager'.
+ALTER TABLE employees ADD COLUMN roles TEXT NOT NULL DEFAULT '';
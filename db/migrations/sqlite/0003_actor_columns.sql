@@ -0,0 +1,5 @@
+ALTER TABLE employees ADD COLUMN created_by TEXT NOT NULL DEFAULT '';
+ALTER TABLE employees ADD COLUMN updated_by TEXT NOT NULL DEFAULT '';
+ALTER TABLE assets ADD COLUMN created_by TEXT NOT NULL DEFAULT '';
+ALTER TABLE assets ADD COLUMN updated_by TEXT NOT NULL DEFAULT '';
+ALTER TABLE mappings ADD COLUMN assigned_by TEXT NOT NULL DEFAULT '';
@@ -0,0 +1,11 @@
+CREATE TABLE refresh_tokens (
+    token_hash TEXT PRIMARY KEY,
+    family_id  TEXT NOT NULL,
+    emp_id     TEXT NOT NULL REFERENCES employees (emp_id) ON DELETE CASCADE,
+    created_at DATETIME NOT NULL,
+    expires_at DATETIME NOT NULL,
+    used_at    DATETIME,
+    revoked_at DATETIME
+);
+
+CREATE INDEX refresh_tokens_family_id_idx ON refresh_tokens (family_id);
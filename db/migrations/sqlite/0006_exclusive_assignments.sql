@@ -0,0 +1,14 @@
+-- Shared assets may be checked out to several employees at once; every
+-- other asset can only have one active mapping. The partial unique index
+-- enforces that atomically, even for concurrent checkouts.
+ALTER TABLE assets ADD COLUMN shared BOOLEAN NOT NULL DEFAULT 0;
+ALTER TABLE mappings ADD COLUMN exclusive BOOLEAN NOT NULL DEFAULT 0;
+
+-- Mappings that are already active fall under the index too. Creating it
+-- fails with a UNIQUE constraint error while an asset has several of them;
+-- return all but one mapping of each such asset before upgrading.
+UPDATE mappings SET exclusive = NOT (SELECT shared FROM assets WHERE assets.asset_id = mappings.asset_id)
+    WHERE status = 'active';
+
+CREATE UNIQUE INDEX mappings_active_exclusive_asset_idx ON mappings (asset_id)
+    WHERE status = 'active' AND exclusive = 1;
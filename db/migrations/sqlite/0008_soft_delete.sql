@@ -0,0 +1,8 @@
+-- Deleted employees and assets are kept as tombstones until purged.
+ALTER TABLE employees ADD COLUMN deleted_at DATETIME;
+ALTER TABLE employees ADD COLUMN deleted_by TEXT NOT NULL DEFAULT '';
+ALTER TABLE assets ADD COLUMN deleted_at DATETIME;
+ALTER TABLE assets ADD COLUMN deleted_by TEXT NOT NULL DEFAULT '';
+
+CREATE INDEX employees_deleted_at_idx ON employees (deleted_at);
+CREATE INDEX assets_deleted_at_idx ON assets (deleted_at);
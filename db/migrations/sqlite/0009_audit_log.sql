@@ -0,0 +1,26 @@
+CREATE TABLE audit_log (
+    entry_id    TEXT PRIMARY KEY,
+    timestamp   DATETIME NOT NULL,
+    actor_id    TEXT NOT NULL DEFAULT '',
+    action      TEXT NOT NULL,
+    entity_type TEXT NOT NULL,
+    entity_id   TEXT NOT NULL DEFAULT '',
+    changes     TEXT NOT NULL DEFAULT '{}', -- JSON object of field name to {before, after}
+    request_id  TEXT NOT NULL DEFAULT '',
+    source_ip   TEXT NOT NULL DEFAULT ''
+);
+
+CREATE INDEX audit_log_timestamp_idx ON audit_log (timestamp);
+CREATE INDEX audit_log_entity_idx ON audit_log (entity_type, entity_id);
+CREATE INDEX audit_log_actor_id_idx ON audit_log (actor_id);
+
+-- The audit log is append-only.
+CREATE TRIGGER audit_log_no_update BEFORE UPDATE ON audit_log
+BEGIN
+    SELECT RAISE(ABORT, 'audit_log is append-only');
+END;
+
+CREATE TRIGGER audit_log_no_delete BEFORE DELETE ON audit_log
+BEGIN
+    SELECT RAISE(ABORT, 'audit_log is append-only');
+END;
@@ -0,0 +1,11 @@
+CREATE TABLE record_versions (
+    entity_type    TEXT NOT NULL,
+    entity_id      TEXT NOT NULL,
+    version        INTEGER NOT NULL,
+    action         TEXT NOT NULL,
+    rolled_back_to INTEGER NOT NULL DEFAULT 0,
+    changed_at     DATETIME NOT NULL,
+    changed_by     TEXT NOT NULL DEFAULT '',
+    snapshot       TEXT NOT NULL, -- the record as a JSON object
+    PRIMARY KEY (entity_type, entity_id, version)
+);
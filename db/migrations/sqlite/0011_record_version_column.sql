@@ -0,0 +1,5 @@
+-- Every write bumps the version of the record it touches; updates are
+-- conditional on it so concurrent edits cannot overwrite each other.
+ALTER TABLE employees ADD COLUMN version INTEGER NOT NULL DEFAULT 1;
+ALTER TABLE assets ADD COLUMN version INTEGER NOT NULL DEFAULT 1;
+ALTER TABLE mappings ADD COLUMN version INTEGER NOT NULL DEFAULT 1;
@@ -0,0 +1,13 @@
+-- Listings page through records in the order of their sort field and ID.
+CREATE INDEX employees_created_at_idx ON employees (created_at, emp_id);
+CREATE INDEX assets_created_at_idx ON assets (created_at, asset_id);
+CREATE INDEX assets_asset_type_idx ON assets (asset_type);
+CREATE INDEX mappings_employee_assigned_idx ON mappings (employee_id, assigned_date, mapping_id);
+
+-- Times are compared as text, so drop the monotonic clock reading that
+-- older versions stored after them.
+UPDATE employees SET created_at = substr(created_at, 1, instr(created_at, ' m=') - 1) WHERE instr(created_at, ' m=') > 0;
+UPDATE employees SET updated_at = substr(updated_at, 1, instr(updated_at, ' m=') - 1) WHERE instr(updated_at, ' m=') > 0;
+UPDATE assets SET created_at = substr(created_at, 1, instr(created_at, ' m=') - 1) WHERE instr(created_at, ' m=') > 0;
+UPDATE assets SET updated_at = substr(updated_at, 1, instr(updated_at, ' m=') - 1) WHERE instr(updated_at, ' m=') > 0;
+UPDATE mappings SET assigned_date = substr(assigned_date, 1, instr(assigned_date, ' m=') - 1) WHERE instr(assigned_date, ' m=') > 0;
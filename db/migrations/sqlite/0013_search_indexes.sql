@@ -0,0 +1,51 @@
+-- Full-text search. The FTS5 tables index the rows of their table by rowid
+-- and are kept in step with it by triggers.
+CREATE VIRTUAL TABLE employees_fts USING fts5(
+    first_name, last_name, employee_email, phone_number, address,
+    content = 'employees', content_rowid = 'rowid'
+);
+
+CREATE TRIGGER employees_fts_insert AFTER INSERT ON employees
+BEGIN
+    INSERT INTO employees_fts (rowid, first_name, last_name, employee_email, phone_number, address)
+    VALUES (new.rowid, new.first_name, new.last_name, new.employee_email, new.phone_number, new.address);
+END;
+
+CREATE TRIGGER employees_fts_delete AFTER DELETE ON employees
+BEGIN
+    INSERT INTO employees_fts (employees_fts, rowid, first_name, last_name, employee_email, phone_number, address)
+    VALUES ('delete', old.rowid, old.first_name, old.last_name, old.employee_email, old.phone_number, old.address);
+END;
+
+CREATE TRIGGER employees_fts_update AFTER UPDATE ON employees
+BEGIN
+    INSERT INTO employees_fts (employees_fts, rowid, first_name, last_name, employee_email, phone_number, address)
+    VALUES ('delete', old.rowid, old.first_name, old.last_name, old.employee_email, old.phone_number, old.address);
+    INSERT INTO employees_fts (rowid, first_name, last_name, employee_email, phone_number, address)
+    VALUES (new.rowid, new.first_name, new.last_name, new.employee_email, new.phone_number, new.address);
+END;
+
+CREATE VIRTUAL TABLE assets_fts USING fts5(
+    asset_name, asset_type,
+    content = 'assets', content_rowid = 'rowid'
+);
+
+CREATE TRIGGER assets_fts_insert AFTER INSERT ON assets
+BEGIN
+    INSERT INTO assets_fts (rowid, asset_name, asset_type) VALUES (new.rowid, new.asset_name, new.asset_type);
+END;
+
+CREATE TRIGGER assets_fts_delete AFTER DELETE ON assets
+BEGIN
+    INSERT INTO assets_fts (assets_fts, rowid, asset_name, asset_type) VALUES ('delete', old.rowid, old.asset_name, old.asset_type);
+END;
+
+CREATE TRIGGER assets_fts_update AFTER UPDATE ON assets
+BEGIN
+    INSERT INTO assets_fts (assets_fts, rowid, asset_name, asset_type) VALUES ('delete', old.rowid, old.asset_name, old.asset_type);
+    INSERT INTO assets_fts (rowid, asset_name, asset_type) VALUES (new.rowid, new.asset_name, new.asset_type);
+END;
+
+-- Index the rows that already exist.
+INSERT INTO employees_fts (employees_fts) VALUES ('rebuild');
+INSERT INTO assets_fts (assets_fts) VALUES ('rebuild');
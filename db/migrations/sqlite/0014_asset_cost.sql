@@ -0,0 +1,5 @@
+-- What an asset cost, so the dashboard can total what employees hold.
+ALTER TABLE assets ADD COLUMN purchase_cost REAL NOT NULL DEFAULT 0;
+ALTER TABLE assets ADD COLUMN currency TEXT NOT NULL DEFAULT '';
+
+CREATE INDEX mappings_employee_status_idx ON mappings (employee_id, status);
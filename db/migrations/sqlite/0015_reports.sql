@@ -0,0 +1,7 @@
+-- The department employees belong to, which reports group assignments by.
+ALTER TABLE employees ADD COLUMN department TEXT NOT NULL DEFAULT '';
+
+-- Reports find the latest mapping of each asset and filter assignments by
+-- the date they were made.
+CREATE INDEX mappings_asset_assigned_idx ON mappings (asset_id, assigned_date);
+CREATE INDEX mappings_assigned_idx ON mappings (assigned_date);
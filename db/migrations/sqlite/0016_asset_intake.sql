@@ -0,0 +1,10 @@
+-- What bulk intake records about each unit of a shipment. Serial numbers
+-- and asset tags are unique when set, deleted assets included.
+ALTER TABLE assets ADD COLUMN asset_tag TEXT NOT NULL DEFAULT '';
+ALTER TABLE assets ADD COLUMN serial_number TEXT NOT NULL DEFAULT '';
+ALTER TABLE assets ADD COLUMN vendor TEXT NOT NULL DEFAULT '';
+ALTER TABLE assets ADD COLUMN model TEXT NOT NULL DEFAULT '';
+ALTER TABLE assets ADD COLUMN purchase_date DATETIME;
+
+CREATE UNIQUE INDEX assets_serial_number_idx ON assets (serial_number) WHERE serial_number <> '';
+CREATE UNIQUE INDEX assets_asset_tag_idx ON assets (asset_tag) WHERE asset_tag <> '';
@@ -0,0 +1,43 @@
+-- Purchase and warranty details of assets, and the filters over them.
+ALTER TABLE assets ADD COLUMN manufacturer TEXT NOT NULL DEFAULT '';
+ALTER TABLE assets ADD COLUMN invoice_number TEXT NOT NULL DEFAULT '';
+ALTER TABLE assets ADD COLUMN warranty_expiry DATETIME;
+
+CREATE INDEX assets_invoice_number_idx ON assets (invoice_number);
+CREATE INDEX assets_purchase_date_idx ON assets (purchase_date);
+CREATE INDEX assets_warranty_expiry_idx ON assets (warranty_expiry);
+
+-- Search assets by their tags, serial numbers and purchase details too. The
+-- columns must be in the order of assetSearchFields, whose weights bm25 is
+-- given by position.
+DROP TRIGGER assets_fts_insert;
+DROP TRIGGER assets_fts_delete;
+DROP TRIGGER assets_fts_update;
+DROP TABLE assets_fts;
+
+CREATE VIRTUAL TABLE assets_fts USING fts5(
+    asset_name, asset_tag, serial_number, asset_type, manufacturer, model, vendor, invoice_number,
+    content = 'assets', content_rowid = 'rowid'
+);
+
+CREATE TRIGGER assets_fts_insert AFTER INSERT ON assets
+BEGIN
+    INSERT INTO assets_fts (rowid, asset_name, asset_tag, serial_number, asset_type, manufacturer, model, vendor, invoice_number)
+    VALUES (new.rowid, new.asset_name, new.asset_tag, new.serial_number, new.asset_type, new.manufacturer, new.model, new.vendor, new.invoice_number);
+END;
+
+CREATE TRIGGER assets_fts_delete AFTER DELETE ON assets
+BEGIN
+    INSERT INTO assets_fts (assets_fts, rowid, asset_name, asset_tag, serial_number, asset_type, manufacturer, model, vendor, invoice_number)
+    VALUES ('delete', old.rowid, old.asset_name, old.asset_tag, old.serial_number, old.asset_type, old.manufacturer, old.model, old.vendor, old.invoice_number);
+END;
+
+CREATE TRIGGER assets_fts_update AFTER UPDATE ON assets
+BEGIN
+    INSERT INTO assets_fts (assets_fts, rowid, asset_name, asset_tag, serial_number, asset_type, manufacturer, model, vendor, invoice_number)
+    VALUES ('delete', old.rowid, old.asset_name, old.asset_tag, old.serial_number, old.asset_type, old.manufacturer, old.model, old.vendor, old.invoice_number);
+    INSERT INTO assets_fts (rowid, asset_name, asset_tag, serial_number, asset_type, manufacturer, model, vendor, invoice_number)
+    VALUES (new.rowid, new.asset_name, new.asset_tag, new.serial_number, new.asset_type, new.manufacturer, new.model, new.vendor, new.invoice_number);
+END;
+
+INSERT INTO assets_fts (assets_fts) VALUES ('rebuild');
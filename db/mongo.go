@@ -0,0 +1,1445 @@
+package db
+
+import (
+	"context"
+	"employee-asset-system/models"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// MongoStore implements Store on top of a MongoDB database.
+type MongoStore struct {
+	database *mongo.Database
+
+	mu           sync.Mutex
+	transactions *bool // whether the deployment supports transactions, once known
+}
+
+// NewMongoStore returns a Store backed by the given MongoDB database.
+func NewMongoStore(database *mongo.Database) *MongoStore {
+	return &MongoStore{database: database}
+}
+
+func (s *MongoStore) employees() *mongo.Collection { return s.database.Collection("employee") }
+func (s *MongoStore) assets() *mongo.Collection    { return s.database.Collection("asset") }
+func (s *MongoStore) mappings() *mongo.Collection  { return s.database.Collection("mapping") }
+
+// supportsTransactions reports whether the deployment runs multi-document
+// transactions, which takes a replica set or a sharded cluster. The server is
+// asked once.
+func (s *MongoStore) supportsTransactions(ctx context.Context) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.transactions == nil {
+		var hello struct {
+			SetName string `bson:"setName"`
+			Msg     string `bson:"msg"`
+		}
+		err := s.database.Client().Database("admin").RunCommand(ctx, bson.D{{Key: "hello", Value: 1}}).Decode(&hello)
+		if err != nil {
+			return false, err
+		}
+		supported := hello.SetName != "" || hello.Msg == "isdbgrid"
+		s.transactions = &supported
+	}
+	return *s.transactions, nil
+}
+
+// withTransaction runs fn in a transaction, which the driver retries on
+// transient errors, so fn must be safe to run again.
+func (s *MongoStore) withTransaction(ctx context.Context, fn func(ctx mongo.SessionContext) error) error {
+	session, err := s.database.Client().StartSession()
+	if err != nil {
+		return err
+	}
+	defer session.EndSession(ctx)
+	_, err = session.WithTransaction(ctx, func(ctx mongo.SessionContext) (interface{}, error) {
+		return nil, fn(ctx)
+	})
+	return err
+}
+
+// EnsureIndexes creates the indexes the store relies on for correctness. It
+// is safe to call on every start.
+func (s *MongoStore) EnsureIndexes(ctx context.Context) error {
+	// At most one active exclusive mapping per asset. The unique index is
+	// what makes concurrent checkouts of the same asset fail atomically.
+	// Mappings written before it existed carry no exclusive flag yet.
+	if err := s.backfillExclusive(ctx); err != nil {
+		return err
+	}
+	_, err := s.mappings().Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys: bson.D{{Key: "asset_id", Value: 1}},
+		Options: options.Index().
+			SetName("active_exclusive_asset").
+			SetUnique(true).
+			SetPartialFilterExpression(bson.M{"status": models.MappingStatusActive, "exclusive": true}),
+	})
+	if mongo.IsDuplicateKeyError(err) {
+		return s.doubleAssignmentError(ctx, err)
+	}
+	if err != nil {
+		return err
+	}
+
+	// Listings page through records in the order of their sort field and ID.
+	_, err = s.employees().Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys: bson.D{{Key: "created_at", Value: 1}, {Key: "emp_id", Value: 1}},
+	})
+	if err != nil {
+		return err
+	}
+
+	// Imports look employees up by email regardless of case and by phone
+	// number, under the same collation.
+	_, err = s.employees().Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "employee_email", Value: 1}}, Options: options.Index().SetCollation(caseInsensitive)},
+		{Keys: bson.D{{Key: "phone_number", Value: 1}}, Options: options.Index().SetCollation(caseInsensitive)},
+	})
+	if err != nil {
+		return err
+	}
+	_, err = s.assets().Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "created_at", Value: 1}, {Key: "asset_id", Value: 1}}},
+		{Keys: bson.D{{Key: "asset_type", Value: 1}}},
+	})
+	if err != nil {
+		return err
+	}
+	_, err = s.mappings().Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys: bson.D{{Key: "employee_id", Value: 1}, {Key: "assigned_date", Value: 1}, {Key: "mapping_id", Value: 1}},
+	})
+	if err != nil {
+		return err
+	}
+
+	// Serial numbers and asset tags are unique when set, deleted assets
+	// included.
+	_, err = s.assets().Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "serial_number", Value: 1}},
+			Options: options.Index().SetUnique(true).SetPartialFilterExpression(bson.M{"serial_number": bson.M{"$gt": ""}}),
+		},
+		{
+			Keys:    bson.D{{Key: "asset_tag", Value: 1}},
+			Options: options.Index().SetUnique(true).SetPartialFilterExpression(bson.M{"asset_tag": bson.M{"$gt": ""}}),
+		},
+	})
+	if err != nil {
+		return err
+	}
+
+	// Asset listings filter on purchase details.
+	_, err = s.assets().Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "invoice_number", Value: 1}}},
+		{Keys: bson.D{{Key: "purchase_date", Value: 1}}},
+		{Keys: bson.D{{Key: "warranty_expiry", Value: 1}}},
+	})
+	if err != nil {
+		return err
+	}
+
+	// Reports find the latest mapping of each asset and filter assignments
+	// by the date they were made.
+	_, err = s.mappings().Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "asset_id", Value: 1}, {Key: "assigned_date", Value: -1}}},
+		{Keys: bson.D{{Key: "assigned_date", Value: 1}}},
+	})
+	if err != nil {
+		return err
+	}
+
+	// Full-text search, weighted like the SQL backends. Stemming is off since
+	// what is searched is mostly names.
+	if _, err = s.employees().Indexes().CreateOne(ctx, textIndex(employeeSearchFields)); err != nil {
+		return err
+	}
+	if err = ensureTextIndex(ctx, s.assets(), assetSearchFields); err != nil {
+		return err
+	}
+
+	_, err = s.audit().Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "timestamp", Value: -1}}},
+		{Keys: bson.D{{Key: "entity_type", Value: 1}, {Key: "entity_id", Value: 1}, {Key: "timestamp", Value: -1}}},
+		{Keys: bson.D{{Key: "actor_id", Value: 1}, {Key: "timestamp", Value: -1}}},
+	})
+	if err != nil {
+		return err
+	}
+
+	// Version numbers are unique per record, so of two concurrent appends
+	// one fails instead of both claiming the same number.
+	_, err = s.versions().Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "entity_type", Value: 1}, {Key: "entity_id", Value: 1}, {Key: "version", Value: 1}},
+		Options: options.Index().SetUnique(true),
+	})
+	if err != nil {
+		return err
+	}
+
+	// Refresh tokens are looked up by hash on every exchange and their family
+	// is checked on every authenticated request. Expired tokens are of no
+	// further use, so MongoDB removes them itself.
+	_, err = s.refreshTokens().Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "token_hash", Value: 1}}, Options: options.Index().SetUnique(true)},
+		{Keys: bson.D{{Key: "family_id", Value: 1}}},
+		{Keys: bson.D{{Key: "expires_at", Value: 1}}, Options: options.Index().SetExpireAfterSeconds(0)},
+	})
+	return err
+}
+
+// backfillExclusive flags the active mappings that predate the exclusive
+// field as exclusive unless their asset is shared.
+func (s *MongoStore) backfillExclusive(ctx context.Context) error {
+	shared, err := s.assets().Distinct(ctx, "asset_id", bson.M{"shared": true})
+	if err != nil {
+		return err
+	}
+	_, err = s.mappings().UpdateMany(ctx, bson.M{
+		"status":    models.MappingStatusActive,
+		"exclusive": bson.M{"$exists": false},
+		"asset_id":  bson.M{"$nin": shared},
+	}, bson.M{"$set": bson.M{"exclusive": true}})
+	return err
+}
+
+// doubleAssignmentError explains err, the failure to build the
+// active_exclusive_asset index, by listing the assets that have several
+// active exclusive mappings.
+func (s *MongoStore) doubleAssignmentError(ctx context.Context, err error) error {
+	cursor, aggErr := s.mappings().Aggregate(ctx, mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{"status": models.MappingStatusActive, "exclusive": true}}},
+		{{Key: "$group", Value: bson.M{"_id": "$asset_id", "count": bson.M{"$sum": 1}}}},
+		{{Key: "$match", Value: bson.M{"count": bson.M{"$gt": 1}}}},
+		{{Key: "$sort", Value: bson.M{"_id": 1}}},
+	})
+	if aggErr != nil {
+		return err
+	}
+	var assets []struct {
+		ID string `bson:"_id"`
+	}
+	if aggErr := cursor.All(ctx, &assets); aggErr != nil || len(assets) == 0 {
+		return err
+	}
+	ids := make([]string, len(assets))
+	for i := range assets {
+		ids[i] = assets[i].ID
+	}
+	return fmt.Errorf("assets with more than one active mapping: %s; return all but one mapping of each: %w",
+		strings.Join(ids, ", "), err)
+}
+
+// textIndex returns the text index over fields.
+func textIndex(fields []searchField) mongo.IndexModel {
+	var keys, weights bson.D
+	for _, field := range fields {
+		keys = append(keys, bson.E{Key: field.name, Value: "text"})
+		weights = append(weights, bson.E{Key: field.name, Value: int32(field.weight)})
+	}
+	return mongo.IndexModel{
+		Keys:    keys,
+		Options: options.Index().SetName("search").SetWeights(weights).SetDefaultLanguage("none"),
+	}
+}
+
+// ensureTextIndex creates the text index over fields on coll, replacing a
+// text index over other fields. A collection has at most one text index, so
+// it cannot be added alongside.
+func ensureTextIndex(ctx context.Context, coll *mongo.Collection, fields []searchField) error {
+	index := textIndex(fields)
+	_, err := coll.Indexes().CreateOne(ctx, index)
+	var cmdErr mongo.CommandError
+	if !errors.As(err, &cmdErr) || (cmdErr.Code != 85 && cmdErr.Code != 86) {
+		// Anything but IndexOptionsConflict and IndexKeySpecsConflict.
+		return err
+	}
+	if _, err := coll.Indexes().DropOne(ctx, "search"); err != nil {
+		return err
+	}
+	_, err = coll.Indexes().CreateOne(ctx, index)
+	return err
+}
+
+// mongoError translates duplicate key errors into ErrConflict.
+func mongoError(err error) error {
+	if mongo.IsDuplicateKeyError(err) {
+		return fmt.Errorf("%w: %s", ErrConflict, err.Error())
+	}
+	return err
+}
+
+// live restricts filter to documents that have not been soft deleted. A
+// missing deleted_at field matches null.
+func live(filter bson.M) bson.M {
+	filter["deleted_at"] = nil
+	return filter
+}
+
+// timeRange returns the condition on a time field for times strictly between
+// after and before, either of which may be zero, or nil when both are.
+func timeRange(after, before time.Time) bson.M {
+	if after.IsZero() && before.IsZero() {
+		return nil
+	}
+	condition := bson.M{}
+	if !after.IsZero() {
+		condition["$gt"] = after
+	}
+	if !before.IsZero() {
+		condition["$lt"] = before
+	}
+	return condition
+}
+
+// prefixRegex matches the strings that start with prefix, ignoring case.
+func prefixRegex(prefix string) primitive.Regex {
+	return primitive.Regex{Pattern: "^" + regexp.QuoteMeta(prefix), Options: "i"}
+}
+
+// page adds the condition selecting the records after opts.After to filter
+// and returns the sort order of the page. key is the field holding the
+// record's ID.
+func page(filter bson.M, opts ListOptions, field, key string) (bson.D, error) {
+	dir, op := 1, "$gt"
+	if opts.Desc {
+		dir, op = -1, "$lt"
+	}
+	if opts.After != nil {
+		value, err := cursorValue(opts.After, field)
+		if err != nil {
+			return nil, err
+		}
+		filter["$and"] = bson.A{bson.M{"$or": bson.A{
+			bson.M{field: bson.M{op: value}},
+			bson.M{field: value, key: bson.M{op: opts.After.ID}},
+		}}}
+	}
+	return bson.D{{Key: field, Value: dir}, {Key: key, Value: dir}}, nil
+}
+
+// findOne decodes the first document matching filter into out, translating
+// mongo.ErrNoDocuments into ErrNotFound.
+func findOne(ctx context.Context, coll *mongo.Collection, filter interface{}, out interface{}, opts ...*options.FindOneOptions) error {
+	err := coll.FindOne(ctx, filter, opts...).Decode(out)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return ErrNotFound
+	}
+	return err
+}
+
+// versioned restricts filter to documents at version. Documents written
+// before records were versioned have no version field, which reads as 0.
+func versioned(filter bson.M, version int64) bson.M {
+	if version == 0 {
+		filter["version"] = nil
+	} else {
+		filter["version"] = version
+	}
+	return filter
+}
+
+// replaceVersioned replaces the live document of coll matched by filter
+// with doc as long as it is still at *version, and bumps *version.
+func replaceVersioned(ctx context.Context, coll *mongo.Collection, filter bson.M, doc interface{}, version *int64) error {
+	expected := *version
+	*version = expected + 1
+	res, err := coll.ReplaceOne(ctx, versioned(live(filter), expected), doc)
+	if err == nil && res.MatchedCount == 0 {
+		err = staleOrMissingDoc(ctx, coll, filter)
+	}
+	if err != nil {
+		*version = expected
+	}
+	return mongoError(err)
+}
+
+// staleOrMissingDoc tells why a conditional write matched no document: it
+// returns ErrStaleVersion when a document matches filter without its version
+// condition and ErrNotFound otherwise.
+func staleOrMissingDoc(ctx context.Context, coll *mongo.Collection, filter bson.M) error {
+	delete(filter, "version")
+	n, err := coll.CountDocuments(ctx, filter)
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return ErrNotFound
+	}
+	return ErrStaleVersion
+}
+
+func (s *MongoStore) CreateEmployee(ctx context.Context, employee *models.Employee) error {
+	employee.Version = 1
+	_, err := s.employees().InsertOne(ctx, employee)
+	return mongoError(err)
+}
+
+// CreateEmployees inserts the employees in one transaction. Transactions
+// need a replica set, so standalone servers refuse it with
+// ErrNoTransactions.
+func (s *MongoStore) CreateEmployees(ctx context.Context, employees []models.Employee) error {
+	docs := make([]interface{}, len(employees))
+	for i := range employees {
+		employees[i].Version = 1
+		docs[i] = &employees[i]
+	}
+	return s.insertAll(ctx, s.employees(), docs)
+}
+
+// insertAll inserts every one of docs in a transaction, or fails with
+// ErrNoTransactions where the deployment does not support them.
+func (s *MongoStore) insertAll(ctx context.Context, coll *mongo.Collection, docs []interface{}) error {
+	transactions, err := s.supportsTransactions(ctx)
+	if err != nil {
+		return err
+	}
+	if !transactions {
+		return ErrNoTransactions
+	}
+	return mongoError(s.withTransaction(ctx, func(ctx mongo.SessionContext) error {
+		_, err := coll.InsertMany(ctx, docs)
+		return err
+	}))
+}
+
+func (s *MongoStore) GetEmployee(ctx context.Context, empID string) (*models.Employee, error) {
+	var employee models.Employee
+	if err := findOne(ctx, s.employees(), live(bson.M{"emp_id": empID}), &employee); err != nil {
+		return nil, err
+	}
+	return &employee, nil
+}
+
+func (s *MongoStore) FindEmployeeByIdentifier(ctx context.Context, identifier string) (*models.Employee, error) {
+	var employee models.Employee
+	filter := live(bson.M{
+		"$or": []bson.M{
+			{"phone_number": identifier},
+			{"employee_email": identifier},
+		},
+	})
+	if err := findOne(ctx, s.employees(), filter, &employee); err != nil {
+		return nil, err
+	}
+	return &employee, nil
+}
+
+// caseInsensitive is the collation of the comparisons that ignore case.
+var caseInsensitive = &options.Collation{Locale: "en", Strength: 2}
+
+func (s *MongoStore) EmployeesByIdentifier(ctx context.Context, emails, phones []string) ([]models.Employee, error) {
+	var or []bson.M
+	if len(emails) > 0 {
+		or = append(or, bson.M{"employee_email": bson.M{"$in": emails}})
+	}
+	if len(phones) > 0 {
+		or = append(or, bson.M{"phone_number": bson.M{"$in": phones}})
+	}
+	if len(or) == 0 {
+		return nil, nil
+	}
+	cursor, err := s.employees().Find(ctx, live(bson.M{"$or": or}), options.Find().SetCollation(caseInsensitive))
+	if err != nil {
+		return nil, err
+	}
+	var employees []models.Employee
+	if err := cursor.All(ctx, &employees); err != nil {
+		return nil, err
+	}
+	return employees, nil
+}
+
+func (s *MongoStore) UpdateEmployee(ctx context.Context, employee *models.Employee) error {
+	return replaceVersioned(ctx, s.employees(), bson.M{"emp_id": employee.EmpID}, employee, &employee.Version)
+}
+
+func (s *MongoStore) DeleteEmployee(ctx context.Context, empID string, del models.Deletion) error {
+	return s.deleteWithMappings(ctx, s.employees(), bson.M{"emp_id": empID}, bson.M{"employee_id": empID}, del)
+}
+
+func (s *MongoStore) RestoreEmployee(ctx context.Context, empID string) error {
+	return restoreOne(ctx, s.employees(), bson.M{"emp_id": empID})
+}
+
+func (s *MongoStore) PurgeEmployees(ctx context.Context, deletedBefore time.Time) (int64, error) {
+	return s.purge(ctx, s.employees(), "emp_id", "employee_id", deletedBefore)
+}
+
+func (s *MongoStore) Dashboard(ctx context.Context, q EmployeeQuery) ([]models.DashboardEmployee, int64, error) {
+	filter := live(bson.M{})
+	if q.NamePrefix != "" {
+		filter["$or"] = bson.A{
+			bson.M{"first_name": prefixRegex(q.NamePrefix)},
+			bson.M{"last_name": prefixRegex(q.NamePrefix)},
+		}
+	}
+	if q.Role != "" {
+		filter["roles"] = q.Role
+	}
+	if created := timeRange(q.CreatedAfter, q.CreatedBefore); created != nil {
+		filter["created_at"] = created
+	}
+
+	field, err := sortField(q.ListOptions, EmployeeSortFields)
+	if err != nil {
+		return nil, 0, err
+	}
+	total, err := s.employees().CountDocuments(ctx, filter)
+	if err != nil {
+		return nil, 0, err
+	}
+	order, err := page(filter, q.ListOptions, field, "emp_id")
+	if err != nil {
+		return nil, 0, err
+	}
+
+	// Page through the employees first so the lookups only run for the
+	// ones returned.
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: filter}},
+		{{Key: "$sort", Value: order}},
+	}
+	if q.Limit > 0 {
+		pipeline = append(pipeline, bson.D{{Key: "$limit", Value: q.Limit}})
+	}
+	pipeline = append(pipeline,
+		bson.D{{Key: "$lookup", Value: bson.D{
+			{Key: "from", Value: s.mappings().Name()},
+			{Key: "localField", Value: "emp_id"},
+			{Key: "foreignField", Value: "employee_id"},
+			{Key: "as", Value: "mappings"},
+		}}},
+		bson.D{{Key: "$addFields", Value: bson.D{
+			{Key: "active", Value: bson.D{{Key: "$filter", Value: bson.D{
+				{Key: "input", Value: "$mappings"},
+				{Key: "cond", Value: bson.D{{Key: "$eq", Value: bson.A{"$$this.status", models.MappingStatusActive}}}},
+			}}}},
+			{Key: "last_assigned_at", Value: bson.D{{Key: "$max", Value: "$mappings.assigned_date"}}},
+		}}},
+		bson.D{{Key: "$lookup", Value: bson.D{
+			{Key: "from", Value: s.assets().Name()},
+			{Key: "localField", Value: "active.asset_id"},
+			{Key: "foreignField", Value: "asset_id"},
+			{Key: "as", Value: "active_assets"},
+		}}},
+		bson.D{{Key: "$project", Value: bson.D{
+			{Key: "emp_id", Value: 1},
+			{Key: "first_name", Value: 1},
+			{Key: "last_name", Value: 1},
+			{Key: "gender", Value: 1},
+			{Key: "phone_number", Value: 1},
+			{Key: "employee_email", Value: 1},
+			{Key: "address", Value: 1},
+			{Key: "blood_group", Value: 1},
+			{Key: "emergency_contact_number", Value: 1},
+			{Key: "department", Value: 1},
+			{Key: "created_at", Value: 1},
+			{Key: "updated_at", Value: 1},
+			{Key: "last_assigned_at", Value: 1},
+			{Key: "asset_count", Value: bson.D{{Key: "$size", Value: "$active"}}},
+			{Key: "active_assets.asset_type", Value: 1},
+			{Key: "active_assets.purchase_cost", Value: 1},
+			{Key: "active_assets.currency", Value: 1},
+		}}},
+	)
+
+	cursor, err := s.employees().Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer cursor.Close(ctx)
+
+	var rows []struct {
+		models.DashboardEmployee `bson:",inline"`
+		ActiveAssets             []models.Asset `bson:"active_assets"`
+	}
+	if err := cursor.All(ctx, &rows); err != nil {
+		return nil, 0, err
+	}
+	employees := make([]models.DashboardEmployee, len(rows))
+	for i, row := range rows {
+		employees[i] = row.DashboardEmployee
+		for _, asset := range row.ActiveAssets {
+			addAssigned(&employees[i], asset.AssetType, asset.Currency, 1, asset.PurchaseCost)
+		}
+	}
+	return employees, total, nil
+}
+
+func (s *MongoStore) CreateAsset(ctx context.Context, asset *models.Asset) error {
+	asset.Version = 1
+	_, err := s.assets().InsertOne(ctx, asset)
+	return mongoError(err)
+}
+
+// CreateAssets inserts the assets in one transaction, like CreateEmployees.
+func (s *MongoStore) CreateAssets(ctx context.Context, assets []models.Asset) error {
+	docs := make([]interface{}, len(assets))
+	for i := range assets {
+		assets[i].Version = 1
+		docs[i] = &assets[i]
+	}
+	return s.insertAll(ctx, s.assets(), docs)
+}
+
+func (s *MongoStore) AssetsBySerial(ctx context.Context, serials []string) ([]models.Asset, error) {
+	if len(serials) == 0 {
+		return nil, nil
+	}
+	cursor, err := s.assets().Find(ctx, bson.M{"serial_number": bson.M{"$in": serials}},
+		options.Find().SetSort(bson.D{{Key: "serial_number", Value: 1}}))
+	if err != nil {
+		return nil, err
+	}
+	var assets []models.Asset
+	if err := cursor.All(ctx, &assets); err != nil {
+		return nil, err
+	}
+	return assets, nil
+}
+
+func (s *MongoStore) GetAsset(ctx context.Context, assetID string) (*models.Asset, error) {
+	var asset models.Asset
+	if err := findOne(ctx, s.assets(), live(bson.M{"asset_id": assetID}), &asset); err != nil {
+		return nil, err
+	}
+	return &asset, nil
+}
+
+func (s *MongoStore) ListAssets(ctx context.Context, q AssetQuery) ([]models.Asset, int64, error) {
+	filter := live(bson.M{})
+	if q.AssetType != "" {
+		filter["asset_type"] = q.AssetType
+	}
+	if q.NamePrefix != "" {
+		filter["asset_name"] = prefixRegex(q.NamePrefix)
+	}
+	if q.Shared != nil {
+		filter["shared"] = *q.Shared
+	}
+	if created := timeRange(q.CreatedAfter, q.CreatedBefore); created != nil {
+		filter["created_at"] = created
+	}
+	if q.SerialNumber != "" {
+		filter["serial_number"] = q.SerialNumber
+	}
+	if q.InvoiceNumber != "" {
+		filter["invoice_number"] = q.InvoiceNumber
+	}
+	for field, value := range map[string]string{"manufacturer": q.Manufacturer, "model": q.Model, "vendor": q.Vendor} {
+		if value != "" {
+			filter[field] = primitive.Regex{Pattern: "^" + regexp.QuoteMeta(value) + "$", Options: "i"}
+		}
+	}
+	if purchased := timeRange(q.PurchasedAfter, q.PurchasedBefore); purchased != nil {
+		filter["purchase_date"] = purchased
+	}
+	if expiry := timeRange(q.WarrantyExpiresAfter, q.WarrantyExpiresBefore); expiry != nil {
+		filter["warranty_expiry"] = expiry
+	}
+
+	var assets []models.Asset
+	total, err := findPage(ctx, s.assets(), filter, q.ListOptions, AssetSortFields, "asset_id", &assets)
+	return assets, total, err
+}
+
+func (s *MongoStore) UpdateAsset(ctx context.Context, asset *models.Asset) error {
+	return replaceVersioned(ctx, s.assets(), bson.M{"asset_id": asset.AssetID}, asset, &asset.Version)
+}
+
+func (s *MongoStore) DeleteAsset(ctx context.Context, assetID string, del models.Deletion) error {
+	return s.deleteWithMappings(ctx, s.assets(), bson.M{"asset_id": assetID}, bson.M{"asset_id": assetID}, del)
+}
+
+func (s *MongoStore) RestoreAsset(ctx context.Context, assetID string) error {
+	return restoreOne(ctx, s.assets(), bson.M{"asset_id": assetID})
+}
+
+func (s *MongoStore) PurgeAssets(ctx context.Context, deletedBefore time.Time) (int64, error) {
+	return s.purge(ctx, s.assets(), "asset_id", "asset_id", deletedBefore)
+}
+
+// deleteWithMappings tombstones the live document of coll matched by filter
+// once the active mappings matched by mappings are closed with del.Cascade,
+// failing with ErrActiveMappings when there are some and there is no
+// cascade. Standalone servers have no multi-document transactions, so the
+// check is repeated once the tombstone is written, and the tombstone
+// removed again when a checkout raced the delete.
+func (s *MongoStore) deleteWithMappings(ctx context.Context, coll *mongo.Collection, filter, mappings bson.M, del models.Deletion) error {
+	key := bson.M{}
+	for k, v := range filter {
+		key[k] = v
+	}
+	filter = live(filter)
+	mappings["status"] = models.MappingStatusActive
+	if del.IfVersion != 0 {
+		// Check up front so a stale delete does not close any mappings.
+		var current struct {
+			Version int64 `bson:"version"`
+		}
+		if err := findOne(ctx, coll, filter, &current); err != nil {
+			return err
+		}
+		if current.Version != del.IfVersion {
+			return ErrStaleVersion
+		}
+		versioned(filter, del.IfVersion)
+	}
+	if del.Cascade == nil {
+		active, err := s.mappings().CountDocuments(ctx, mappings)
+		if err != nil {
+			return err
+		}
+		if active > 0 {
+			return ErrActiveMappings
+		}
+	} else {
+		// Do not close anything on behalf of a record that does not exist.
+		if err := coll.FindOne(ctx, filter).Err(); err != nil {
+			if errors.Is(err, mongo.ErrNoDocuments) {
+				return ErrNotFound
+			}
+			return err
+		}
+		if _, err := s.mappings().UpdateMany(ctx, mappings, returnUpdate(*del.Cascade)); err != nil {
+			return err
+		}
+	}
+
+	var tombstoned struct {
+		Version int64 `bson:"version"`
+	}
+	err := coll.FindOneAndUpdate(ctx, filter, bson.M{
+		"$set": bson.M{"deleted_at": del.DeletedAt, "deleted_by": del.DeletedBy},
+		"$inc": bson.M{"version": 1},
+	}, options.FindOneAndUpdate().SetReturnDocument(options.After).SetProjection(bson.M{"version": 1})).Decode(&tombstoned)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return staleOrMissingDoc(ctx, coll, filter)
+	}
+	if err != nil {
+		return err
+	}
+
+	// A checkout may have added a mapping since the check above. Checkouts
+	// look for the tombstone after inserting, so looking for their mappings
+	// once more after writing it leaves no gap in which both miss.
+	if del.Cascade != nil {
+		_, err := s.mappings().UpdateMany(ctx, mappings, returnUpdate(*del.Cascade))
+		return err
+	}
+	active, err := s.mappings().CountDocuments(ctx, mappings)
+	if err != nil {
+		return err
+	}
+	if active > 0 {
+		if err := restoreOne(ctx, coll, versioned(key, tombstoned.Version)); err != nil {
+			return err
+		}
+		return ErrActiveMappings
+	}
+	return nil
+}
+
+// restoreOne clears the tombstone of the deleted document matched by filter.
+func restoreOne(ctx context.Context, coll *mongo.Collection, filter bson.M) error {
+	filter["deleted_at"] = bson.M{"$ne": nil}
+	res, err := coll.UpdateOne(ctx, filter, bson.M{
+		"$unset": bson.M{"deleted_at": "", "deleted_by": ""},
+		"$inc":   bson.M{"version": 1},
+	})
+	if err != nil {
+		return err
+	}
+	if res.MatchedCount == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// purge removes the documents of coll deleted before the given time unless
+// the mappings field column still references their key.
+func (s *MongoStore) purge(ctx context.Context, coll *mongo.Collection, key, column string, deletedBefore time.Time) (int64, error) {
+	filter := bson.M{"deleted_at": bson.M{"$lt": deletedBefore}}
+	deleted, err := coll.Distinct(ctx, key, filter)
+	if err != nil || len(deleted) == 0 {
+		return 0, err
+	}
+	referenced, err := s.mappings().Distinct(ctx, column, bson.M{column: bson.M{"$in": deleted}})
+	if err != nil {
+		return 0, err
+	}
+	filter[key] = bson.M{"$nin": referenced}
+	res, err := coll.DeleteMany(ctx, filter)
+	if err != nil {
+		return 0, err
+	}
+	return res.DeletedCount, nil
+}
+
+func (s *MongoStore) CreateMapping(ctx context.Context, mapping *models.EmployeeAssetMapping) error {
+	mapping.Version = 1
+	_, err := s.mappings().InsertOne(ctx, mapping)
+	if mongo.IsDuplicateKeyError(err) {
+		return ErrAssetAssigned
+	}
+	if err != nil {
+		return err
+	}
+
+	// Deletes write their tombstone before looking for active mappings, so
+	// looking for it only now means one of the two always sees the other.
+	if err := s.checkMappingParents(ctx, mapping); err != nil {
+		if _, deleteErr := s.mappings().DeleteOne(ctx, bson.M{"mapping_id": mapping.MappingID}); deleteErr != nil {
+			return deleteErr
+		}
+		return err
+	}
+	return nil
+}
+
+// checkMappingParents fails with ErrNotFound unless the employee and the
+// asset of mapping exist and are not deleted.
+func (s *MongoStore) checkMappingParents(ctx context.Context, mapping *models.EmployeeAssetMapping) error {
+	for _, parent := range []struct {
+		coll   *mongo.Collection
+		filter bson.M
+	}{
+		{s.employees(), bson.M{"emp_id": mapping.EmployeeID}},
+		{s.assets(), bson.M{"asset_id": mapping.AssetID}},
+	} {
+		n, err := parent.coll.CountDocuments(ctx, live(parent.filter), options.Count().SetLimit(1))
+		if err != nil {
+			return err
+		}
+		if n == 0 {
+			return ErrNotFound
+		}
+	}
+	return nil
+}
+
+func (s *MongoStore) GetMapping(ctx context.Context, mappingID string) (*models.EmployeeAssetMapping, error) {
+	var mapping models.EmployeeAssetMapping
+	if err := findOne(ctx, s.mappings(), bson.M{"mapping_id": mappingID}, &mapping); err != nil {
+		return nil, err
+	}
+	return &mapping, nil
+}
+
+func (s *MongoStore) ListMappingsByEmployee(ctx context.Context, empID, status string) ([]models.EmployeeAssetMapping, error) {
+	return s.listMappings(ctx, bson.M{"employee_id": empID}, status)
+}
+
+func (s *MongoStore) ListMappingsByAsset(ctx context.Context, assetID, status string) ([]models.EmployeeAssetMapping, error) {
+	return s.listMappings(ctx, bson.M{"asset_id": assetID}, status)
+}
+
+func (s *MongoStore) listMappings(ctx context.Context, filter bson.M, status string) ([]models.EmployeeAssetMapping, error) {
+	if status != "" {
+		filter["status"] = status
+	}
+	opts := options.Find().SetSort(bson.D{{Key: "assigned_date", Value: 1}})
+	cursor, err := s.mappings().Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var mappings []models.EmployeeAssetMapping
+	for cursor.Next(ctx) {
+		var mapping models.EmployeeAssetMapping
+		if err := cursor.Decode(&mapping); err == nil {
+			mappings = append(mappings, mapping)
+		}
+	}
+	return mappings, nil
+}
+
+func (s *MongoStore) ListMappings(ctx context.Context, q MappingQuery) ([]models.EmployeeAssetMapping, int64, error) {
+	filter := bson.M{}
+	if q.EmployeeID != "" {
+		filter["employee_id"] = q.EmployeeID
+	}
+	if q.AssetID != "" {
+		filter["asset_id"] = q.AssetID
+	}
+	if q.Status != "" {
+		filter["status"] = q.Status
+	}
+	if assigned := timeRange(q.AssignedAfter, q.AssignedBefore); assigned != nil {
+		filter["assigned_date"] = assigned
+	}
+
+	var mappings []models.EmployeeAssetMapping
+	total, err := findPage(ctx, s.mappings(), filter, q.ListOptions, MappingSortFields, "mapping_id", &mappings)
+	return mappings, total, err
+}
+
+// findPage decodes the page of the documents of coll matching filter that
+// opts select into out, and returns how many documents match filter. fields
+// are those the documents can be sorted by.
+func findPage(ctx context.Context, coll *mongo.Collection, filter bson.M, opts ListOptions, fields []string, key string, out interface{}) (int64, error) {
+	field, err := sortField(opts, fields)
+	if err != nil {
+		return 0, err
+	}
+	total, err := coll.CountDocuments(ctx, filter)
+	if err != nil {
+		return 0, err
+	}
+	order, err := page(filter, opts, field, key)
+	if err != nil {
+		return 0, err
+	}
+	findOpts := options.Find().SetSort(order)
+	if opts.Limit > 0 {
+		findOpts.SetLimit(int64(opts.Limit))
+	}
+	cursor, err := coll.Find(ctx, filter, findOpts)
+	if err != nil {
+		return 0, err
+	}
+	defer cursor.Close(ctx)
+	return total, cursor.All(ctx, out)
+}
+
+func (s *MongoStore) ReturnMapping(ctx context.Context, mappingID string, ret models.MappingReturn) (*models.EmployeeAssetMapping, error) {
+	var mapping models.EmployeeAssetMapping
+	update := returnUpdate(ret)
+	filter := bson.M{"mapping_id": mappingID, "status": models.MappingStatusActive}
+	if ret.IfVersion != 0 {
+		versioned(filter, ret.IfVersion)
+	}
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+	err := s.mappings().FindOneAndUpdate(ctx, filter, update, opts).Decode(&mapping)
+	if err == nil {
+		return &mapping, nil
+	}
+	if !errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, err
+	}
+
+	// Tell a missing or changed mapping apart from one that is already
+	// closed.
+	if err := findOne(ctx, s.mappings(), bson.M{"mapping_id": mappingID}, &mapping); err != nil {
+		return nil, err
+	}
+	if ret.IfVersion != 0 && mapping.Version != ret.IfVersion {
+		return nil, ErrStaleVersion
+	}
+	return nil, ErrMappingClosed
+}
+
+// ApplyMappingChanges applies the changes one by one. An atomic batch runs
+// in a transaction, which the first failed change aborts.
+func (s *MongoStore) ApplyMappingChanges(ctx context.Context, changes []MappingChange, atomic bool) error {
+	if !atomic {
+		for i := range changes {
+			s.applyMappingChange(ctx, &changes[i])
+		}
+		return nil
+	}
+	transactions, err := s.supportsTransactions(ctx)
+	if err != nil {
+		return err
+	}
+	if !transactions {
+		return ErrNoTransactions
+	}
+	err = s.withTransaction(ctx, func(ctx mongo.SessionContext) error {
+		for i := range changes {
+			changes[i].Result, changes[i].Err = nil, nil
+		}
+		for i := range changes {
+			if s.applyMappingChange(ctx, &changes[i]); changes[i].Err != nil {
+				return changes[i].Err
+			}
+		}
+		return nil
+	})
+	if failed(changes) {
+		// The error is the failed change's, which reports it.
+		return nil
+	}
+	return err
+}
+
+// applyMappingChange applies c, setting its Result or Err.
+func (s *MongoStore) applyMappingChange(ctx context.Context, c *MappingChange) {
+	if c.Assign != nil {
+		if c.Err = s.CreateMapping(ctx, c.Assign); c.Err == nil {
+			c.Result = c.Assign
+		}
+		return
+	}
+	c.Result, c.Err = s.ReturnMapping(ctx, c.MappingID, c.Return)
+}
+
+func (s *MongoStore) UpdateMapping(ctx context.Context, mapping *models.EmployeeAssetMapping) error {
+	filter := versioned(bson.M{"mapping_id": mapping.MappingID}, mapping.Version)
+	result, err := s.mappings().UpdateOne(ctx, filter, bson.M{"$set": bson.M{
+		"notes":            mapping.Notes,
+		"return_condition": mapping.ReturnCondition,
+		"close_reason":     mapping.CloseReason,
+		"version":          mapping.Version + 1,
+	}})
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return staleOrMissingDoc(ctx, s.mappings(), filter)
+	}
+	mapping.Version++
+	return nil
+}
+
+// returnUpdate is the update document closing a mapping with ret.
+func returnUpdate(ret models.MappingReturn) bson.M {
+	var mapping models.EmployeeAssetMapping
+	applyReturn(&mapping, ret)
+	return bson.M{"$set": bson.M{
+		"status":           mapping.Status,
+		"returned_date":    mapping.ReturnedDate,
+		"returned_by":      mapping.ReturnedBy,
+		"return_condition": mapping.ReturnCondition,
+		"close_reason":     mapping.CloseReason,
+	}, "$inc": bson.M{"version": 1}}
+}
+
+func (s *MongoStore) refreshTokens() *mongo.Collection { return s.database.Collection("refresh_token") }
+
+func (s *MongoStore) CreateRefreshToken(ctx context.Context, token *models.RefreshToken) error {
+	_, err := s.refreshTokens().InsertOne(ctx, token)
+	return err
+}
+
+func (s *MongoStore) UseRefreshToken(ctx context.Context, tokenHash string, at time.Time) (*models.RefreshToken, error) {
+	var token models.RefreshToken
+	filter := bson.M{"token_hash": tokenHash, "used_at": nil}
+	err := s.refreshTokens().FindOneAndUpdate(ctx, filter, bson.M{"$set": bson.M{"used_at": at}}).Decode(&token)
+	if err == nil {
+		token.UsedAt = &at
+		return &token, nil
+	}
+	if !errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, err
+	}
+
+	// Either the token never existed or it was already exchanged.
+	if err := findOne(ctx, s.refreshTokens(), bson.M{"token_hash": tokenHash}, &token); err != nil {
+		return nil, err
+	}
+	return &token, ErrTokenReused
+}
+
+func (s *MongoStore) RevokeTokenFamily(ctx context.Context, familyID string, at time.Time) error {
+	filter := bson.M{"family_id": familyID, "revoked_at": nil}
+	_, err := s.refreshTokens().UpdateMany(ctx, filter, bson.M{"$set": bson.M{"revoked_at": at}})
+	return err
+}
+
+func (s *MongoStore) IsTokenFamilyRevoked(ctx context.Context, familyID string) (bool, error) {
+	filter := bson.M{"family_id": familyID, "revoked_at": bson.M{"$ne": nil}}
+	n, err := s.refreshTokens().CountDocuments(ctx, filter, options.Count().SetLimit(1))
+	return n > 0, err
+}
+
+// PurgeRefreshTokens removes what the TTL index on expires_at has not
+// removed yet; its monitor only runs once a minute.
+func (s *MongoStore) PurgeRefreshTokens(ctx context.Context, expiredBefore time.Time) (int64, error) {
+	res, err := s.refreshTokens().DeleteMany(ctx, bson.M{"expires_at": bson.M{"$lt": expiredBefore}})
+	if err != nil {
+		return 0, err
+	}
+	return res.DeletedCount, nil
+}
+
+func (s *MongoStore) audit() *mongo.Collection { return s.database.Collection("audit_log") }
+
+func (s *MongoStore) AppendAudit(ctx context.Context, entry *models.AuditEntry) error {
+	_, err := s.audit().InsertOne(ctx, entry)
+	return err
+}
+
+func (s *MongoStore) ListAudit(ctx context.Context, q AuditQuery) ([]models.AuditEntry, int64, error) {
+	filter := bson.M{}
+	for field, value := range map[string]string{
+		"actor_id":    q.ActorID,
+		"action":      q.Action,
+		"entity_type": q.EntityType,
+		"entity_id":   q.EntityID,
+	} {
+		if value != "" {
+			filter[field] = value
+		}
+	}
+	if !q.From.IsZero() || !q.To.IsZero() {
+		timestamp := bson.M{}
+		if !q.From.IsZero() {
+			timestamp["$gte"] = q.From
+		}
+		if !q.To.IsZero() {
+			timestamp["$lte"] = q.To
+		}
+		filter["timestamp"] = timestamp
+	}
+
+	total, err := s.audit().CountDocuments(ctx, filter)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	opts := options.Find().
+		SetSort(bson.D{{Key: "timestamp", Value: -1}, {Key: "_id", Value: -1}}).
+		SetSkip(int64(q.Offset))
+	if q.Limit > 0 {
+		opts.SetLimit(int64(q.Limit))
+	}
+	cursor, err := s.audit().Find(ctx, filter, opts)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer cursor.Close(ctx)
+
+	var entries []models.AuditEntry
+	if err := cursor.All(ctx, &entries); err != nil {
+		return nil, 0, err
+	}
+	return entries, total, nil
+}
+
+func (s *MongoStore) versions() *mongo.Collection { return s.database.Collection("record_version") }
+
+func (s *MongoStore) AppendVersion(ctx context.Context, v *models.RecordVersion) error {
+	var latest models.RecordVersion
+	opts := options.FindOne().SetSort(bson.D{{Key: "version", Value: -1}})
+	err := findOne(ctx, s.versions(), bson.M{"entity_type": v.EntityType, "entity_id": v.EntityID}, &latest, opts)
+	if err != nil && !errors.Is(err, ErrNotFound) {
+		return err
+	}
+	v.Version = latest.Version + 1
+	_, err = s.versions().InsertOne(ctx, v)
+	return mongoError(err)
+}
+
+func (s *MongoStore) ListVersions(ctx context.Context, entityType, entityID string) ([]models.RecordVersion, error) {
+	opts := options.Find().SetSort(bson.D{{Key: "version", Value: 1}})
+	cursor, err := s.versions().Find(ctx, bson.M{"entity_type": entityType, "entity_id": entityID}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var versions []models.RecordVersion
+	if err := cursor.All(ctx, &versions); err != nil {
+		return nil, err
+	}
+	return versions, nil
+}
+
+func (s *MongoStore) GetVersion(ctx context.Context, entityType, entityID string, version int) (*models.RecordVersion, error) {
+	var v models.RecordVersion
+	filter := bson.M{"entity_type": entityType, "entity_id": entityID, "version": version}
+	if err := findOne(ctx, s.versions(), filter, &v); err != nil {
+		return nil, err
+	}
+	return &v, nil
+}
+
+func (s *MongoStore) Search(ctx context.Context, q SearchQuery) ([]SearchHit, error) {
+	terms := SearchTerms(q.Text)
+	if len(terms) == 0 {
+		return nil, nil
+	}
+	var lists [][]SearchHit
+	for _, coll := range []struct {
+		entityType string
+		coll       *mongo.Collection
+		key        string
+		fields     []searchField
+	}{
+		{models.AuditEntityEmployee, s.employees(), "emp_id", employeeSearchFields},
+		{models.AuditEntityAsset, s.assets(), "asset_id", assetSearchFields},
+	} {
+		if !q.searches(coll.entityType) {
+			continue
+		}
+		hits, err := searchCollection(ctx, coll.coll, coll.entityType, coll.key, coll.fields, terms, q)
+		if err != nil {
+			return nil, err
+		}
+		lists = append(lists, hits)
+	}
+	return rankHits(q.Limit, lists...), nil
+}
+
+// prefixCandidates is how many documents per matching hit a prefix search
+// ranks, and maxPrefixCandidates the most it ranks per collection.
+const (
+	prefixCandidates    = 20
+	maxPrefixCandidates = 1000
+)
+
+// searchCollection finds the live documents of coll matching every one of
+// terms. Whole words are looked up in the text index and ranked by text
+// score. The text index cannot match the start of words, so prefix searches
+// use regular expressions instead and are ranked like the memory store.
+// Those match the start of any word, not just of the field, so they cannot
+// be anchored and no index serves them; the scan instead stops after a
+// bounded number of candidates, walking the _id index.
+func searchCollection(ctx context.Context, coll *mongo.Collection, entityType, key string, fields []searchField, terms []string, q SearchQuery) ([]SearchHit, error) {
+	filter := live(bson.M{})
+	projection := bson.M{key: 1}
+	for _, field := range fields {
+		projection[field.name] = 1
+	}
+	opts := options.Find()
+	if q.Prefix {
+		all := bson.A{}
+		for _, term := range terms {
+			wordStart := primitive.Regex{Pattern: `(^|[^\p{L}\p{N}])` + regexp.QuoteMeta(term), Options: "i"}
+			matches := bson.A{}
+			for _, field := range fields {
+				matches = append(matches, bson.M{field.name: wordStart})
+			}
+			all = append(all, bson.M{"$or": matches})
+		}
+		filter["$and"] = all
+		candidates := int64(maxPrefixCandidates)
+		if q.Limit > 0 && q.Limit*prefixCandidates < maxPrefixCandidates {
+			candidates = int64(q.Limit * prefixCandidates)
+		}
+		opts.SetSort(bson.D{{Key: "_id", Value: 1}}).SetLimit(candidates)
+	} else {
+		phrases := make([]string, len(terms))
+		for i, term := range terms {
+			phrases[i] = `"` + term + `"`
+		}
+		filter["$text"] = bson.M{"$search": strings.Join(phrases, " ")}
+		projection["score"] = bson.M{"$meta": "textScore"}
+		opts.SetSort(bson.D{{Key: "score", Value: bson.M{"$meta": "textScore"}}})
+		if q.Limit > 0 {
+			opts.SetLimit(int64(q.Limit))
+		}
+	}
+	cursor, err := coll.Find(ctx, filter, opts.SetProjection(projection))
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+	var docs []bson.M
+	if err := cursor.All(ctx, &docs); err != nil {
+		return nil, err
+	}
+
+	var hits []SearchHit
+	for _, doc := range docs {
+		hit := SearchHit{Type: entityType, Fields: make(map[string]string, len(fields))}
+		hit.ID, _ = doc[key].(string)
+		for _, field := range fields {
+			hit.Fields[field.name], _ = doc[field.name].(string)
+		}
+		if q.Prefix {
+			hit.Score = scoreFields(hit.Fields, fields, terms, true)
+		} else {
+			hit.Score, _ = doc["score"].(float64)
+		}
+		hits = append(hits, hit)
+	}
+	if q.Prefix {
+		hits = rankHits(q.Limit, hits)
+	}
+	return hits, nil
+}
+
+// reportRange returns the condition on a time field limiting it to r, or
+// nil when r is open.
+func reportRange(r ReportRange) bson.M {
+	if r.From.IsZero() && r.To.IsZero() {
+		return nil
+	}
+	condition := bson.M{}
+	if !r.From.IsZero() {
+		condition["$gte"] = r.From
+	}
+	if !r.To.IsZero() {
+		condition["$lte"] = r.To
+	}
+	return condition
+}
+
+func (s *MongoStore) AssetStatusCounts(ctx context.Context, r ReportRange) ([]models.AssetStatusCount, error) {
+	filter := live(bson.M{})
+	if created := reportRange(r); created != nil {
+		filter["created_at"] = created
+	}
+	latest := bson.D{{Key: "$arrayElemAt", Value: bson.A{"$mappings.status", 0}}}
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: filter}},
+		{{Key: "$lookup", Value: bson.D{
+			{Key: "from", Value: s.mappings().Name()},
+			{Key: "let", Value: bson.D{{Key: "asset_id", Value: "$asset_id"}}},
+			{Key: "pipeline", Value: bson.A{
+				bson.D{{Key: "$match", Value: bson.D{{Key: "$expr", Value: bson.D{{Key: "$eq", Value: bson.A{"$asset_id", "$$asset_id"}}}}}}},
+				bson.D{{Key: "$sort", Value: bson.D{{Key: "assigned_date", Value: -1}, {Key: "mapping_id", Value: -1}}}},
+				bson.D{{Key: "$project", Value: bson.D{{Key: "status", Value: 1}}}},
+			}},
+			{Key: "as", Value: "mappings"},
+		}}},
+		{{Key: "$group", Value: bson.D{
+			{Key: "_id", Value: bson.D{
+				{Key: "asset_type", Value: "$asset_type"},
+				{Key: "status", Value: bson.D{{Key: "$switch", Value: bson.D{
+					{Key: "branches", Value: bson.A{
+						bson.D{
+							{Key: "case", Value: bson.D{{Key: "$in", Value: bson.A{models.MappingStatusActive, "$mappings.status"}}}},
+							{Key: "then", Value: models.AssetStatusAssigned},
+						},
+						bson.D{
+							{Key: "case", Value: bson.D{{Key: "$in", Value: bson.A{latest, bson.A{models.MappingStatusLost, models.MappingStatusDamaged}}}}},
+							{Key: "then", Value: latest},
+						},
+					}},
+					{Key: "default", Value: models.AssetStatusAvailable},
+				}}}},
+			}},
+			{Key: "count", Value: bson.D{{Key: "$sum", Value: 1}}},
+		}}},
+		{{Key: "$sort", Value: bson.D{{Key: "_id.asset_type", Value: 1}, {Key: "_id.status", Value: 1}}}},
+		{{Key: "$project", Value: bson.D{
+			{Key: "_id", Value: 0},
+			{Key: "asset_type", Value: "$_id.asset_type"},
+			{Key: "status", Value: "$_id.status"},
+			{Key: "count", Value: 1},
+		}}},
+	}
+	var result []models.AssetStatusCount
+	if err := aggregate(ctx, s.assets(), pipeline, &result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+func (s *MongoStore) DepartmentAssignments(ctx context.Context, r ReportRange) ([]models.DepartmentAssignments, error) {
+	filter := bson.M{}
+	if assigned := reportRange(r); assigned != nil {
+		filter["assigned_date"] = assigned
+	}
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: filter}},
+		{{Key: "$lookup", Value: bson.D{
+			{Key: "from", Value: s.employees().Name()},
+			{Key: "localField", Value: "employee_id"},
+			{Key: "foreignField", Value: "emp_id"},
+			{Key: "as", Value: "employee"},
+		}}},
+		{{Key: "$group", Value: bson.D{
+			{Key: "_id", Value: bson.D{{Key: "$ifNull", Value: bson.A{
+				bson.D{{Key: "$arrayElemAt", Value: bson.A{"$employee.department", 0}}}, "",
+			}}}},
+			{Key: "assignments", Value: bson.D{{Key: "$sum", Value: 1}}},
+			{Key: "active", Value: bson.D{{Key: "$sum", Value: bson.D{{Key: "$cond", Value: bson.A{
+				bson.D{{Key: "$eq", Value: bson.A{"$status", models.MappingStatusActive}}}, 1, 0,
+			}}}}}},
+		}}},
+		{{Key: "$sort", Value: bson.D{{Key: "_id", Value: 1}}}},
+		{{Key: "$project", Value: bson.D{
+			{Key: "_id", Value: 0},
+			{Key: "department", Value: "$_id"},
+			{Key: "assignments", Value: 1},
+			{Key: "active", Value: 1},
+		}}},
+	}
+	var result []models.DepartmentAssignments
+	if err := aggregate(ctx, s.mappings(), pipeline, &result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+func (s *MongoStore) AssignmentDuration(ctx context.Context, r ReportRange) (models.AssignmentDuration, error) {
+	filter := bson.M{
+		"status":        bson.M{"$ne": models.MappingStatusActive},
+		"returned_date": bson.M{"$ne": nil},
+	}
+	if assigned := reportRange(r); assigned != nil {
+		filter["assigned_date"] = assigned
+	}
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: filter}},
+		{{Key: "$group", Value: bson.D{
+			{Key: "_id", Value: nil},
+			{Key: "closed_assignments", Value: bson.D{{Key: "$sum", Value: 1}}},
+			// Subtracting dates yields milliseconds.
+			{Key: "average_duration_seconds", Value: bson.D{{Key: "$avg", Value: bson.D{{Key: "$divide", Value: bson.A{
+				bson.D{{Key: "$subtract", Value: bson.A{"$returned_date", "$assigned_date"}}}, 1000,
+			}}}}}},
+		}}},
+	}
+	var result []models.AssignmentDuration
+	if err := aggregate(ctx, s.mappings(), pipeline, &result); err != nil || len(result) == 0 {
+		return models.AssignmentDuration{}, err
+	}
+	return result[0], nil
+}
+
+func (s *MongoStore) TopHolders(ctx context.Context, r ReportRange, limit int) ([]models.AssetHolder, error) {
+	filter := bson.M{"status": models.MappingStatusActive}
+	if assigned := reportRange(r); assigned != nil {
+		filter["assigned_date"] = assigned
+	}
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: filter}},
+		{{Key: "$group", Value: bson.D{
+			{Key: "_id", Value: "$employee_id"},
+			{Key: "active_assets", Value: bson.D{{Key: "$sum", Value: 1}}},
+		}}},
+		{{Key: "$sort", Value: bson.D{{Key: "active_assets", Value: -1}, {Key: "_id", Value: 1}}}},
+	}
+	if limit > 0 {
+		pipeline = append(pipeline, bson.D{{Key: "$limit", Value: limit}})
+	}
+	first := func(field string) bson.D {
+		return bson.D{{Key: "$ifNull", Value: bson.A{bson.D{{Key: "$arrayElemAt", Value: bson.A{"$employee." + field, 0}}}, ""}}}
+	}
+	pipeline = append(pipeline,
+		bson.D{{Key: "$lookup", Value: bson.D{
+			{Key: "from", Value: s.employees().Name()},
+			{Key: "localField", Value: "_id"},
+			{Key: "foreignField", Value: "emp_id"},
+			{Key: "as", Value: "employee"},
+		}}},
+		bson.D{{Key: "$project", Value: bson.D{
+			{Key: "_id", Value: 0},
+			{Key: "employee_id", Value: "$_id"},
+			{Key: "first_name", Value: first("first_name")},
+			{Key: "last_name", Value: first("last_name")},
+			{Key: "department", Value: first("department")},
+			{Key: "active_assets", Value: 1},
+		}}},
+	)
+	var holders []models.AssetHolder
+	if err := aggregate(ctx, s.mappings(), pipeline, &holders); err != nil {
+		return nil, err
+	}
+	return holders, nil
+}
+
+// aggregate runs pipeline on coll and decodes every result into out.
+func aggregate(ctx context.Context, coll *mongo.Collection, pipeline mongo.Pipeline, out interface{}) error {
+	cursor, err := coll.Aggregate(ctx, pipeline)
+	if err != nil {
+		return err
+	}
+	return cursor.All(ctx, out)
+}
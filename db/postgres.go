@@ -0,0 +1,90 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"embed"
+	"errors"
+	"log"
+	"strings"
+
+	"github.com/lib/pq"
+)
+
+//go:embed migrations/postgres/*.sql
+var postgresMigrations embed.FS
+
+// OpenPostgres connects to the PostgreSQL database at url and brings its
+// schema up to date before returning.
+func OpenPostgres(ctx context.Context, url string) (*SQLStore, error) {
+	conn, err := sql.Open("postgres", url)
+	if err != nil {
+		return nil, err
+	}
+	if err := conn.PingContext(ctx); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	if err := migrate(ctx, conn, postgresMigrations, "migrations/postgres"); err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	log.Println("Successfully connected to PostgreSQL!")
+	return &SQLStore{db: conn, translate: pgError, searchSQL: pgSearchSQL, shareLock: " FOR SHARE"}, nil
+}
+
+// pgError translates constraint violations into ErrConflict so callers do not
+// need to know about driver error codes.
+func pgError(err error) error {
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) {
+		switch pqErr.Code {
+		case "23503": // foreign_key_violation
+			return &constraintError{msg: pqErr.Message}
+		case "23505": // unique_violation
+			return &constraintError{unique: true, msg: pqErr.Message}
+		}
+	}
+	return err
+}
+
+// pgSearchSQL searches the weighted text search vector that the *_search_idx
+// GIN indexes are built on, ranking with ts_rank.
+func pgSearchSQL(table, key string, fields []searchField, terms []string, prefix bool) (string, []interface{}) {
+	vector := pgSearchVector(fields)
+	columns := make([]string, len(fields))
+	for i, field := range fields {
+		columns[i] = field.name
+	}
+	query := make([]string, len(terms))
+	for i, term := range terms {
+		query[i] = "'" + term + "'"
+		if prefix {
+			query[i] += ":*"
+		}
+	}
+	return `SELECT ` + key + `, ` + strings.Join(columns, ", ") + `, ts_rank(` + vector + `, q) AS score
+		FROM ` + table + `, to_tsquery('simple', $1) q
+		WHERE deleted_at IS NULL AND ` + vector + ` @@ q
+		ORDER BY score DESC, ` + key, []interface{}{strings.Join(query, " & ")}
+}
+
+// pgSearchVector returns the text search vector of fields. Punctuation is
+// replaced by spaces first so that emails and phone numbers split into words
+// the way SearchTerms splits queries. It must match the expression of the
+// indexes created by the 0013 migration and, for assets, 0017.
+func pgSearchVector(fields []searchField) string {
+	parts := make([]string, len(fields))
+	for i, field := range fields {
+		weight := "C"
+		switch {
+		case field.weight >= 10:
+			weight = "A"
+		case field.weight >= 5:
+			weight = "B"
+		}
+		parts[i] = "setweight(to_tsvector('simple', regexp_replace(" + field.name + ", '[^[:alnum:]]+', ' ', 'g')), '" + weight + "')"
+	}
+	return "(" + strings.Join(parts, " || ") + ")"
+}
@@ -0,0 +1,70 @@
+package db
+
+import (
+	"context"
+	"employee-asset-system/models"
+	"time"
+)
+
+// ReportRange limits reports to the assets created, or the assignments made,
+// between From and To inclusive. Zero bounds are open.
+type ReportRange struct {
+	From time.Time
+	To   time.Time
+}
+
+// contains reports whether t lies within r.
+func (r ReportRange) contains(t time.Time) bool {
+	return (r.From.IsZero() || !t.Before(r.From)) && (r.To.IsZero() || !t.After(r.To))
+}
+
+// ReportStore computes organization-wide figures over assets and their
+// assignments. Only AssetStatusCounts leaves deleted records out; the
+// assignment reports count every assignment made.
+type ReportStore interface {
+	// AssetStatusCounts counts the assets created in r by type and status,
+	// ordered by type and then status.
+	AssetStatusCounts(ctx context.Context, r ReportRange) ([]models.AssetStatusCount, error)
+	// DepartmentAssignments counts the assignments made in r by the
+	// department of their employee, ordered by department.
+	DepartmentAssignments(ctx context.Context, r ReportRange) ([]models.DepartmentAssignments, error)
+	// AssignmentDuration averages how long the assignments made in r that
+	// have since been closed lasted.
+	AssignmentDuration(ctx context.Context, r ReportRange) (models.AssignmentDuration, error)
+	// TopHolders returns up to limit employees holding the most assets
+	// through active assignments made in r, most first.
+	TopHolders(ctx context.Context, r ReportRange, limit int) ([]models.AssetHolder, error)
+}
+
+// assetStatus derives the report status of an asset from whether it is
+// assigned and the status of its latest mapping, if any.
+func assetStatus(assigned bool, latest string) string {
+	switch {
+	case assigned:
+		return models.AssetStatusAssigned
+	case latest == models.MappingStatusLost, latest == models.MappingStatusDamaged:
+		return latest
+	default:
+		return models.AssetStatusAvailable
+	}
+}
+
+// durationSum averages assignment durations for the backends that cannot
+// subtract times in the database.
+type durationSum struct {
+	n     int64
+	total time.Duration
+}
+
+func (d *durationSum) add(assigned, returned time.Time) {
+	d.n++
+	d.total += returned.Sub(assigned)
+}
+
+func (d *durationSum) report() models.AssignmentDuration {
+	report := models.AssignmentDuration{ClosedAssignments: d.n}
+	if d.n > 0 {
+		report.AverageDurationSeconds = d.total.Seconds() / float64(d.n)
+	}
+	return report
+}
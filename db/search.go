@@ -0,0 +1,154 @@
+package db
+
+import (
+	"context"
+	"employee-asset-system/models"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// SearchQuery is a full-text search over employees and assets.
+type SearchQuery struct {
+	Text  string
+	Types []string // entity types to search (models.AuditEntity*); empty for all
+	// Prefix matches every term against the start of words rather than
+	// whole words, for typeahead. MongoStore only ranks a bounded number of
+	// the matching records, so the best ones may be missed on a large
+	// collection.
+	Prefix bool
+	Limit  int // 0 means no limit
+}
+
+// SearchHit is a record matching a search.
+type SearchHit struct {
+	Type string
+	ID   string
+	// Score ranks the hit; higher is better. Scores are only comparable
+	// within the results of one search.
+	Score float64
+	// Fields holds the searchable fields of the record by name.
+	Fields map[string]string
+}
+
+// SearchStore finds employees and assets by the words in their names,
+// emails, phone numbers and addresses, and in the tags, serial numbers and
+// purchase details of assets. Every term of a query must match.
+type SearchStore interface {
+	Search(ctx context.Context, q SearchQuery) ([]SearchHit, error)
+}
+
+// searchField is a searchable field and the weight of its matches.
+type searchField struct {
+	name   string
+	weight float64
+}
+
+// The searchable fields of employees and assets, which the backends index.
+var (
+	employeeSearchFields = []searchField{
+		{"first_name", 10}, {"last_name", 10}, {"employee_email", 5}, {"phone_number", 5}, {"address", 2},
+	}
+	assetSearchFields = []searchField{
+		{"asset_name", 10}, {"asset_tag", 10}, {"serial_number", 10}, {"asset_type", 5},
+		{"manufacturer", 5}, {"model", 5}, {"vendor", 2}, {"invoice_number", 2},
+	}
+)
+
+// SearchTerms splits text into the lower case words searches match on: runs
+// of letters and digits.
+func SearchTerms(text string) []string {
+	return strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+// MatchesTerm reports whether word, as returned by SearchTerms, matches the
+// search term: whole, or only its start in prefix mode.
+func MatchesTerm(word, term string, prefix bool) bool {
+	if prefix {
+		return strings.HasPrefix(word, term)
+	}
+	return word == term
+}
+
+// searches reports whether q covers records of entityType.
+func (q SearchQuery) searches(entityType string) bool {
+	if len(q.Types) == 0 {
+		return true
+	}
+	for _, t := range q.Types {
+		if t == entityType {
+			return true
+		}
+	}
+	return false
+}
+
+// scoreFields scores the record with the given field values against terms
+// for the backends that rank in Go. Each matched term adds the weight of the
+// fields it matches, whole words twice as much as prefixes. It returns 0
+// unless every term matches.
+func scoreFields(values map[string]string, fields []searchField, terms []string, prefix bool) float64 {
+	var score float64
+	for _, term := range terms {
+		var termScore float64
+		for _, field := range fields {
+			for _, word := range SearchTerms(values[field.name]) {
+				switch {
+				case word == term:
+					termScore += 2 * field.weight
+				case MatchesTerm(word, term, prefix):
+					termScore += field.weight
+				}
+			}
+		}
+		if termScore == 0 {
+			return 0
+		}
+		score += termScore
+	}
+	return score
+}
+
+func employeeSearchValues(e *models.Employee) map[string]string {
+	return map[string]string{
+		"first_name":     e.FirstName,
+		"last_name":      e.LastName,
+		"employee_email": e.EmployeeEmail,
+		"phone_number":   e.PhoneNumber,
+		"address":        e.Address,
+	}
+}
+
+func assetSearchValues(a *models.Asset) map[string]string {
+	return map[string]string{
+		"asset_name":     a.AssetName,
+		"asset_tag":      a.AssetTag,
+		"serial_number":  a.SerialNumber,
+		"asset_type":     a.AssetType,
+		"manufacturer":   a.Manufacturer,
+		"model":          a.Model,
+		"vendor":         a.Vendor,
+		"invoice_number": a.InvoiceNumber,
+	}
+}
+
+// rankHits merges hits of several entity types, best first, and keeps the
+// first limit of them, or all when limit is 0.
+func rankHits(limit int, lists ...[]SearchHit) []SearchHit {
+	var hits []SearchHit
+	for _, list := range lists {
+		hits = append(hits, list...)
+	}
+	sort.SliceStable(hits, func(i, j int) bool {
+		if hits[i].Score != hits[j].Score {
+			return hits[i].Score > hits[j].Score
+		}
+		return hits[i].ID < hits[j].ID
+	})
+	if limit > 0 && len(hits) > limit {
+		hits = hits[:limit]
+	}
+	return hits
+}
@@ -0,0 +1,1361 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"employee-asset-system/models"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// SQLStore implements Store on top of database/sql. The same queries serve
+// PostgreSQL and SQLite: placeholders are written as $1..$n in ascending
+// order so both drivers bind them positionally.
+type SQLStore struct {
+	db *sql.DB
+	// translate maps driver specific constraint errors onto *constraintError.
+	translate func(error) error
+	// searchSQL builds the driver specific full-text query of Search.
+	searchSQL searchSQL
+	// shareLock is appended to subqueries whose rows must not change until
+	// the transaction ends. SQLite serialises writes and needs none.
+	shareLock string
+}
+
+// searchSQL returns the query selecting the key, the searchable fields and
+// the score of the live rows of table matching every one of terms, best
+// first, along with its arguments. Terms only hold letters and digits.
+type searchSQL func(table, key string, fields []searchField, terms []string, prefix bool) (string, []interface{})
+
+// constraintError is a constraint violation reported by the database. It
+// unwraps to ErrConflict; unique tells uniqueness violations apart from
+// foreign key violations.
+type constraintError struct {
+	unique bool
+	msg    string
+}
+
+func (e *constraintError) Error() string { return ErrConflict.Error() + ": " + e.msg }
+func (e *constraintError) Unwrap() error { return ErrConflict }
+
+// isUniqueViolation reports whether err is a uniqueness constraint violation.
+func isUniqueViolation(err error) bool {
+	var ce *constraintError
+	return errors.As(err, &ce) && ce.unique
+}
+
+// execOne runs a write that must touch exactly one row, returning
+// ErrNotFound when it touched none.
+func (s *SQLStore) execOne(ctx context.Context, query string, args ...interface{}) error {
+	res, err := s.db.ExecContext(ctx, query, args...)
+	if err != nil {
+		return s.translate(err)
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// rowScanner is satisfied by both *sql.Row and *sql.Rows.
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+// Each table is described by its column list and a function returning
+// pointers to the matching model fields, in the same order. The pointers are
+// used both as Scan destinations and as statement arguments, so the two can
+// never drift apart. The first column is always the primary key.
+var (
+	employeeColumns = []string{"emp_id", "first_name", "last_name", "gender", "phone_number",
+		"employee_email", "address", "blood_group", "emergency_contact_number", "password", "roles",
+		"created_at", "updated_at", "created_by", "updated_by", "deleted_at", "deleted_by", "version", "department"}
+	assetColumns = []string{"asset_id", "asset_name", "asset_type", "shared", "created_at", "updated_at", "created_by", "updated_by",
+		"deleted_at", "deleted_by", "version", "purchase_cost", "currency", "asset_tag", "serial_number", "vendor", "model", "purchase_date",
+		"manufacturer", "invoice_number", "warranty_expiry"}
+	mappingColumns = []string{"mapping_id", "employee_id", "asset_id", "assigned_date", "assigned_by", "status", "exclusive", "notes",
+		"returned_date", "returned_by", "return_condition", "close_reason", "version"}
+)
+
+func employeeFields(e *models.Employee) []interface{} {
+	return []interface{}{&e.EmpID, &e.FirstName, &e.LastName, &e.Gender, &e.PhoneNumber,
+		&e.EmployeeEmail, &e.Address, &e.BloodGroup, &e.EmergencyContactNumber, &e.Password, stringList{&e.Roles},
+		&e.CreatedAt, &e.UpdatedAt, &e.CreatedBy, &e.UpdatedBy, &e.DeletedAt, &e.DeletedBy, &e.Version, &e.Department}
+}
+
+func assetFields(a *models.Asset) []interface{} {
+	return []interface{}{&a.AssetID, &a.AssetName, &a.AssetType, &a.Shared, &a.CreatedAt, &a.UpdatedAt, &a.CreatedBy, &a.UpdatedBy,
+		&a.DeletedAt, &a.DeletedBy, &a.Version, &a.PurchaseCost, &a.Currency, &a.AssetTag, &a.SerialNumber, &a.Vendor, &a.Model,
+		&a.PurchaseDate, &a.Manufacturer, &a.InvoiceNumber, &a.WarrantyExpiry}
+}
+
+func mappingFields(m *models.EmployeeAssetMapping) []interface{} {
+	return []interface{}{&m.MappingID, &m.EmployeeID, &m.AssetID, &m.AssignedDate, &m.AssignedBy, &m.Status, &m.Exclusive, &m.Notes,
+		&m.ReturnedDate, &m.ReturnedBy, &m.ReturnCondition, &m.CloseReason, &m.Version}
+}
+
+// stringList stores a []string as a comma separated TEXT column.
+type stringList struct{ list *[]string }
+
+func (l stringList) Scan(src interface{}) error {
+	var text string
+	switch v := src.(type) {
+	case string:
+		text = v
+	case []byte:
+		text = string(v)
+	case nil:
+	default:
+		return fmt.Errorf("cannot scan %T into a string list", src)
+	}
+	*l.list = nil
+	if text != "" {
+		*l.list = strings.Split(text, ",")
+	}
+	return nil
+}
+
+func (l stringList) Value() (driver.Value, error) {
+	return strings.Join(*l.list, ","), nil
+}
+
+// nullTime scans a nullable time. Unlike columns, computed values such as
+// MAX(assigned_date) come back from SQLite as text.
+type nullTime struct{ t **time.Time }
+
+func (n nullTime) Scan(src interface{}) error {
+	var text string
+	switch v := src.(type) {
+	case nil:
+		*n.t = nil
+		return nil
+	case time.Time:
+		*n.t = &v
+		return nil
+	case string:
+		text = v
+	case []byte:
+		text = string(v)
+	default:
+		return fmt.Errorf("cannot scan %T into a time", src)
+	}
+	for _, layout := range []string{"2006-01-02 15:04:05.999999999 -0700 MST", time.RFC3339Nano, "2006-01-02 15:04:05.999999999-07:00"} {
+		if t, err := time.Parse(layout, text); err == nil {
+			*n.t = &t
+			return nil
+		}
+	}
+	return fmt.Errorf("cannot parse time %q", text)
+}
+
+// jsonText stores any JSON encodable value in a TEXT column.
+type jsonText struct{ v interface{} }
+
+func (j jsonText) Scan(src interface{}) error {
+	switch v := src.(type) {
+	case string:
+		return json.Unmarshal([]byte(v), j.v)
+	case []byte:
+		return json.Unmarshal(v, j.v)
+	case nil:
+		return nil
+	default:
+		return fmt.Errorf("cannot scan %T into JSON", src)
+	}
+}
+
+func (j jsonText) Value() (driver.Value, error) {
+	data, err := json.Marshal(j.v)
+	return string(data), err
+}
+
+// selectSQL returns "SELECT <columns> FROM <table>" ready for a WHERE clause.
+func selectSQL(table string, columns []string) string {
+	return "SELECT " + strings.Join(columns, ", ") + " FROM " + table
+}
+
+func insertSQL(table string, columns []string) string {
+	return "INSERT INTO " + table + " (" + strings.Join(columns, ", ") + ") VALUES (" + placeholders(len(columns)) + ")"
+}
+
+// insertWhereSQL is insertSQL for a row that is only inserted when the
+// condition holds. The condition's placeholders follow those of columns.
+func insertWhereSQL(table string, columns []string, condition string) string {
+	return "INSERT INTO " + table + " (" + strings.Join(columns, ", ") + ") SELECT " + placeholders(len(columns)) + " WHERE " + condition
+}
+
+// placeholders returns "$1, $2, ..., $n".
+func placeholders(n int) string {
+	list := make([]string, n)
+	for i := range list {
+		list[i] = "$" + strconv.Itoa(i+1)
+	}
+	return strings.Join(list, ", ")
+}
+
+// updateSQL sets every column but the first and matches on the first. Use it
+// with keyLast to order the arguments accordingly.
+func updateSQL(table string, columns []string) string {
+	sets := make([]string, len(columns)-1)
+	for i, column := range columns[1:] {
+		sets[i] = column + " = $" + strconv.Itoa(i+1)
+	}
+	return "UPDATE " + table + " SET " + strings.Join(sets, ", ") + " WHERE " + columns[0] + " = $" + strconv.Itoa(len(columns))
+}
+
+// keyLast moves the primary key argument to the end, matching updateSQL.
+func keyLast(fields []interface{}) []interface{} {
+	return append(fields[1:len(fields):len(fields)], fields[0])
+}
+
+// updateVersioned saves fields, the fields of a live row of table at
+// *version, over the stored row as long as it is still at that version, and
+// bumps *version.
+func (s *SQLStore) updateVersioned(ctx context.Context, table string, columns []string, fields []interface{}, version *int64) error {
+	expected := *version
+	*version = expected + 1
+	args := utcArgs(keyLast(fields))
+	query := updateSQL(table, columns) + " AND deleted_at IS NULL AND version = $" + strconv.Itoa(len(args)+1)
+	err := s.execOne(ctx, query, append(args, expected)...)
+	if errors.Is(err, ErrNotFound) {
+		err = staleOrMissing(ctx, s.db, table, columns[0], args[len(args)-1], " AND deleted_at IS NULL")
+	}
+	if err != nil {
+		*version = expected
+	}
+	return err
+}
+
+// queryRower is satisfied by both *sql.DB and *sql.Tx.
+type queryRower interface {
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+}
+
+// sqlConn runs the statements of a write either directly on the database or
+// within a transaction.
+type sqlConn interface {
+	queryRower
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+}
+
+// staleOrMissing tells why a conditional write matched no row: it returns
+// ErrStaleVersion when the row whose key is id exists and ErrNotFound
+// otherwise. live is appended to the WHERE clause.
+func staleOrMissing(ctx context.Context, q queryRower, table, key string, id interface{}, live string) error {
+	var n int
+	err := q.QueryRowContext(ctx, `SELECT COUNT(*) FROM `+table+` WHERE `+key+` = $1`+live, id).Scan(&n)
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return ErrNotFound
+	}
+	return ErrStaleVersion
+}
+
+// utcArgs returns args with the times among them converted to UTC. SQLite
+// compares times as text, so they must all be stored in the same zone and
+// without the monotonic clock reading time.Now attaches to them.
+func utcArgs(args []interface{}) []interface{} {
+	out := make([]interface{}, len(args))
+	for i, arg := range args {
+		switch v := arg.(type) {
+		case *time.Time:
+			out[i] = v.UTC()
+		case **time.Time:
+			if *v != nil {
+				out[i] = (*v).UTC()
+			}
+		default:
+			out[i] = arg
+		}
+	}
+	return out
+}
+
+// sqlFilter collects the conditions of a WHERE clause and their arguments.
+type sqlFilter struct {
+	conditions []string
+	args       []interface{}
+}
+
+// where adds condition, in which each ? stands for the next of args.
+func (f *sqlFilter) where(condition string, args ...interface{}) {
+	for _, arg := range args {
+		f.args = append(f.args, arg)
+		condition = strings.Replace(condition, "?", "$"+strconv.Itoa(len(f.args)), 1)
+	}
+	f.conditions = append(f.conditions, condition)
+}
+
+func (f *sqlFilter) clause() string {
+	if len(f.conditions) == 0 {
+		return ""
+	}
+	return " WHERE " + strings.Join(f.conditions, " AND ")
+}
+
+// prefixPattern returns a LIKE pattern, for use with ESCAPE '\', matching
+// the lower case strings that start with prefix.
+func prefixPattern(prefix string) string {
+	escaped := strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(strings.ToLower(prefix))
+	return escaped + "%"
+}
+
+// page adds the condition selecting the records after opts.After to f and
+// returns the ORDER BY and LIMIT clauses of the page. field is the sort field,
+// column the expression it is stored in and key the primary key column.
+func (f *sqlFilter) page(opts ListOptions, field, column, key string) (string, error) {
+	dir, op := "ASC", ">"
+	if opts.Desc {
+		dir, op = "DESC", "<"
+	}
+	if opts.After != nil {
+		value, err := cursorValue(opts.After, field)
+		if err != nil {
+			return "", err
+		}
+		if t, ok := value.(time.Time); ok {
+			value = t.UTC()
+		}
+		f.where("("+column+" "+op+" ? OR ("+column+" = ? AND "+key+" "+op+" ?))", value, value, opts.After.ID)
+	}
+	order := " ORDER BY " + column + " " + dir + ", " + key + " " + dir
+	if opts.Limit > 0 {
+		f.args = append(f.args, opts.Limit)
+		order += " LIMIT $" + strconv.Itoa(len(f.args))
+	}
+	return order, nil
+}
+
+// scanOne scans a single row into fields, translating sql.ErrNoRows.
+func scanOne(row *sql.Row, fields []interface{}) error {
+	err := row.Scan(fields...)
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrNotFound
+	}
+	return err
+}
+
+func (s *SQLStore) CreateEmployee(ctx context.Context, e *models.Employee) error {
+	e.Version = 1
+	_, err := s.db.ExecContext(ctx, insertSQL("employees", employeeColumns), utcArgs(employeeFields(e))...)
+	return s.translate(err)
+}
+
+func (s *SQLStore) CreateEmployees(ctx context.Context, employees []models.Employee) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	insert, err := tx.PrepareContext(ctx, insertSQL("employees", employeeColumns))
+	if err != nil {
+		return err
+	}
+	defer insert.Close()
+	for i := range employees {
+		employees[i].Version = 1
+		if _, err := insert.ExecContext(ctx, utcArgs(employeeFields(&employees[i]))...); err != nil {
+			return s.translate(err)
+		}
+	}
+	return tx.Commit()
+}
+
+func (s *SQLStore) GetEmployee(ctx context.Context, empID string) (*models.Employee, error) {
+	var e models.Employee
+	row := s.db.QueryRowContext(ctx, selectSQL("employees", employeeColumns)+" WHERE emp_id = $1 AND deleted_at IS NULL", empID)
+	if err := scanOne(row, employeeFields(&e)); err != nil {
+		return nil, err
+	}
+	return &e, nil
+}
+
+func (s *SQLStore) FindEmployeeByIdentifier(ctx context.Context, identifier string) (*models.Employee, error) {
+	var e models.Employee
+	row := s.db.QueryRowContext(ctx, selectSQL("employees", employeeColumns)+
+		" WHERE (phone_number = $1 OR employee_email = $1) AND deleted_at IS NULL LIMIT 1", identifier)
+	if err := scanOne(row, employeeFields(&e)); err != nil {
+		return nil, err
+	}
+	return &e, nil
+}
+
+func (s *SQLStore) EmployeesByIdentifier(ctx context.Context, emails, phones []string) ([]models.Employee, error) {
+	var conditions []string
+	var args []interface{}
+	for _, list := range []struct {
+		column string
+		values []string
+	}{{"LOWER(employee_email)", emails}, {"phone_number", phones}} {
+		if len(list.values) == 0 {
+			continue
+		}
+		placeholders := make([]string, len(list.values))
+		for i, value := range list.values {
+			args = append(args, value)
+			placeholders[i] = "$" + strconv.Itoa(len(args))
+		}
+		conditions = append(conditions, list.column+" IN ("+strings.Join(placeholders, ", ")+")")
+	}
+	if len(conditions) == 0 {
+		return nil, nil
+	}
+	rows, err := s.db.QueryContext(ctx, selectSQL("employees", employeeColumns)+
+		" WHERE ("+strings.Join(conditions, " OR ")+") AND deleted_at IS NULL", args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var employees []models.Employee
+	for rows.Next() {
+		var e models.Employee
+		if err := rows.Scan(employeeFields(&e)...); err != nil {
+			return nil, err
+		}
+		employees = append(employees, e)
+	}
+	return employees, rows.Err()
+}
+
+func (s *SQLStore) UpdateEmployee(ctx context.Context, e *models.Employee) error {
+	return s.updateVersioned(ctx, "employees", employeeColumns, employeeFields(e), &e.Version)
+}
+
+func (s *SQLStore) DeleteEmployee(ctx context.Context, empID string, del models.Deletion) error {
+	return s.deleteWithMappings(ctx, "employees", "emp_id", "employee_id", empID, del)
+}
+
+func (s *SQLStore) RestoreEmployee(ctx context.Context, empID string) error {
+	return s.execOne(ctx, `UPDATE employees SET deleted_at = NULL, deleted_by = '', version = version + 1
+		WHERE emp_id = $1 AND deleted_at IS NOT NULL`, empID)
+}
+
+func (s *SQLStore) PurgeEmployees(ctx context.Context, deletedBefore time.Time) (int64, error) {
+	return s.purge(ctx, "employees", "emp_id", "employee_id", deletedBefore)
+}
+
+func (s *SQLStore) Dashboard(ctx context.Context, q EmployeeQuery) ([]models.DashboardEmployee, int64, error) {
+	var f sqlFilter
+	f.where("e.deleted_at IS NULL")
+	if q.NamePrefix != "" {
+		pattern := prefixPattern(q.NamePrefix)
+		f.where(`(LOWER(e.first_name) LIKE ? ESCAPE '\' OR LOWER(e.last_name) LIKE ? ESCAPE '\')`, pattern, pattern)
+	}
+	if q.Role != "" {
+		f.where("',' || e.roles || ',' LIKE ?", "%,"+q.Role+",%")
+	}
+	if !q.CreatedAfter.IsZero() {
+		f.where("e.created_at > ?", q.CreatedAfter.UTC())
+	}
+	if !q.CreatedBefore.IsZero() {
+		f.where("e.created_at < ?", q.CreatedBefore.UTC())
+	}
+
+	var total int64
+	if err := s.db.QueryRowContext(ctx, "SELECT COUNT(*) FROM employees e"+f.clause(), f.args...).Scan(&total); err != nil {
+		return nil, 0, err
+	}
+	field, err := sortField(q.ListOptions, EmployeeSortFields)
+	if err != nil {
+		return nil, 0, err
+	}
+	order, err := f.page(q.ListOptions, field, "e."+field, "e.emp_id")
+	if err != nil {
+		return nil, 0, err
+	}
+
+	rows, err := s.db.QueryContext(ctx, `SELECT e.emp_id, e.first_name, e.last_name, e.gender,
+			e.phone_number, e.employee_email, e.address, e.blood_group, e.emergency_contact_number, e.department,
+			(SELECT COUNT(*) FROM mappings m WHERE m.employee_id = e.emp_id AND m.status = '`+models.MappingStatusActive+`'),
+			(SELECT MAX(m.assigned_date) FROM mappings m WHERE m.employee_id = e.emp_id),
+			e.created_at, e.updated_at
+		FROM employees e`+f.clause()+order, f.args...)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	var employees []models.DashboardEmployee
+	for rows.Next() {
+		var d models.DashboardEmployee
+		if err := rows.Scan(&d.EmpId, &d.FirstName, &d.LastName, &d.Gender, &d.PhoneNumber,
+			&d.EmployeeEmail, &d.Address, &d.BloodGroup, &d.EmergencyContactNumber, &d.Department, &d.AssetCount,
+			nullTime{&d.LastAssignedAt}, &d.CreatedAt, &d.UpdatedAt); err != nil {
+			return nil, 0, err
+		}
+		employees = append(employees, d)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, 0, err
+	}
+	return employees, total, s.addAssignedAssets(ctx, employees)
+}
+
+// addAssignedAssets breaks the assets currently assigned to employees down
+// by type and currency.
+func (s *SQLStore) addAssignedAssets(ctx context.Context, employees []models.DashboardEmployee) error {
+	if len(employees) == 0 {
+		return nil
+	}
+	byID := make(map[string]*models.DashboardEmployee, len(employees))
+	placeholders := make([]string, len(employees))
+	args := make([]interface{}, len(employees))
+	for i := range employees {
+		byID[employees[i].EmpId] = &employees[i]
+		placeholders[i] = "$" + strconv.Itoa(i+1)
+		args[i] = employees[i].EmpId
+	}
+	rows, err := s.db.QueryContext(ctx, `SELECT m.employee_id, a.asset_type, a.currency, COUNT(*), COALESCE(SUM(a.purchase_cost), 0)
+		FROM mappings m
+		JOIN assets a ON a.asset_id = m.asset_id
+		WHERE m.employee_id IN (`+strings.Join(placeholders, ", ")+`) AND m.status = '`+models.MappingStatusActive+`'
+		GROUP BY m.employee_id, a.asset_type, a.currency`, args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var empID, assetType, currency string
+		var n int
+		var cost float64
+		if err := rows.Scan(&empID, &assetType, &currency, &n, &cost); err != nil {
+			return err
+		}
+		addAssigned(byID[empID], assetType, currency, n, cost)
+	}
+	return rows.Err()
+}
+
+func (s *SQLStore) CreateAsset(ctx context.Context, a *models.Asset) error {
+	a.Version = 1
+	_, err := s.db.ExecContext(ctx, insertSQL("assets", assetColumns), utcArgs(assetFields(a))...)
+	return s.translate(err)
+}
+
+func (s *SQLStore) CreateAssets(ctx context.Context, assets []models.Asset) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	insert, err := tx.PrepareContext(ctx, insertSQL("assets", assetColumns))
+	if err != nil {
+		return err
+	}
+	defer insert.Close()
+	for i := range assets {
+		assets[i].Version = 1
+		if _, err := insert.ExecContext(ctx, utcArgs(assetFields(&assets[i]))...); err != nil {
+			return s.translate(err)
+		}
+	}
+	return tx.Commit()
+}
+
+func (s *SQLStore) AssetsBySerial(ctx context.Context, serials []string) ([]models.Asset, error) {
+	if len(serials) == 0 {
+		return nil, nil
+	}
+	placeholders := make([]string, len(serials))
+	args := make([]interface{}, len(serials))
+	for i, serial := range serials {
+		placeholders[i] = "$" + strconv.Itoa(i+1)
+		args[i] = serial
+	}
+	rows, err := s.db.QueryContext(ctx, selectSQL("assets", assetColumns)+
+		" WHERE serial_number IN ("+strings.Join(placeholders, ", ")+") ORDER BY serial_number", args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var assets []models.Asset
+	for rows.Next() {
+		var a models.Asset
+		if err := rows.Scan(assetFields(&a)...); err != nil {
+			return nil, err
+		}
+		assets = append(assets, a)
+	}
+	return assets, rows.Err()
+}
+
+func (s *SQLStore) GetAsset(ctx context.Context, assetID string) (*models.Asset, error) {
+	var a models.Asset
+	row := s.db.QueryRowContext(ctx, selectSQL("assets", assetColumns)+" WHERE asset_id = $1 AND deleted_at IS NULL", assetID)
+	if err := scanOne(row, assetFields(&a)); err != nil {
+		return nil, err
+	}
+	return &a, nil
+}
+
+func (s *SQLStore) ListAssets(ctx context.Context, q AssetQuery) ([]models.Asset, int64, error) {
+	var f sqlFilter
+	f.where("deleted_at IS NULL")
+	if q.AssetType != "" {
+		f.where("asset_type = ?", q.AssetType)
+	}
+	if q.NamePrefix != "" {
+		f.where(`LOWER(asset_name) LIKE ? ESCAPE '\'`, prefixPattern(q.NamePrefix))
+	}
+	if q.Shared != nil {
+		f.where("shared = ?", *q.Shared)
+	}
+	if !q.CreatedAfter.IsZero() {
+		f.where("created_at > ?", q.CreatedAfter.UTC())
+	}
+	if !q.CreatedBefore.IsZero() {
+		f.where("created_at < ?", q.CreatedBefore.UTC())
+	}
+	if q.SerialNumber != "" {
+		f.where("serial_number = ?", q.SerialNumber)
+	}
+	if q.InvoiceNumber != "" {
+		f.where("invoice_number = ?", q.InvoiceNumber)
+	}
+	for _, c := range []struct{ column, value string }{
+		{"manufacturer", q.Manufacturer}, {"model", q.Model}, {"vendor", q.Vendor},
+	} {
+		if c.value != "" {
+			f.where("LOWER("+c.column+") = ?", strings.ToLower(c.value))
+		}
+	}
+	if !q.PurchasedAfter.IsZero() {
+		f.where("purchase_date > ?", q.PurchasedAfter.UTC())
+	}
+	if !q.PurchasedBefore.IsZero() {
+		f.where("purchase_date < ?", q.PurchasedBefore.UTC())
+	}
+	if !q.WarrantyExpiresAfter.IsZero() {
+		f.where("warranty_expiry > ?", q.WarrantyExpiresAfter.UTC())
+	}
+	if !q.WarrantyExpiresBefore.IsZero() {
+		f.where("warranty_expiry < ?", q.WarrantyExpiresBefore.UTC())
+	}
+
+	var total int64
+	if err := s.db.QueryRowContext(ctx, "SELECT COUNT(*) FROM assets"+f.clause(), f.args...).Scan(&total); err != nil {
+		return nil, 0, err
+	}
+	field, err := sortField(q.ListOptions, AssetSortFields)
+	if err != nil {
+		return nil, 0, err
+	}
+	order, err := f.page(q.ListOptions, field, field, "asset_id")
+	if err != nil {
+		return nil, 0, err
+	}
+
+	rows, err := s.db.QueryContext(ctx, selectSQL("assets", assetColumns)+f.clause()+order, f.args...)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	var assets []models.Asset
+	for rows.Next() {
+		var a models.Asset
+		if err := rows.Scan(assetFields(&a)...); err != nil {
+			return nil, 0, err
+		}
+		assets = append(assets, a)
+	}
+	return assets, total, rows.Err()
+}
+
+func (s *SQLStore) UpdateAsset(ctx context.Context, a *models.Asset) error {
+	return s.updateVersioned(ctx, "assets", assetColumns, assetFields(a), &a.Version)
+}
+
+func (s *SQLStore) DeleteAsset(ctx context.Context, assetID string, del models.Deletion) error {
+	return s.deleteWithMappings(ctx, "assets", "asset_id", "asset_id", assetID, del)
+}
+
+func (s *SQLStore) RestoreAsset(ctx context.Context, assetID string) error {
+	return s.execOne(ctx, `UPDATE assets SET deleted_at = NULL, deleted_by = '', version = version + 1
+		WHERE asset_id = $1 AND deleted_at IS NOT NULL`, assetID)
+}
+
+func (s *SQLStore) PurgeAssets(ctx context.Context, deletedBefore time.Time) (int64, error) {
+	return s.purge(ctx, "assets", "asset_id", "asset_id", deletedBefore)
+}
+
+// purge removes the rows of table deleted before the given time unless the
+// mappings column still references their key. Deletion times are stored in
+// UTC so that SQLite, which compares them as text, orders them correctly.
+func (s *SQLStore) purge(ctx context.Context, table, key, column string, deletedBefore time.Time) (int64, error) {
+	res, err := s.db.ExecContext(ctx, `DELETE FROM `+table+` WHERE deleted_at < $1
+		AND NOT EXISTS (SELECT 1 FROM mappings WHERE mappings.`+column+` = `+table+`.`+key+`)`, deletedBefore.UTC())
+	if err != nil {
+		return 0, s.translate(err)
+	}
+	return res.RowsAffected()
+}
+
+// deleteWithMappings tombstones the live row of table whose key is id in a
+// single transaction with the active mappings whose column references it:
+// they are closed with del.Cascade, or the delete fails with
+// ErrActiveMappings. The tombstone is written first so that it locks the
+// row: a checkout racing the delete has either committed its mapping, which
+// is then seen here, or waits for the delete and finds the row gone.
+func (s *SQLStore) deleteWithMappings(ctx context.Context, table, key, column, id string, del models.Deletion) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	query := `UPDATE ` + table + ` SET deleted_at = $1, deleted_by = $2, version = version + 1
+		WHERE ` + key + ` = $3 AND deleted_at IS NULL`
+	args := []interface{}{del.DeletedAt.UTC(), del.DeletedBy, id}
+	if del.IfVersion != 0 {
+		query += " AND version = $4"
+		args = append(args, del.IfVersion)
+	}
+	res, err := tx.ExecContext(ctx, query, args...)
+	if err != nil {
+		return s.translate(err)
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return staleOrMissing(ctx, tx, table, key, id, " AND deleted_at IS NULL")
+	}
+
+	if del.Cascade == nil {
+		var active int
+		err := tx.QueryRowContext(ctx, `SELECT COUNT(*) FROM mappings WHERE `+column+` = $1 AND status = $2`,
+			id, models.MappingStatusActive).Scan(&active)
+		if err != nil {
+			return err
+		}
+		if active > 0 {
+			return ErrActiveMappings
+		}
+	} else {
+		var m models.EmployeeAssetMapping
+		applyReturn(&m, *del.Cascade)
+		_, err := tx.ExecContext(ctx, `UPDATE mappings SET status = $1, returned_date = $2, returned_by = $3,
+				return_condition = $4, close_reason = $5, version = version + 1
+			WHERE `+column+` = $6 AND status = $7`,
+			utcArgs([]interface{}{m.Status, m.ReturnedDate, m.ReturnedBy, m.ReturnCondition, m.CloseReason, id,
+				models.MappingStatusActive})...)
+		if err != nil {
+			return s.translate(err)
+		}
+	}
+	return tx.Commit()
+}
+
+func (s *SQLStore) CreateMapping(ctx context.Context, m *models.EmployeeAssetMapping) error {
+	return s.createMapping(ctx, s.db, m)
+}
+
+// createMapping inserts m unless its employee or asset is missing or
+// deleted. The check is part of the insert, and on PostgreSQL locks both
+// rows, so a concurrent delete either waits for the mapping or wins.
+func (s *SQLStore) createMapping(ctx context.Context, conn sqlConn, m *models.EmployeeAssetMapping) error {
+	m.Version = 1
+	n := len(mappingColumns)
+	query := insertWhereSQL("mappings", mappingColumns,
+		`EXISTS (SELECT 1 FROM employees WHERE emp_id = $`+strconv.Itoa(n+1)+` AND deleted_at IS NULL`+s.shareLock+`)
+		AND EXISTS (SELECT 1 FROM assets WHERE asset_id = $`+strconv.Itoa(n+2)+` AND deleted_at IS NULL`+s.shareLock+`)`)
+	res, err := conn.ExecContext(ctx, query, append(utcArgs(mappingFields(m)), m.EmployeeID, m.AssetID)...)
+	if err = s.translate(err); isUniqueViolation(err) {
+		// The only unique index besides the primary key is the one on
+		// active exclusive mappings per asset.
+		return ErrAssetAssigned
+	}
+	if err != nil {
+		return err
+	}
+	inserted, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if inserted == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func (s *SQLStore) GetMapping(ctx context.Context, mappingID string) (*models.EmployeeAssetMapping, error) {
+	return getMapping(ctx, s.db, mappingID)
+}
+
+func getMapping(ctx context.Context, q queryRower, mappingID string) (*models.EmployeeAssetMapping, error) {
+	var m models.EmployeeAssetMapping
+	row := q.QueryRowContext(ctx, selectSQL("mappings", mappingColumns)+" WHERE mapping_id = $1", mappingID)
+	if err := scanOne(row, mappingFields(&m)); err != nil {
+		return nil, err
+	}
+	return &m, nil
+}
+
+func (s *SQLStore) ListMappingsByEmployee(ctx context.Context, empID, status string) ([]models.EmployeeAssetMapping, error) {
+	return s.listMappings(ctx, "employee_id", empID, status)
+}
+
+func (s *SQLStore) ListMappingsByAsset(ctx context.Context, assetID, status string) ([]models.EmployeeAssetMapping, error) {
+	return s.listMappings(ctx, "asset_id", assetID, status)
+}
+
+func (s *SQLStore) listMappings(ctx context.Context, column, value, status string) ([]models.EmployeeAssetMapping, error) {
+	query := selectSQL("mappings", mappingColumns) + " WHERE " + column + " = $1"
+	args := []interface{}{value}
+	if status != "" {
+		query += " AND status = $2"
+		args = append(args, status)
+	}
+	rows, err := s.db.QueryContext(ctx, query+" ORDER BY assigned_date, mapping_id", args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var mappings []models.EmployeeAssetMapping
+	for rows.Next() {
+		var m models.EmployeeAssetMapping
+		if err := rows.Scan(mappingFields(&m)...); err != nil {
+			return nil, err
+		}
+		mappings = append(mappings, m)
+	}
+	return mappings, rows.Err()
+}
+
+func (s *SQLStore) ListMappings(ctx context.Context, q MappingQuery) ([]models.EmployeeAssetMapping, int64, error) {
+	var f sqlFilter
+	if q.EmployeeID != "" {
+		f.where("employee_id = ?", q.EmployeeID)
+	}
+	if q.AssetID != "" {
+		f.where("asset_id = ?", q.AssetID)
+	}
+	if q.Status != "" {
+		f.where("status = ?", q.Status)
+	}
+	if !q.AssignedAfter.IsZero() {
+		f.where("assigned_date > ?", q.AssignedAfter.UTC())
+	}
+	if !q.AssignedBefore.IsZero() {
+		f.where("assigned_date < ?", q.AssignedBefore.UTC())
+	}
+
+	var total int64
+	if err := s.db.QueryRowContext(ctx, "SELECT COUNT(*) FROM mappings"+f.clause(), f.args...).Scan(&total); err != nil {
+		return nil, 0, err
+	}
+	field, err := sortField(q.ListOptions, MappingSortFields)
+	if err != nil {
+		return nil, 0, err
+	}
+	order, err := f.page(q.ListOptions, field, field, "mapping_id")
+	if err != nil {
+		return nil, 0, err
+	}
+
+	rows, err := s.db.QueryContext(ctx, selectSQL("mappings", mappingColumns)+f.clause()+order, f.args...)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	var mappings []models.EmployeeAssetMapping
+	for rows.Next() {
+		var m models.EmployeeAssetMapping
+		if err := rows.Scan(mappingFields(&m)...); err != nil {
+			return nil, 0, err
+		}
+		mappings = append(mappings, m)
+	}
+	return mappings, total, rows.Err()
+}
+
+func (s *SQLStore) ReturnMapping(ctx context.Context, mappingID string, ret models.MappingReturn) (*models.EmployeeAssetMapping, error) {
+	return s.returnMapping(ctx, s.db, mappingID, ret)
+}
+
+func (s *SQLStore) returnMapping(ctx context.Context, conn sqlConn, mappingID string, ret models.MappingReturn) (*models.EmployeeAssetMapping, error) {
+	var m models.EmployeeAssetMapping
+	applyReturn(&m, ret)
+	query := `UPDATE mappings SET status = $1, returned_date = $2, returned_by = $3, return_condition = $4,
+			close_reason = $5, version = version + 1
+		WHERE mapping_id = $6 AND status = $7`
+	args := []interface{}{m.Status, m.ReturnedDate, m.ReturnedBy, m.ReturnCondition, m.CloseReason, mappingID,
+		models.MappingStatusActive}
+	if ret.IfVersion != 0 {
+		query += " AND version = $8"
+		args = append(args, ret.IfVersion)
+	}
+	res, err := conn.ExecContext(ctx, query, utcArgs(args)...)
+	if err != nil {
+		return nil, s.translate(err)
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return nil, err
+	}
+	if n == 0 {
+		// Tell a missing or changed mapping apart from one that is already
+		// closed.
+		current, err := getMapping(ctx, conn, mappingID)
+		if err != nil {
+			return nil, err
+		}
+		if ret.IfVersion != 0 && current.Version != ret.IfVersion {
+			return nil, ErrStaleVersion
+		}
+		return nil, ErrMappingClosed
+	}
+	return getMapping(ctx, conn, mappingID)
+}
+
+// ApplyMappingChanges runs every change under a savepoint of one
+// transaction, so a failed change is rolled back on its own.
+func (s *SQLStore) ApplyMappingChanges(ctx context.Context, changes []MappingChange, atomic bool) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	for i := range changes {
+		c := &changes[i]
+		if _, err := tx.ExecContext(ctx, "SAVEPOINT mapping_change"); err != nil {
+			return err
+		}
+		if c.Assign != nil {
+			if c.Err = s.createMapping(ctx, tx, c.Assign); c.Err == nil {
+				c.Result = c.Assign
+			}
+		} else {
+			c.Result, c.Err = s.returnMapping(ctx, tx, c.MappingID, c.Return)
+		}
+		release := "RELEASE SAVEPOINT mapping_change"
+		if c.Err != nil {
+			release = "ROLLBACK TO SAVEPOINT mapping_change"
+		}
+		if _, err := tx.ExecContext(ctx, release); err != nil {
+			return err
+		}
+	}
+	if atomic && failed(changes) {
+		return nil
+	}
+	return tx.Commit()
+}
+
+func (s *SQLStore) UpdateMapping(ctx context.Context, m *models.EmployeeAssetMapping) error {
+	err := s.execOne(ctx, `UPDATE mappings SET notes = $1, return_condition = $2, close_reason = $3, version = $4
+		WHERE mapping_id = $5 AND version = $6`,
+		m.Notes, m.ReturnCondition, m.CloseReason, m.Version+1, m.MappingID, m.Version)
+	if errors.Is(err, ErrNotFound) {
+		return staleOrMissing(ctx, s.db, "mappings", "mapping_id", m.MappingID, "")
+	}
+	if err == nil {
+		m.Version++
+	}
+	return err
+}
+
+var refreshTokenColumns = []string{"token_hash", "family_id", "emp_id", "created_at", "expires_at", "used_at", "revoked_at"}
+
+func refreshTokenFields(t *models.RefreshToken) []interface{} {
+	return []interface{}{&t.TokenHash, &t.FamilyID, &t.EmpID, &t.CreatedAt, &t.ExpiresAt, &t.UsedAt, &t.RevokedAt}
+}
+
+func (s *SQLStore) CreateRefreshToken(ctx context.Context, t *models.RefreshToken) error {
+	_, err := s.db.ExecContext(ctx, insertSQL("refresh_tokens", refreshTokenColumns), utcArgs(refreshTokenFields(t))...)
+	return s.translate(err)
+}
+
+func (s *SQLStore) UseRefreshToken(ctx context.Context, tokenHash string, at time.Time) (*models.RefreshToken, error) {
+	res, err := s.db.ExecContext(ctx, `UPDATE refresh_tokens SET used_at = $1
+		WHERE token_hash = $2 AND used_at IS NULL`, at.UTC(), tokenHash)
+	if err != nil {
+		return nil, err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return nil, err
+	}
+
+	var t models.RefreshToken
+	row := s.db.QueryRowContext(ctx, selectSQL("refresh_tokens", refreshTokenColumns)+" WHERE token_hash = $1", tokenHash)
+	if err := scanOne(row, refreshTokenFields(&t)); err != nil {
+		return nil, err
+	}
+	if n == 0 {
+		return &t, ErrTokenReused
+	}
+	return &t, nil
+}
+
+func (s *SQLStore) RevokeTokenFamily(ctx context.Context, familyID string, at time.Time) error {
+	_, err := s.db.ExecContext(ctx, `UPDATE refresh_tokens SET revoked_at = $1
+		WHERE family_id = $2 AND revoked_at IS NULL`, at.UTC(), familyID)
+	return err
+}
+
+func (s *SQLStore) IsTokenFamilyRevoked(ctx context.Context, familyID string) (bool, error) {
+	var revoked bool
+	err := s.db.QueryRowContext(ctx, `SELECT EXISTS (SELECT 1 FROM refresh_tokens
+		WHERE family_id = $1 AND revoked_at IS NOT NULL)`, familyID).Scan(&revoked)
+	return revoked, err
+}
+
+func (s *SQLStore) PurgeRefreshTokens(ctx context.Context, expiredBefore time.Time) (int64, error) {
+	res, err := s.db.ExecContext(ctx, `DELETE FROM refresh_tokens WHERE expires_at < $1`, expiredBefore.UTC())
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
+
+var auditColumns = []string{"entry_id", "timestamp", "actor_id", "action", "entity_type", "entity_id", "changes",
+	"request_id", "source_ip"}
+
+func auditFields(e *models.AuditEntry) []interface{} {
+	return []interface{}{&e.EntryID, &e.Timestamp, &e.ActorID, &e.Action, &e.EntityType, &e.EntityID, jsonText{&e.Changes},
+		&e.RequestID, &e.SourceIP}
+}
+
+func (s *SQLStore) AppendAudit(ctx context.Context, entry *models.AuditEntry) error {
+	// Timestamps are stored in UTC so that SQLite, which compares them as
+	// text, filters them correctly.
+	stored := *entry
+	stored.Timestamp = entry.Timestamp.UTC()
+	_, err := s.db.ExecContext(ctx, insertSQL("audit_log", auditColumns), auditFields(&stored)...)
+	return s.translate(err)
+}
+
+func (s *SQLStore) ListAudit(ctx context.Context, q AuditQuery) ([]models.AuditEntry, int64, error) {
+	var conditions []string
+	var args []interface{}
+	where := func(condition string, arg interface{}) {
+		args = append(args, arg)
+		conditions = append(conditions, condition+" $"+strconv.Itoa(len(args)))
+	}
+	if q.ActorID != "" {
+		where("actor_id =", q.ActorID)
+	}
+	if q.Action != "" {
+		where("action =", q.Action)
+	}
+	if q.EntityType != "" {
+		where("entity_type =", q.EntityType)
+	}
+	if q.EntityID != "" {
+		where("entity_id =", q.EntityID)
+	}
+	if !q.From.IsZero() {
+		where("timestamp >=", q.From.UTC())
+	}
+	if !q.To.IsZero() {
+		where("timestamp <=", q.To.UTC())
+	}
+	clause := ""
+	if len(conditions) > 0 {
+		clause = " WHERE " + strings.Join(conditions, " AND ")
+	}
+
+	var total int64
+	if err := s.db.QueryRowContext(ctx, "SELECT COUNT(*) FROM audit_log"+clause, args...).Scan(&total); err != nil {
+		return nil, 0, err
+	}
+
+	limit := q.Limit
+	if limit <= 0 {
+		limit = math.MaxInt32
+	}
+	args = append(args, limit, q.Offset)
+	query := selectSQL("audit_log", auditColumns) + clause + " ORDER BY timestamp DESC, entry_id DESC" +
+		" LIMIT $" + strconv.Itoa(len(args)-1) + " OFFSET $" + strconv.Itoa(len(args))
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	var entries []models.AuditEntry
+	for rows.Next() {
+		var e models.AuditEntry
+		if err := rows.Scan(auditFields(&e)...); err != nil {
+			return nil, 0, err
+		}
+		entries = append(entries, e)
+	}
+	return entries, total, rows.Err()
+}
+
+var versionColumns = []string{"entity_type", "entity_id", "version", "action", "rolled_back_to", "changed_at", "changed_by", "snapshot"}
+
+func versionFields(v *models.RecordVersion) []interface{} {
+	return []interface{}{&v.EntityType, &v.EntityID, &v.Version, &v.Action, &v.RolledBackTo, &v.ChangedAt, &v.ChangedBy,
+		jsonText{&v.Snapshot}}
+}
+
+func (s *SQLStore) AppendVersion(ctx context.Context, v *models.RecordVersion) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var latest int
+	err = tx.QueryRowContext(ctx, `SELECT COALESCE(MAX(version), 0) FROM record_versions
+		WHERE entity_type = $1 AND entity_id = $2`, v.EntityType, v.EntityID).Scan(&latest)
+	if err != nil {
+		return err
+	}
+	v.Version = latest + 1
+	// The primary key turns a concurrent append of the same number into
+	// ErrConflict.
+	if _, err := tx.ExecContext(ctx, insertSQL("record_versions", versionColumns), versionFields(v)...); err != nil {
+		return s.translate(err)
+	}
+	return tx.Commit()
+}
+
+func (s *SQLStore) ListVersions(ctx context.Context, entityType, entityID string) ([]models.RecordVersion, error) {
+	rows, err := s.db.QueryContext(ctx, selectSQL("record_versions", versionColumns)+
+		" WHERE entity_type = $1 AND entity_id = $2 ORDER BY version", entityType, entityID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var versions []models.RecordVersion
+	for rows.Next() {
+		var v models.RecordVersion
+		if err := rows.Scan(versionFields(&v)...); err != nil {
+			return nil, err
+		}
+		versions = append(versions, v)
+	}
+	return versions, rows.Err()
+}
+
+func (s *SQLStore) GetVersion(ctx context.Context, entityType, entityID string, version int) (*models.RecordVersion, error) {
+	var v models.RecordVersion
+	row := s.db.QueryRowContext(ctx, selectSQL("record_versions", versionColumns)+
+		" WHERE entity_type = $1 AND entity_id = $2 AND version = $3", entityType, entityID, version)
+	if err := scanOne(row, versionFields(&v)); err != nil {
+		return nil, err
+	}
+	return &v, nil
+}
+
+func (s *SQLStore) Search(ctx context.Context, q SearchQuery) ([]SearchHit, error) {
+	terms := SearchTerms(q.Text)
+	if len(terms) == 0 {
+		return nil, nil
+	}
+	var lists [][]SearchHit
+	for _, table := range []struct {
+		entityType, name, key string
+		fields                []searchField
+	}{
+		{models.AuditEntityEmployee, "employees", "emp_id", employeeSearchFields},
+		{models.AuditEntityAsset, "assets", "asset_id", assetSearchFields},
+	} {
+		if !q.searches(table.entityType) {
+			continue
+		}
+		query, args := s.searchSQL(table.name, table.key, table.fields, terms, q.Prefix)
+		if q.Limit > 0 {
+			args = append(args, q.Limit)
+			query += " LIMIT $" + strconv.Itoa(len(args))
+		}
+		hits, err := s.searchTable(ctx, table.entityType, table.fields, query, args)
+		if err != nil {
+			return nil, err
+		}
+		lists = append(lists, hits)
+	}
+	return rankHits(q.Limit, lists...), nil
+}
+
+// searchTable runs a query built by searchSQL.
+func (s *SQLStore) searchTable(ctx context.Context, entityType string, fields []searchField, query string, args []interface{}) ([]SearchHit, error) {
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var hits []SearchHit
+	for rows.Next() {
+		hit := SearchHit{Type: entityType, Fields: make(map[string]string, len(fields))}
+		values := make([]string, len(fields))
+		dest := []interface{}{&hit.ID}
+		for i := range values {
+			dest = append(dest, &values[i])
+		}
+		if err := rows.Scan(append(dest, &hit.Score)...); err != nil {
+			return nil, err
+		}
+		for i, field := range fields {
+			hit.Fields[field.name] = values[i]
+		}
+		hits = append(hits, hit)
+	}
+	return hits, rows.Err()
+}
+
+// within adds the conditions limiting column to r.
+func (f *sqlFilter) within(column string, r ReportRange) {
+	if !r.From.IsZero() {
+		f.where(column+" >= ?", r.From.UTC())
+	}
+	if !r.To.IsZero() {
+		f.where(column+" <= ?", r.To.UTC())
+	}
+}
+
+func (s *SQLStore) AssetStatusCounts(ctx context.Context, r ReportRange) ([]models.AssetStatusCount, error) {
+	var f sqlFilter
+	f.where("a.deleted_at IS NULL")
+	f.within("a.created_at", r)
+	rows, err := s.db.QueryContext(ctx, `SELECT asset_type, assigned, COALESCE(latest, ''), COUNT(*)
+		FROM (SELECT a.asset_type,
+				EXISTS (SELECT 1 FROM mappings m WHERE m.asset_id = a.asset_id AND m.status = '`+models.MappingStatusActive+`') AS assigned,
+				(SELECT m.status FROM mappings m WHERE m.asset_id = a.asset_id
+					ORDER BY m.assigned_date DESC, m.mapping_id DESC LIMIT 1) AS latest
+			FROM assets a`+f.clause()+`) s
+		GROUP BY asset_type, assigned, latest`, f.args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	// Several latest statuses fold into one report status, so merge them.
+	type key struct{ assetType, status string }
+	counts := make(map[key]int64)
+	var result []models.AssetStatusCount
+	for rows.Next() {
+		var assetType, latest string
+		var assigned bool
+		var n int64
+		if err := rows.Scan(&assetType, &assigned, &latest, &n); err != nil {
+			return nil, err
+		}
+		k := key{assetType, assetStatus(assigned, latest)}
+		if _, ok := counts[k]; !ok {
+			result = append(result, models.AssetStatusCount{AssetType: k.assetType, Status: k.status})
+		}
+		counts[k] += n
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	for i := range result {
+		result[i].Count = counts[key{result[i].AssetType, result[i].Status}]
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].AssetType != result[j].AssetType {
+			return result[i].AssetType < result[j].AssetType
+		}
+		return result[i].Status < result[j].Status
+	})
+	return result, nil
+}
+
+func (s *SQLStore) DepartmentAssignments(ctx context.Context, r ReportRange) ([]models.DepartmentAssignments, error) {
+	var f sqlFilter
+	f.within("m.assigned_date", r)
+	rows, err := s.db.QueryContext(ctx, `SELECT COALESCE(e.department, '') AS department, COUNT(*),
+			SUM(CASE WHEN m.status = '`+models.MappingStatusActive+`' THEN 1 ELSE 0 END)
+		FROM mappings m
+		LEFT JOIN employees e ON e.emp_id = m.employee_id`+f.clause()+`
+		GROUP BY COALESCE(e.department, '')
+		ORDER BY department`, f.args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var result []models.DepartmentAssignments
+	for rows.Next() {
+		var d models.DepartmentAssignments
+		if err := rows.Scan(&d.Department, &d.Assignments, &d.Active); err != nil {
+			return nil, err
+		}
+		result = append(result, d)
+	}
+	return result, rows.Err()
+}
+
+// AssignmentDuration sums the durations in Go, since SQLite keeps times as
+// text it cannot subtract.
+func (s *SQLStore) AssignmentDuration(ctx context.Context, r ReportRange) (models.AssignmentDuration, error) {
+	var f sqlFilter
+	f.where("status <> '" + models.MappingStatusActive + "'")
+	f.where("returned_date IS NOT NULL")
+	f.within("assigned_date", r)
+	rows, err := s.db.QueryContext(ctx, "SELECT assigned_date, returned_date FROM mappings"+f.clause(), f.args...)
+	if err != nil {
+		return models.AssignmentDuration{}, err
+	}
+	defer rows.Close()
+
+	var d durationSum
+	for rows.Next() {
+		var assigned, returned time.Time
+		if err := rows.Scan(&assigned, &returned); err != nil {
+			return models.AssignmentDuration{}, err
+		}
+		d.add(assigned, returned)
+	}
+	return d.report(), rows.Err()
+}
+
+func (s *SQLStore) TopHolders(ctx context.Context, r ReportRange, limit int) ([]models.AssetHolder, error) {
+	var f sqlFilter
+	f.where("m.status = '" + models.MappingStatusActive + "'")
+	f.within("m.assigned_date", r)
+	query := `SELECT m.employee_id, COALESCE(e.first_name, ''), COALESCE(e.last_name, ''), COALESCE(e.department, ''), COUNT(*)
+		FROM mappings m
+		LEFT JOIN employees e ON e.emp_id = m.employee_id` + f.clause() + `
+		GROUP BY m.employee_id, e.first_name, e.last_name, e.department
+		ORDER BY COUNT(*) DESC, m.employee_id`
+	if limit > 0 {
+		f.args = append(f.args, limit)
+		query += " LIMIT $" + strconv.Itoa(len(f.args))
+	}
+	rows, err := s.db.QueryContext(ctx, query, f.args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var holders []models.AssetHolder
+	for rows.Next() {
+		var h models.AssetHolder
+		if err := rows.Scan(&h.EmployeeID, &h.FirstName, &h.LastName, &h.Department, &h.ActiveAssets); err != nil {
+			return nil, err
+		}
+		holders = append(holders, h)
+	}
+	return holders, rows.Err()
+}
@@ -0,0 +1,81 @@
+package db
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"employee-asset-system/models"
+)
+
+// openTestSQLite returns an SQLStore on a new SQLite database that is closed
+// when the test ends.
+func openTestSQLite(t *testing.T) *SQLStore {
+	t.Helper()
+	s, err := OpenSQLite(context.Background(), filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatalf("OpenSQLite: %v", err)
+	}
+	t.Cleanup(func() { s.db.Close() })
+	return s
+}
+
+// inZone runs the rest of the test with time.Local set to the named zone.
+func inZone(t *testing.T, name string) {
+	t.Helper()
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		t.Skipf("time zone %s: %v", name, err)
+	}
+	local := time.Local
+	time.Local = loc
+	t.Cleanup(func() { time.Local = local })
+}
+
+func TestSQLiteRefreshTokensOutsideUTC(t *testing.T) {
+	inZone(t, "America/New_York")
+	ctx := context.Background()
+	s := openTestSQLite(t)
+	now := time.Now()
+	if err := s.CreateEmployee(ctx, &models.Employee{EmpID: "e1", FirstName: "Ada", CreatedAt: now, UpdatedAt: now}); err != nil {
+		t.Fatalf("CreateEmployee: %v", err)
+	}
+
+	tokens := []*models.RefreshToken{
+		{TokenHash: "live", FamilyID: "f1", EmpID: "e1", CreatedAt: now, ExpiresAt: now.Add(time.Hour)},
+		{TokenHash: "expired", FamilyID: "f2", EmpID: "e1", CreatedAt: now.Add(-2 * time.Hour), ExpiresAt: now.Add(-time.Hour)},
+	}
+	for _, token := range tokens {
+		if err := s.CreateRefreshToken(ctx, token); err != nil {
+			t.Fatalf("CreateRefreshToken: %v", err)
+		}
+	}
+	used, err := s.UseRefreshToken(ctx, "live", now)
+	if err != nil {
+		t.Fatalf("UseRefreshToken: %v", err)
+	}
+	if used.UsedAt == nil || !used.UsedAt.Equal(now) {
+		t.Errorf("used_at = %v, want %v", used.UsedAt, now)
+	}
+	if err := s.RevokeTokenFamily(ctx, "f1", now); err != nil {
+		t.Fatalf("RevokeTokenFamily: %v", err)
+	}
+
+	// Only the expired token may go: purging the revoked one would let its
+	// session be used again.
+	n, err := s.PurgeRefreshTokens(ctx, now)
+	if err != nil {
+		t.Fatalf("PurgeRefreshTokens: %v", err)
+	}
+	if n != 1 {
+		t.Errorf("purged %d tokens, want 1", n)
+	}
+	revoked, err := s.IsTokenFamilyRevoked(ctx, "f1")
+	if err != nil {
+		t.Fatalf("IsTokenFamilyRevoked: %v", err)
+	}
+	if !revoked {
+		t.Error("family f1 is no longer revoked after the purge")
+	}
+}
@@ -0,0 +1,82 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"embed"
+	"errors"
+	"log"
+	"strconv"
+	"strings"
+
+	"modernc.org/sqlite"
+	sqlite3 "modernc.org/sqlite/lib"
+)
+
+//go:embed migrations/sqlite/*.sql
+var sqliteMigrations embed.FS
+
+// OpenSQLite opens (creating if necessary) the SQLite database file at path
+// and brings its schema up to date. Foreign keys are switched on for every
+// connection and WAL mode lets readers proceed while a write is in progress.
+// Transactions take the write lock when they begin: one that read first
+// could otherwise not upgrade once another write committed, and would fail
+// with SQLITE_BUSY instead of waiting.
+func OpenSQLite(ctx context.Context, path string) (*SQLStore, error) {
+	dsn := "file:" + path + "?_pragma=foreign_keys(1)&_pragma=journal_mode(WAL)&_pragma=busy_timeout(5000)&_txlock=immediate"
+	conn, err := sql.Open("sqlite", dsn)
+	if err != nil {
+		return nil, err
+	}
+	if err := conn.PingContext(ctx); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	if err := migrate(ctx, conn, sqliteMigrations, "migrations/sqlite"); err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	log.Printf("Using SQLite database %s", path)
+	return &SQLStore{db: conn, translate: sqliteError, searchSQL: sqliteSearchSQL}, nil
+}
+
+// sqliteError translates constraint violations into ErrConflict so callers do
+// not need to know about driver error codes.
+func sqliteError(err error) error {
+	var sqliteErr *sqlite.Error
+	if errors.As(err, &sqliteErr) {
+		switch sqliteErr.Code() {
+		case sqlite3.SQLITE_CONSTRAINT_FOREIGNKEY:
+			return &constraintError{msg: sqliteErr.Error()}
+		case sqlite3.SQLITE_CONSTRAINT_UNIQUE, sqlite3.SQLITE_CONSTRAINT_PRIMARYKEY:
+			return &constraintError{unique: true, msg: sqliteErr.Error()}
+		}
+	}
+	return err
+}
+
+// sqliteSearchSQL searches the FTS5 table <table>_fts that triggers keep in
+// step with table, ranking with bm25 using the weights of fields.
+func sqliteSearchSQL(table, key string, fields []searchField, terms []string, prefix bool) (string, []interface{}) {
+	fts := table + "_fts"
+	columns := make([]string, len(fields))
+	weights := make([]string, len(fields))
+	for i, field := range fields {
+		columns[i] = "t." + field.name
+		weights[i] = strconv.FormatFloat(field.weight, 'f', -1, 64)
+	}
+	// bm25 is lower for better matches.
+	rank := "bm25(" + fts + ", " + strings.Join(weights, ", ") + ")"
+	match := make([]string, len(terms))
+	for i, term := range terms {
+		match[i] = `"` + term + `"`
+		if prefix {
+			match[i] += "*"
+		}
+	}
+	return `SELECT t.` + key + `, ` + strings.Join(columns, ", ") + `, -` + rank + `
+		FROM ` + fts + ` JOIN ` + table + ` t ON t.rowid = ` + fts + `.rowid
+		WHERE ` + fts + ` MATCH $1 AND t.deleted_at IS NULL
+		ORDER BY ` + rank + `, t.` + key, []interface{}{strings.Join(match, " ")}
+}
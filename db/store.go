@@ -0,0 +1,244 @@
+package db
+
+import (
+	"context"
+	"employee-asset-system/models"
+	"errors"
+	"fmt"
+	"time"
+)
+
+var (
+	// ErrNotFound is returned by a store when the requested record does not exist.
+	ErrNotFound = errors.New("record not found")
+	// ErrConflict is returned when a write would break a uniqueness or
+	// referential constraint enforced by the backend.
+	ErrConflict = errors.New("record conflicts with existing data")
+	// ErrTokenReused is returned by UseRefreshToken when the token has
+	// already been exchanged once.
+	ErrTokenReused = errors.New("refresh token already used")
+	// ErrMappingClosed is returned when returning a mapping that is no longer
+	// active. It wraps ErrConflict.
+	ErrMappingClosed = fmt.Errorf("%w: asset mapping is not active", ErrConflict)
+	// ErrAssetAssigned is returned by CreateMapping when an exclusive asset
+	// already has an active mapping. It wraps ErrConflict.
+	ErrAssetAssigned = fmt.Errorf("%w: asset is already assigned", ErrConflict)
+	// ErrActiveMappings is returned when deleting an employee or asset that
+	// still has active mappings. It wraps ErrConflict.
+	ErrActiveMappings = fmt.Errorf("%w: active asset mappings exist", ErrConflict)
+	// ErrStaleVersion is returned by conditional writes when the stored
+	// record has moved past the version the caller read. It wraps
+	// ErrConflict.
+	ErrStaleVersion = fmt.Errorf("%w: record was modified concurrently", ErrConflict)
+	// ErrNoTransactions is returned by writes that must be all or nothing,
+	// such as an atomic batch of ApplyMappingChanges, when the backend cannot
+	// run them as a transaction.
+	ErrNoTransactions = errors.New("the database does not support transactions")
+)
+
+// EmployeeStore persists employees and serves the login and dashboard lookups.
+// Deleted employees are kept as tombstones that every other method ignores,
+// until they are restored or purged.
+//
+// Records carry a version that every write increments. Updates are
+// conditional on it: they only apply while the stored record is still at
+// the version of the record passed in, and set the record's version to the
+// new one. Otherwise they fail with ErrStaleVersion. The same holds for
+// assets and mappings.
+type EmployeeStore interface {
+	CreateEmployee(ctx context.Context, employee *models.Employee) error
+	// CreateEmployees adds every one of employees or, when any of them
+	// fails, none. A backend without transactions refuses it with
+	// ErrNoTransactions.
+	CreateEmployees(ctx context.Context, employees []models.Employee) error
+	GetEmployee(ctx context.Context, empID string) (*models.Employee, error)
+	// FindEmployeeByIdentifier looks an employee up by phone number or email.
+	FindEmployeeByIdentifier(ctx context.Context, identifier string) (*models.Employee, error)
+	// EmployeesByIdentifier returns the employees whose email, ignoring
+	// case, is one of emails or whose phone number is one of phones. emails
+	// must be lowercase.
+	EmployeesByIdentifier(ctx context.Context, emails, phones []string) ([]models.Employee, error)
+	UpdateEmployee(ctx context.Context, employee *models.Employee) error
+	// DeleteEmployee tombstones an employee. It fails with ErrActiveMappings
+	// while the employee holds assets, unless del.Cascade is set: the active
+	// mappings are then closed with it in the same operation. It fails with
+	// ErrStaleVersion when del.IfVersion is set and no longer matches.
+	DeleteEmployee(ctx context.Context, empID string, del models.Deletion) error
+	// RestoreEmployee undoes DeleteEmployee. It returns ErrNotFound unless
+	// the employee is deleted. Mappings closed by a cascade stay closed.
+	RestoreEmployee(ctx context.Context, empID string) error
+	// PurgeEmployees permanently removes employees deleted before the given
+	// time and returns how many there were. Employees that still have
+	// mappings are kept, so the assignment history never dangles.
+	PurgeEmployees(ctx context.Context, deletedBefore time.Time) (int64, error)
+	// Dashboard lists the employees selected by q together with their asset
+	// count, along with how many employees q selects in total.
+	Dashboard(ctx context.Context, q EmployeeQuery) ([]models.DashboardEmployee, int64, error)
+}
+
+// AssetStore persists assets. Deleted assets are tombstoned like employees.
+type AssetStore interface {
+	CreateAsset(ctx context.Context, asset *models.Asset) error
+	// CreateAssets adds every one of assets or, when any of them fails,
+	// none, like CreateEmployees. Serial numbers and asset tags already in
+	// use fail with ErrConflict.
+	CreateAssets(ctx context.Context, assets []models.Asset) error
+	// AssetsBySerial returns the assets, deleted ones included, whose serial
+	// number is one of serials.
+	AssetsBySerial(ctx context.Context, serials []string) ([]models.Asset, error)
+	GetAsset(ctx context.Context, assetID string) (*models.Asset, error)
+	// ListAssets returns the assets selected by q along with how many assets
+	// q selects in total.
+	ListAssets(ctx context.Context, q AssetQuery) ([]models.Asset, int64, error)
+	UpdateAsset(ctx context.Context, asset *models.Asset) error
+	// DeleteAsset, RestoreAsset and PurgeAssets behave like their employee
+	// counterparts.
+	DeleteAsset(ctx context.Context, assetID string, del models.Deletion) error
+	RestoreAsset(ctx context.Context, assetID string) error
+	PurgeAssets(ctx context.Context, deletedBefore time.Time) (int64, error)
+}
+
+// MappingStore persists employee to asset assignments. Mappings are never
+// deleted; returning an asset closes its mapping so the history remains.
+type MappingStore interface {
+	// CreateMapping fails with ErrAssetAssigned when mapping is exclusive
+	// and its asset already has an active exclusive mapping. The check is
+	// enforced by the backend so concurrent checkouts cannot both succeed.
+	// It fails with ErrNotFound when the employee or asset does not exist or
+	// is deleted, also when they are deleted concurrently.
+	CreateMapping(ctx context.Context, mapping *models.EmployeeAssetMapping) error
+	GetMapping(ctx context.Context, mappingID string) (*models.EmployeeAssetMapping, error)
+	// ListMappingsByEmployee and ListMappingsByAsset return mappings oldest
+	// first, limited to the given status unless it is empty.
+	ListMappingsByEmployee(ctx context.Context, empID, status string) ([]models.EmployeeAssetMapping, error)
+	ListMappingsByAsset(ctx context.Context, assetID, status string) ([]models.EmployeeAssetMapping, error)
+	// ListMappings returns the mappings selected by q along with how many
+	// mappings q selects in total.
+	ListMappings(ctx context.Context, q MappingQuery) ([]models.EmployeeAssetMapping, int64, error)
+	// ReturnMapping closes an active mapping and returns the updated record.
+	// It fails with ErrConflict when the mapping is no longer active, and with
+	// ErrStaleVersion when ret.IfVersion is set and no longer matches.
+	ReturnMapping(ctx context.Context, mappingID string, ret models.MappingReturn) (*models.EmployeeAssetMapping, error)
+	// UpdateMapping saves the notes, return condition and close reason of
+	// mapping. Since returning a mapping bumps its version, an edit cannot
+	// race a return.
+	UpdateMapping(ctx context.Context, mapping *models.EmployeeAssetMapping) error
+	// ApplyMappingChanges applies changes in order, setting the Result or Err
+	// of each one; a failed change leaves the others in place. When atomic,
+	// the batch is one transaction and nothing is kept unless every change
+	// succeeds. A backend may then stop at the first failure, leaving the
+	// changes after it with neither, and one without transactions refuses
+	// the batch with ErrNoTransactions. The returned error is for failures of
+	// the batch as a whole.
+	ApplyMappingChanges(ctx context.Context, changes []MappingChange, atomic bool) error
+}
+
+// MappingChange is one item of a batch of mapping writes: it assigns Assign,
+// as CreateMapping would, when that is set and otherwise closes the active
+// mapping MappingID with Return.
+type MappingChange struct {
+	Assign    *models.EmployeeAssetMapping
+	MappingID string
+	Return    models.MappingReturn
+
+	Result *models.EmployeeAssetMapping // the mapping written
+	Err    error                        // why the change failed
+}
+
+// failed reports whether any of changes failed.
+func failed(changes []MappingChange) bool {
+	for i := range changes {
+		if changes[i].Err != nil {
+			return true
+		}
+	}
+	return false
+}
+
+// TokenStore persists refresh tokens and the login sessions they belong to.
+type TokenStore interface {
+	CreateRefreshToken(ctx context.Context, token *models.RefreshToken) error
+	// UseRefreshToken atomically marks the token with the given hash as used
+	// and returns it. A token that was already used is returned together
+	// with ErrTokenReused so the caller can revoke its family.
+	UseRefreshToken(ctx context.Context, tokenHash string, at time.Time) (*models.RefreshToken, error)
+	RevokeTokenFamily(ctx context.Context, familyID string, at time.Time) error
+	IsTokenFamilyRevoked(ctx context.Context, familyID string) (bool, error)
+	// PurgeRefreshTokens removes the tokens that expired before the given
+	// time and returns how many there were. Access tokens never outlive the
+	// refresh token they were issued with, so a purged family no longer
+	// needs to be reported as revoked.
+	PurgeRefreshTokens(ctx context.Context, expiredBefore time.Time) (int64, error)
+}
+
+// AuditQuery selects audit log entries. Zero fields do not filter; From and
+// To bound the timestamp inclusively.
+type AuditQuery struct {
+	ActorID    string
+	Action     string
+	EntityType string
+	EntityID   string
+	From       time.Time
+	To         time.Time
+
+	Offset int
+	Limit  int // 0 means no limit
+}
+
+// AuditStore is the append-only audit log. There is deliberately no way to
+// change or remove an entry; the SQL backends also reject it in the schema.
+type AuditStore interface {
+	AppendAudit(ctx context.Context, entry *models.AuditEntry) error
+	// ListAudit returns a page of the entries matching q, newest first,
+	// together with the number of entries matching q in total.
+	ListAudit(ctx context.Context, q AuditQuery) ([]models.AuditEntry, int64, error)
+}
+
+// VersionStore keeps a snapshot of every revision of employees and assets.
+type VersionStore interface {
+	// AppendVersion stores v as the next version of its record and sets
+	// v.Version accordingly. A concurrent append for the same record may
+	// fail with ErrConflict.
+	AppendVersion(ctx context.Context, v *models.RecordVersion) error
+	// ListVersions returns every version of a record, oldest first.
+	ListVersions(ctx context.Context, entityType, entityID string) ([]models.RecordVersion, error)
+	GetVersion(ctx context.Context, entityType, entityID string, version int) (*models.RecordVersion, error)
+}
+
+// Store bundles every store the API server needs. Each backend implements
+// all of them on a single type.
+type Store interface {
+	EmployeeStore
+	AssetStore
+	MappingStore
+	TokenStore
+	AuditStore
+	VersionStore
+	SearchStore
+	ReportStore
+}
+
+// applyReturn copies ret onto mapping, closing it.
+func applyReturn(mapping *models.EmployeeAssetMapping, ret models.MappingReturn) {
+	returned := ret.ReturnedDate
+	mapping.Status = ret.Status
+	mapping.ReturnedDate = &returned
+	mapping.ReturnedBy = ret.ReturnedBy
+	mapping.ReturnCondition = ret.Condition
+	mapping.CloseReason = ret.Reason
+}
+
+// addAssigned counts n assets of assetType, costing cost in currency
+// altogether, among the assets currently assigned to e.
+func addAssigned(e *models.DashboardEmployee, assetType, currency string, n int, cost float64) {
+	if e.AssetsByType == nil {
+		e.AssetsByType = make(map[string]int)
+	}
+	e.AssetsByType[assetType] += n
+	if cost != 0 {
+		if e.AssignedValue == nil {
+			e.AssignedValue = make(map[string]float64)
+		}
+		e.AssignedValue[currency] += cost
+	}
+}
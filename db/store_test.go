@@ -0,0 +1,293 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"employee-asset-system/models"
+)
+
+// testStores returns a MemoryStore and an SQLite SQLStore, each holding
+// employees e1 and e2, the asset a1 and the shared asset s1.
+func testStores(t *testing.T) map[string]Store {
+	t.Helper()
+	stores := map[string]Store{"memory": NewMemoryStore(), "sqlite": openTestSQLite(t)}
+	ctx := context.Background()
+	now := time.Now()
+	for _, s := range stores {
+		for _, id := range []string{"e1", "e2"} {
+			if err := s.CreateEmployee(ctx, &models.Employee{EmpID: id, FirstName: id, CreatedAt: now, UpdatedAt: now}); err != nil {
+				t.Fatalf("CreateEmployee: %v", err)
+			}
+		}
+		for _, id := range []string{"a1", "s1"} {
+			asset := &models.Asset{AssetID: id, AssetName: id, Shared: id == "s1", CreatedAt: now, UpdatedAt: now}
+			if err := s.CreateAsset(ctx, asset); err != nil {
+				t.Fatalf("CreateAsset: %v", err)
+			}
+		}
+	}
+	return stores
+}
+
+func TestStoreVersionedUpdates(t *testing.T) {
+	ctx := context.Background()
+	for name, s := range testStores(t) {
+		t.Run(name, func(t *testing.T) {
+			e, err := s.GetEmployee(ctx, "e1")
+			if err != nil {
+				t.Fatalf("GetEmployee: %v", err)
+			}
+			if e.Version != 1 {
+				t.Fatalf("new employee at version %d, want 1", e.Version)
+			}
+			stale := *e
+
+			e.FirstName = "Ada"
+			if err := s.UpdateEmployee(ctx, e); err != nil {
+				t.Fatalf("UpdateEmployee: %v", err)
+			}
+			if e.Version != 2 {
+				t.Errorf("version after update = %d, want 2", e.Version)
+			}
+			stale.FirstName = "Grace"
+			if err := s.UpdateEmployee(ctx, &stale); !errors.Is(err, ErrStaleVersion) {
+				t.Errorf("update of version 1: err = %v, want ErrStaleVersion", err)
+			}
+			missing := models.Employee{EmpID: "e9", Version: 1}
+			if err := s.UpdateEmployee(ctx, &missing); !errors.Is(err, ErrNotFound) {
+				t.Errorf("update of a missing employee: err = %v, want ErrNotFound", err)
+			}
+
+			got, err := s.GetEmployee(ctx, "e1")
+			if err != nil {
+				t.Fatalf("GetEmployee: %v", err)
+			}
+			if got.FirstName != "Ada" || got.Version != 2 {
+				t.Errorf("stored %q at version %d, want \"Ada\" at 2", got.FirstName, got.Version)
+			}
+		})
+	}
+}
+
+func TestStoreConcurrentUpdates(t *testing.T) {
+	const writers = 8
+	ctx := context.Background()
+	for name, s := range testStores(t) {
+		t.Run(name, func(t *testing.T) {
+			read, err := s.GetAsset(ctx, "a1")
+			if err != nil {
+				t.Fatalf("GetAsset: %v", err)
+			}
+
+			// Every writer read the same version, so exactly one of them
+			// may win; the others must see ErrStaleVersion rather than
+			// silently overwrite it.
+			var wg sync.WaitGroup
+			errs := make([]error, writers)
+			for i := range errs {
+				wg.Add(1)
+				go func(i int) {
+					defer wg.Done()
+					a := *read
+					a.AssetName = string(rune('A' + i))
+					errs[i] = s.UpdateAsset(ctx, &a)
+				}(i)
+			}
+			wg.Wait()
+
+			won := 0
+			for _, err := range errs {
+				switch {
+				case err == nil:
+					won++
+				case !errors.Is(err, ErrStaleVersion):
+					t.Errorf("UpdateAsset: err = %v, want nil or ErrStaleVersion", err)
+				}
+			}
+			if won != 1 {
+				t.Errorf("%d concurrent updates applied, want 1", won)
+			}
+			got, err := s.GetAsset(ctx, "a1")
+			if err != nil {
+				t.Fatalf("GetAsset: %v", err)
+			}
+			if got.Version != 2 {
+				t.Errorf("version = %d, want 2", got.Version)
+			}
+		})
+	}
+}
+
+func TestStoreSoftDeleteAndRestore(t *testing.T) {
+	ctx := context.Background()
+	for name, s := range testStores(t) {
+		t.Run(name, func(t *testing.T) {
+			if err := s.RestoreEmployee(ctx, "e1"); !errors.Is(err, ErrNotFound) {
+				t.Errorf("restoring a live employee: err = %v, want ErrNotFound", err)
+			}
+			if err := s.DeleteEmployee(ctx, "e1", models.Deletion{DeletedAt: time.Now(), DeletedBy: "e2"}); err != nil {
+				t.Fatalf("DeleteEmployee: %v", err)
+			}
+			if _, err := s.GetEmployee(ctx, "e1"); !errors.Is(err, ErrNotFound) {
+				t.Errorf("GetEmployee of a deleted employee: err = %v, want ErrNotFound", err)
+			}
+			if err := s.DeleteEmployee(ctx, "e1", models.Deletion{DeletedAt: time.Now()}); !errors.Is(err, ErrNotFound) {
+				t.Errorf("deleting twice: err = %v, want ErrNotFound", err)
+			}
+
+			if err := s.RestoreEmployee(ctx, "e1"); err != nil {
+				t.Fatalf("RestoreEmployee: %v", err)
+			}
+			e, err := s.GetEmployee(ctx, "e1")
+			if err != nil {
+				t.Fatalf("GetEmployee after restoring: %v", err)
+			}
+			if e.DeletedAt != nil || e.DeletedBy != "" {
+				t.Errorf("restored employee still marked deleted at %v by %q", e.DeletedAt, e.DeletedBy)
+			}
+		})
+	}
+}
+
+func TestStorePurge(t *testing.T) {
+	ctx := context.Background()
+	for name, s := range testStores(t) {
+		t.Run(name, func(t *testing.T) {
+			// e2 keeps a closed mapping of a1, so neither may be purged.
+			m := &models.EmployeeAssetMapping{MappingID: "m1", EmployeeID: "e2", AssetID: "a1",
+				AssignedDate: time.Now(), Status: models.MappingStatusActive, Exclusive: true}
+			if err := s.CreateMapping(ctx, m); err != nil {
+				t.Fatalf("CreateMapping: %v", err)
+			}
+			ret := models.MappingReturn{Status: models.MappingStatusReturned, ReturnedDate: time.Now()}
+			if _, err := s.ReturnMapping(ctx, "m1", ret); err != nil {
+				t.Fatalf("ReturnMapping: %v", err)
+			}
+
+			deleted := models.Deletion{DeletedAt: time.Now().Add(-time.Hour)}
+			for _, id := range []string{"e1", "e2"} {
+				if err := s.DeleteEmployee(ctx, id, deleted); err != nil {
+					t.Fatalf("DeleteEmployee: %v", err)
+				}
+			}
+			for _, id := range []string{"a1", "s1"} {
+				if err := s.DeleteAsset(ctx, id, deleted); err != nil {
+					t.Fatalf("DeleteAsset: %v", err)
+				}
+			}
+
+			if n, err := s.PurgeEmployees(ctx, time.Now().Add(-2*time.Hour)); err != nil || n != 0 {
+				t.Errorf("purging before the deletions: %d purged, err = %v, want 0", n, err)
+			}
+			if n, err := s.PurgeEmployees(ctx, time.Now()); err != nil || n != 1 {
+				t.Errorf("PurgeEmployees: %d purged, err = %v, want 1", n, err)
+			}
+			if n, err := s.PurgeAssets(ctx, time.Now()); err != nil || n != 1 {
+				t.Errorf("PurgeAssets: %d purged, err = %v, want 1", n, err)
+			}
+
+			if err := s.RestoreEmployee(ctx, "e1"); !errors.Is(err, ErrNotFound) {
+				t.Errorf("restoring a purged employee: err = %v, want ErrNotFound", err)
+			}
+			if err := s.RestoreEmployee(ctx, "e2"); err != nil {
+				t.Errorf("restoring an employee with mappings: %v", err)
+			}
+			if err := s.RestoreAsset(ctx, "a1"); err != nil {
+				t.Errorf("restoring an asset with mappings: %v", err)
+			}
+		})
+	}
+}
+
+func TestStoreDeleteWithActiveMappings(t *testing.T) {
+	ctx := context.Background()
+	for name, s := range testStores(t) {
+		t.Run(name, func(t *testing.T) {
+			m := &models.EmployeeAssetMapping{MappingID: "m1", EmployeeID: "e1", AssetID: "a1",
+				AssignedDate: time.Now(), Status: models.MappingStatusActive, Exclusive: true}
+			if err := s.CreateMapping(ctx, m); err != nil {
+				t.Fatalf("CreateMapping: %v", err)
+			}
+
+			if err := s.DeleteEmployee(ctx, "e1", models.Deletion{DeletedAt: time.Now()}); !errors.Is(err, ErrActiveMappings) {
+				t.Fatalf("DeleteEmployee: err = %v, want ErrActiveMappings", err)
+			}
+			if _, err := s.GetEmployee(ctx, "e1"); err != nil {
+				t.Errorf("refused delete left the employee deleted: %v", err)
+			}
+
+			cascade := &models.MappingReturn{Status: models.MappingStatusReturned, ReturnedDate: time.Now(), Reason: "left"}
+			if err := s.DeleteEmployee(ctx, "e1", models.Deletion{DeletedAt: time.Now(), Cascade: cascade}); err != nil {
+				t.Fatalf("DeleteEmployee with cascade: %v", err)
+			}
+			closed, err := s.GetMapping(ctx, "m1")
+			if err != nil {
+				t.Fatalf("GetMapping: %v", err)
+			}
+			if closed.Status != models.MappingStatusReturned || closed.CloseReason != "left" {
+				t.Errorf("cascaded mapping is %s closed for %q, want returned for \"left\"", closed.Status, closed.CloseReason)
+			}
+
+			for _, m := range []models.EmployeeAssetMapping{
+				{MappingID: "m2", EmployeeID: "e1", AssetID: "s1"},
+				{MappingID: "m3", EmployeeID: "e2", AssetID: "a9"},
+			} {
+				m.AssignedDate, m.Status = time.Now(), models.MappingStatusActive
+				if err := s.CreateMapping(ctx, &m); !errors.Is(err, ErrNotFound) {
+					t.Errorf("mapping %s to %s: err = %v, want ErrNotFound", m.EmployeeID, m.AssetID, err)
+				}
+			}
+		})
+	}
+}
+
+func TestStoreDeleteRacesCheckout(t *testing.T) {
+	const rounds = 20
+	ctx := context.Background()
+	for name, s := range testStores(t) {
+		t.Run(name, func(t *testing.T) {
+			now := time.Now()
+			for i := 0; i < rounds; i++ {
+				id := fmt.Sprintf("r%d", i)
+				if err := s.CreateEmployee(ctx, &models.Employee{EmpID: id, CreatedAt: now, UpdatedAt: now}); err != nil {
+					t.Fatalf("CreateEmployee: %v", err)
+				}
+
+				var wg sync.WaitGroup
+				var deleteErr, assignErr error
+				wg.Add(2)
+				go func() {
+					defer wg.Done()
+					deleteErr = s.DeleteEmployee(ctx, id, models.Deletion{DeletedAt: time.Now()})
+				}()
+				go func() {
+					defer wg.Done()
+					assignErr = s.CreateMapping(ctx, &models.EmployeeAssetMapping{MappingID: "m-" + id, EmployeeID: id,
+						AssetID: "s1", AssignedDate: time.Now(), Status: models.MappingStatusActive})
+				}()
+				wg.Wait()
+
+				// Exactly one of them wins.
+				switch {
+				case deleteErr == nil && errors.Is(assignErr, ErrNotFound):
+				case assignErr == nil && errors.Is(deleteErr, ErrActiveMappings):
+				default:
+					t.Fatalf("round %d: delete err = %v, checkout err = %v", i, deleteErr, assignErr)
+				}
+				_, getErr := s.GetEmployee(ctx, id)
+				active, err := s.ListMappingsByEmployee(ctx, id, models.MappingStatusActive)
+				if err != nil {
+					t.Fatalf("ListMappingsByEmployee: %v", err)
+				}
+				if getErr != nil && len(active) > 0 {
+					t.Fatalf("round %d: deleted employee keeps %d active mappings", i, len(active))
+				}
+			}
+		})
+	}
+}
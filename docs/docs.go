@@ -15,9 +15,58 @@ const docTemplate = `{
     "host": "{{.Host}}",
     "basePath": "{{.BasePath}}",
     "paths": {
+        "/.well-known/jwks.json": {
+            "get": {
+                "description": "Publishes the public halves of the RS256/ES256 token signing keys as a JSON Web Key Set so other services can verify access tokens",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Login"
+                ],
+                "summary": "Public signing keys",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/middleware.JWKS"
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/purge": {
+            "post": {
+                "description": "Permanently removes employees and assets that were deleted longer ago than the configured retention period. Records that asset mappings still reference are kept so that the assignment history stays complete. Expired refresh tokens are removed as well.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Maintenance"
+                ],
+                "summary": "Purge deleted records",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.PurgeResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
         "/asset-mapping": {
             "post": {
-                "description": "Assigns a new asset to an employee",
+                "description": "Checks an asset out to an employee by creating an active mapping. Unless the asset is shared, it must not already be checked out. The assignment date defaults to now and may not lie in the future.",
                 "consumes": [
                     "application/json"
                 ],
@@ -30,12 +79,12 @@ const docTemplate = `{
                 "summary": "Assign an asset to an employee",
                 "parameters": [
                     {
-                        "description": "Asset mapping data",
+                        "description": "Employee and asset to map",
                         "name": "mapping",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/models.EmployeeAssetMapping"
+                            "$ref": "#/definitions/controllers.AssignRequest"
                         }
                     }
                 ],
@@ -47,6 +96,12 @@ const docTemplate = `{
                             "additionalProperties": {
                                 "type": "string"
                             }
+                        },
+                        "headers": {
+                            "ETag": {
+                                "type": "string",
+                                "description": "Version of the mapping"
+                            }
                         }
                     },
                     "400": {
@@ -58,6 +113,15 @@ const docTemplate = `{
                             }
                         }
                     },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
@@ -72,7 +136,7 @@ const docTemplate = `{
         },
         "/asset-mapping/employee/{employeeId}": {
             "get": {
-                "description": "Fetches all asset mappings for a specific employee",
+                "description": "Lists the asset mappings of an employee a page at a time, including returned ones unless filtered by status. Pass the next_page_token of a page as page_token, with the same sort, to fetch the one after it.",
                 "produces": [
                     "application/json"
                 ],
@@ -87,15 +151,57 @@ const docTemplate = `{
                         "name": "employeeId",
                         "in": "path",
                         "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Only mappings with this status (active, returned, lost, damaged)",
+                        "name": "status",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Only mappings assigned after this RFC 3339 time",
+                        "name": "assigned_after",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Only mappings assigned before this RFC 3339 time",
+                        "name": "assigned_before",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Sort field (assigned_date, status, mapping_id), prefixed with - for descending order",
+                        "name": "sort",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Mappings per page (default 50, at most 200)",
+                        "name": "page_size",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Token of the page to fetch",
+                        "name": "page_token",
+                        "in": "query"
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "type": "array",
-                            "items": {
-                                "$ref": "#/definitions/models.EmployeeAssetMapping"
+                            "$ref": "#/definitions/controllers.MappingPage"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
                             }
                         }
                     },
@@ -113,7 +219,7 @@ const docTemplate = `{
         },
         "/asset-mapping/{mappingId}": {
             "delete": {
-                "description": "Deletes a specific asset mapping by its ID",
+                "description": "Marks a specific asset mapping as returned. The mapping is kept as history.",
                 "produces": [
                     "application/json"
                 ],
@@ -128,6 +234,12 @@ const docTemplate = `{
                         "name": "mappingId",
                         "in": "path",
                         "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "ETag the client last read; the write is refused with 412 when the record has changed since",
+                        "name": "If-Match",
+                        "in": "header"
                     }
                 ],
                 "responses": {
@@ -138,37 +250,38 @@ const docTemplate = `{
                             "additionalProperties": {
                                 "type": "string"
                             }
+                        },
+                        "headers": {
+                            "ETag": {
+                                "type": "string",
+                                "description": "Version of the mapping"
+                            }
                         }
                     },
-                    "500": {
-                        "description": "Internal Server Error",
+                    "404": {
+                        "description": "Not Found",
                         "schema": {
                             "type": "object",
                             "additionalProperties": {
                                 "type": "string"
                             }
                         }
-                    }
-                }
-            }
-        },
-        "/assets": {
-            "get": {
-                "description": "Fetches all assets from the database",
-                "produces": [
-                    "application/json"
-                ],
-                "tags": [
-                    "Assets"
-                ],
-                "summary": "Get all assets",
-                "responses": {
-                    "200": {
-                        "description": "OK",
+                    },
+                    "409": {
+                        "description": "Conflict",
                         "schema": {
-                            "type": "array",
-                            "items": {
-                                "$ref": "#/definitions/models.Asset"
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "412": {
+                        "description": "Precondition Failed",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
                             }
                         }
                     },
@@ -182,9 +295,11 @@ const docTemplate = `{
                         }
                     }
                 }
-            },
-            "post": {
-                "description": "Adds a new asset to the database",
+            }
+        },
+        "/asset/editasset/{assetId}": {
+            "put": {
+                "description": "Replaces every editable field of an asset. Fields missing from the body are cleared. Only the fields of AssetPatch may be set; any other key is rejected.",
                 "consumes": [
                     "application/json"
                 ],
@@ -194,26 +309,45 @@ const docTemplate = `{
                 "tags": [
                     "Assets"
                 ],
-                "summary": "Create a new asset",
+                "summary": "Replace an asset's details",
                 "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Asset ID",
+                        "name": "assetId",
+                        "in": "path",
+                        "required": true
+                    },
                     {
                         "description": "Asset data",
-                        "name": "asset",
+                        "name": "data",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/models.Asset"
+                            "$ref": "#/definitions/models.AssetPatch"
                         }
+                    },
+                    {
+                        "type": "string",
+                        "description": "ETag the client last read; the write is refused with 412 when the record has changed since",
+                        "name": "If-Match",
+                        "in": "header"
                     }
                 ],
                 "responses": {
-                    "201": {
-                        "description": "Created",
+                    "200": {
+                        "description": "OK",
                         "schema": {
                             "type": "object",
                             "additionalProperties": {
                                 "type": "string"
                             }
+                        },
+                        "headers": {
+                            "ETag": {
+                                "type": "string",
+                                "description": "Version of the asset"
+                            }
                         }
                     },
                     "400": {
@@ -225,46 +359,32 @@ const docTemplate = `{
                             }
                         }
                     },
-                    "500": {
-                        "description": "Internal Server Error",
+                    "404": {
+                        "description": "Not Found",
                         "schema": {
                             "type": "object",
                             "additionalProperties": {
                                 "type": "string"
                             }
                         }
-                    }
-                }
-            }
-        },
-        "/assets/{assetId}": {
-            "get": {
-                "description": "Fetches details of a single asset",
-                "produces": [
-                    "application/json"
-                ],
-                "tags": [
-                    "Assets"
-                ],
-                "summary": "Get an asset by ID",
-                "parameters": [
-                    {
-                        "type": "string",
-                        "description": "Asset ID",
-                        "name": "assetId",
-                        "in": "path",
-                        "required": true
-                    }
-                ],
-                "responses": {
-                    "200": {
-                        "description": "OK",
+                    },
+                    "412": {
+                        "description": "Precondition Failed",
                         "schema": {
-                            "$ref": "#/definitions/models.Asset"
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
                         }
                     },
-                    "404": {
-                        "description": "Not Found",
+                    "422": {
+                        "description": "Unprocessable Entity",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ValidationErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
                         "schema": {
                             "type": "object",
                             "additionalProperties": {
@@ -274,10 +394,11 @@ const docTemplate = `{
                     }
                 }
             },
-            "put": {
-                "description": "Updates an asset's information by ID",
+            "patch": {
+                "description": "Applies a JSON Merge Patch (RFC 7386) or JSON Patch (RFC 6902) to the editable fields of an asset, chosen by Content-Type. Removing a field clears it. A failed test operation is rejected with 409.",
                 "consumes": [
-                    "application/json"
+                    "application/merge-patch+json",
+                    "application/json-patch+json"
                 ],
                 "produces": [
                     "application/json"
@@ -285,7 +406,7 @@ const docTemplate = `{
                 "tags": [
                     "Assets"
                 ],
-                "summary": "Edit an asset's details",
+                "summary": "Partially update an asset",
                 "parameters": [
                     {
                         "type": "string",
@@ -295,14 +416,19 @@ const docTemplate = `{
                         "required": true
                     },
                     {
-                        "description": "Updated data",
-                        "name": "data",
+                        "description": "Merge patch object or JSON Patch operation array",
+                        "name": "patch",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": true
+                            "type": "object"
                         }
+                    },
+                    {
+                        "type": "string",
+                        "description": "ETag the client last read; the write is refused with 412 when the record has changed since",
+                        "name": "If-Match",
+                        "in": "header"
                     }
                 ],
                 "responses": {
@@ -313,6 +439,12 @@ const docTemplate = `{
                             "additionalProperties": {
                                 "type": "string"
                             }
+                        },
+                        "headers": {
+                            "ETag": {
+                                "type": "string",
+                                "description": "Version of the asset"
+                            }
                         }
                     },
                     "400": {
@@ -324,38 +456,17 @@ const docTemplate = `{
                             }
                         }
                     },
-                    "500": {
-                        "description": "Internal Server Error",
+                    "404": {
+                        "description": "Not Found",
                         "schema": {
                             "type": "object",
                             "additionalProperties": {
                                 "type": "string"
                             }
                         }
-                    }
-                }
-            },
-            "delete": {
-                "description": "Deletes an asset from the database by ID",
-                "produces": [
-                    "application/json"
-                ],
-                "tags": [
-                    "Assets"
-                ],
-                "summary": "Delete an asset",
-                "parameters": [
-                    {
-                        "type": "string",
-                        "description": "Asset ID",
-                        "name": "assetId",
-                        "in": "path",
-                        "required": true
-                    }
-                ],
-                "responses": {
-                    "200": {
-                        "description": "OK",
+                    },
+                    "409": {
+                        "description": "Conflict",
                         "schema": {
                             "type": "object",
                             "additionalProperties": {
@@ -363,33 +474,28 @@ const docTemplate = `{
                             }
                         }
                     },
-                    "500": {
-                        "description": "Internal Server Error",
+                    "412": {
+                        "description": "Precondition Failed",
                         "schema": {
                             "type": "object",
                             "additionalProperties": {
                                 "type": "string"
                             }
                         }
-                    }
-                }
-            }
-        },
-        "/employees": {
-            "get": {
-                "description": "Fetches all employees and their asset counts",
-                "produces": [
-                    "application/json"
-                ],
-                "tags": [
-                    "Employees"
-                ],
-                "summary": "Get all employees with asset count",
-                "responses": {
-                    "200": {
-                        "description": "OK",
+                    },
+                    "415": {
+                        "description": "Unsupported Media Type",
                         "schema": {
-                            "$ref": "#/definitions/models.EmployeeList"
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "422": {
+                        "description": "Unprocessable Entity",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ValidationErrorResponse"
                         }
                     },
                     "500": {
@@ -402,9 +508,11 @@ const docTemplate = `{
                         }
                     }
                 }
-            },
+            }
+        },
+        "/asset/intake": {
             "post": {
-                "description": "Adds a new employee to the database",
+                "description": "Creates one asset per serial number of a purchase manifest, each with a generated asset tag. Serial numbers that already belong to an asset, deleted ones included, are skipped and reported under existing. Replies 409 when every unit already exists. The units are created all or nothing, so a standalone MongoDB server, which has no transactions, replies 501.",
                 "consumes": [
                     "application/json"
                 ],
@@ -412,17 +520,17 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Employees"
+                    "Assets"
                 ],
-                "summary": "Create a new employee",
+                "summary": "Receive a shipment of assets",
                 "parameters": [
                     {
-                        "description": "Employee data",
-                        "name": "employee",
+                        "description": "Purchase manifest",
+                        "name": "manifest",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/models.Employee"
+                            "$ref": "#/definitions/controllers.IntakeManifest"
                         }
                     }
                 ],
@@ -430,14 +538,26 @@ const docTemplate = `{
                     "201": {
                         "description": "Created",
                         "schema": {
-                            "type": "object",
+                            "$ref": "#/definitions/controllers.IntakeReport"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
                             "additionalProperties": {
                                 "type": "string"
                             }
                         }
                     },
-                    "400": {
-                        "description": "Bad Request",
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.IntakeReport"
+                        }
+                    },
+                    "413": {
+                        "description": "Request Entity Too Large",
                         "schema": {
                             "type": "object",
                             "additionalProperties": {
@@ -445,6 +565,12 @@ const docTemplate = `{
                             }
                         }
                     },
+                    "422": {
+                        "description": "Unprocessable Entity",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ValidationErrorResponse"
+                        }
+                    },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
@@ -453,25 +579,34 @@ const docTemplate = `{
                                 "type": "string"
                             }
                         }
+                    },
+                    "501": {
+                        "description": "Not Implemented",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
                     }
                 }
             }
         },
-        "/employees/{employeeId}": {
-            "get": {
-                "description": "Fetches details of a single employee",
+        "/asset/restoreasset/{assetId}": {
+            "post": {
+                "description": "Undoes the soft delete of an asset. Mappings closed when it was deleted stay closed.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "Employees"
+                    "Assets"
                 ],
-                "summary": "Get an employee by ID",
+                "summary": "Restore a deleted asset",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "Employee ID",
-                        "name": "employeeId",
+                        "description": "Asset ID",
+                        "name": "assetId",
                         "in": "path",
                         "required": true
                     }
@@ -480,7 +615,10 @@ const docTemplate = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/models.Employee"
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
                         }
                     },
                     "404": {
@@ -491,39 +629,100 @@ const docTemplate = `{
                                 "type": "string"
                             }
                         }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
                     }
                 }
-            },
-            "put": {
-                "description": "Updates an employee's information by ID",
-                "consumes": [
-                    "application/json"
-                ],
+            }
+        },
+        "/asset/{assetId}/history": {
+            "get": {
+                "description": "Lists every version of an asset record, oldest first, with the fields each version changed",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "Employees"
+                    "Assets"
                 ],
-                "summary": "Edit an employee's details",
+                "summary": "Get the change history of an asset",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "Employee ID",
-                        "name": "employeeId",
+                        "description": "Asset ID",
+                        "name": "assetId",
                         "in": "path",
                         "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/controllers.HistoryEntry"
+                            }
+                        }
                     },
-                    {
-                        "description": "Updated data",
-                        "name": "data",
-                        "in": "body",
-                        "required": true,
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
                         "schema": {
                             "type": "object",
-                            "additionalProperties": true
+                            "additionalProperties": {
+                                "type": "string"
+                            }
                         }
                     }
+                }
+            }
+        },
+        "/asset/{assetId}/rollback/{version}": {
+            "post": {
+                "description": "Restores the fields of an asset as they were in the given version. The rollback is itself recorded as a new version.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Assets"
+                ],
+                "summary": "Roll an asset back to a previous version",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Asset ID",
+                        "name": "assetId",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Version to restore",
+                        "name": "version",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "ETag the client last read; the write is refused with 412 when the record has changed since",
+                        "name": "If-Match",
+                        "in": "header"
+                    }
                 ],
                 "responses": {
                     "200": {
@@ -533,6 +732,12 @@ const docTemplate = `{
                             "additionalProperties": {
                                 "type": "string"
                             }
+                        },
+                        "headers": {
+                            "ETag": {
+                                "type": "string",
+                                "description": "Version of the asset"
+                            }
                         }
                     },
                     "400": {
@@ -544,6 +749,24 @@ const docTemplate = `{
                             }
                         }
                     },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "412": {
+                        "description": "Precondition Failed",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
@@ -554,28 +777,131 @@ const docTemplate = `{
                         }
                     }
                 }
-            },
-            "delete": {
-                "description": "Deletes an employee from the database by ID",
+            }
+        },
+        "/assets": {
+            "get": {
+                "description": "Lists assets a page at a time. Pass the next_page_token of a page as page_token, with the same sort, to fetch the one after it.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "Employees"
+                    "Assets"
                 ],
-                "summary": "Delete an employee",
+                "summary": "Get all assets",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "Employee ID",
-                        "name": "employeeId",
-                        "in": "path",
-                        "required": true
+                        "description": "Only assets of this type",
+                        "name": "asset_type",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Only assets whose name starts with this, ignoring case",
+                        "name": "name",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Only shared or only exclusive assets",
+                        "name": "shared",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Only assets created after this RFC 3339 time",
+                        "name": "created_after",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Only assets created before this RFC 3339 time",
+                        "name": "created_before",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Only the asset with this serial number",
+                        "name": "serial_number",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Only assets of this manufacturer, ignoring case",
+                        "name": "manufacturer",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Only assets of this model, ignoring case",
+                        "name": "model",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Only assets bought from this vendor, ignoring case",
+                        "name": "vendor",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Only assets on this invoice",
+                        "name": "invoice_number",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Only assets purchased after this date or RFC 3339 time",
+                        "name": "purchased_after",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Only assets purchased before this date or RFC 3339 time",
+                        "name": "purchased_before",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Only assets whose warranty expires after this date or RFC 3339 time",
+                        "name": "warranty_expires_after",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Only assets whose warranty expires before this date or RFC 3339 time",
+                        "name": "warranty_expires_before",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Sort field (created_at, updated_at, asset_name, asset_type, asset_id), prefixed with - for descending order",
+                        "name": "sort",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Assets per page (default 50, at most 200)",
+                        "name": "page_size",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Token of the page to fetch",
+                        "name": "page_token",
+                        "in": "query"
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.AssetPage"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
                         "schema": {
                             "type": "object",
                             "additionalProperties": {
@@ -593,11 +919,9 @@ const docTemplate = `{
                         }
                     }
                 }
-            }
-        },
-        "/login/auth": {
+            },
             "post": {
-                "description": "Employee login using phone number or email and password",
+                "description": "Adds a new asset to the database",
                 "consumes": [
                     "application/json"
                 ],
@@ -605,29 +929,62 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Login"
+                    "Assets"
                 ],
-                "summary": "Login for employees",
+                "summary": "Create a new asset",
                 "parameters": [
                     {
-                        "description": "Login Request Body",
-                        "name": "request",
+                        "description": "Asset data",
+                        "name": "asset",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/controllers.LoginRequest"
+                            "$ref": "#/definitions/models.AssetPatch"
                         }
                     }
                 ],
                 "responses": {
-                    "200": {
-                        "description": "OK",
+                    "201": {
+                        "description": "Created",
                         "schema": {
-                            "$ref": "#/definitions/controllers.LoginResponse"
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        },
+                        "headers": {
+                            "ETag": {
+                                "type": "string",
+                                "description": "Version of the asset"
+                            }
                         }
                     },
-                    "401": {
-                        "description": "Unauthorized",
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "422": {
+                        "description": "Unprocessable Entity",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ValidationErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
                         "schema": {
                             "type": "object",
                             "additionalProperties": {
@@ -637,25 +994,2967 @@ const docTemplate = `{
                     }
                 }
             }
-        }
-    },
-    "definitions": {
-        "controllers.LoginRequest": {
-            "type": "object",
-            "properties": {
-                "identifier": {
-                    "type": "string"
-                },
-                "password": {
-                    "type": "string"
-                }
-            }
+        },
+        "/assets/{assetId}": {
+            "get": {
+                "description": "Fetches details of a single asset",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Assets"
+                ],
+                "summary": "Get an asset by ID",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Asset ID",
+                        "name": "assetId",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "ETag of the copy the client holds",
+                        "name": "If-None-Match",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.Asset"
+                        },
+                        "headers": {
+                            "ETag": {
+                                "type": "string",
+                                "description": "Version of the asset"
+                            }
+                        }
+                    },
+                    "304": {
+                        "description": "Not modified"
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "description": "Soft deletes an asset by ID. It can be restored until it is purged. The delete is refused while the asset has active asset mappings unless cascade is set, which returns them first with the given reason. Closed mappings are kept as history.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Assets"
+                ],
+                "summary": "Delete an asset",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Asset ID",
+                        "name": "assetId",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Return the asset's active mappings instead of refusing the delete",
+                        "name": "cascade",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Reason recorded on mappings closed by cascade",
+                        "name": "reason",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "ETag the client last read; the write is refused with 412 when the record has changed since",
+                        "name": "If-Match",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "412": {
+                        "description": "Precondition Failed",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/audit": {
+            "get": {
+                "description": "Lists the audit trail of every change made through the API, newest first",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Audit"
+                ],
+                "summary": "List audit log entries",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Only changes made by this employee ID",
+                        "name": "actor",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Only this action (create, update, delete, restore, purge, assign, return)",
+                        "name": "action",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Only this entity type (employee, asset, mapping)",
+                        "name": "entity_type",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Only changes to this entity",
+                        "name": "entity_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Only changes at or after this RFC 3339 time",
+                        "name": "from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Only changes at or before this RFC 3339 time",
+                        "name": "to",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Page number, starting at 1",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Entries per page (default 50, at most 200)",
+                        "name": "page_size",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.AuditPage"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/employee/editemployee/{employeeId}": {
+            "put": {
+                "description": "Replaces every editable field of an employee. Fields missing from the body are cleared, except the password, which is only changed when given. Only the fields of EmployeePatch may be set; any other key is rejected.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Employees"
+                ],
+                "summary": "Replace an employee's details",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Employee ID",
+                        "name": "employeeId",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Employee data",
+                        "name": "data",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.EmployeePatch"
+                        }
+                    },
+                    {
+                        "type": "string",
+                        "description": "ETag the client last read; the write is refused with 412 when the record has changed since",
+                        "name": "If-Match",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        },
+                        "headers": {
+                            "ETag": {
+                                "type": "string",
+                                "description": "Version of the employee"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "412": {
+                        "description": "Precondition Failed",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "422": {
+                        "description": "Unprocessable Entity",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ValidationErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            },
+            "patch": {
+                "description": "Applies a JSON Merge Patch (RFC 7386) or JSON Patch (RFC 6902) to the editable fields of an employee, chosen by Content-Type. Removing a field clears it. A failed test operation is rejected with 409.",
+                "consumes": [
+                    "application/merge-patch+json",
+                    "application/json-patch+json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Employees"
+                ],
+                "summary": "Partially update an employee",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Employee ID",
+                        "name": "employeeId",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Merge patch object or JSON Patch operation array",
+                        "name": "patch",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "type": "object"
+                        }
+                    },
+                    {
+                        "type": "string",
+                        "description": "ETag the client last read; the write is refused with 412 when the record has changed since",
+                        "name": "If-Match",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        },
+                        "headers": {
+                            "ETag": {
+                                "type": "string",
+                                "description": "Version of the employee"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "412": {
+                        "description": "Precondition Failed",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "415": {
+                        "description": "Unsupported Media Type",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "422": {
+                        "description": "Unprocessable Entity",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ValidationErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/employee/import": {
+            "post": {
+                "description": "Creates employees from a CSV file, whose header row names the fields of EmployeePatch (roles separated by semicolons), or from JSON Lines of EmployeePatch objects. Every row is validated and checked for emails and phone numbers used twice or by an existing employee. The import is all or nothing: with any row in error nothing is created and the errors are reported per line with 422. A standalone MongoDB server has no transactions to run it in and replies 501. A dry run only reports the errors.",
+                "consumes": [
+                    "text/csv",
+                    "application/jsonl"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Employees"
+                ],
+                "summary": "Import employees in bulk",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "csv or jsonl; defaults to the Content-Type",
+                        "name": "format",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Only validate the rows",
+                        "name": "dry_run",
+                        "in": "query"
+                    },
+                    {
+                        "description": "CSV or JSON Lines rows",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Dry run report",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ImportReport"
+                        }
+                    },
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ImportReport"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "413": {
+                        "description": "Request Entity Too Large",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "415": {
+                        "description": "Unsupported Media Type",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "422": {
+                        "description": "Unprocessable Entity",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ImportReport"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "501": {
+                        "description": "Not Implemented",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/employee/restoreemployee/{employeeId}": {
+            "post": {
+                "description": "Undoes the soft delete of an employee. Mappings closed when it was deleted stay closed.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Employees"
+                ],
+                "summary": "Restore a deleted employee",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Employee ID",
+                        "name": "employeeId",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/employee/{employeeId}/history": {
+            "get": {
+                "description": "Lists every version of an employee record, oldest first, with the fields each version changed",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Employees"
+                ],
+                "summary": "Get the change history of an employee",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Employee ID",
+                        "name": "employeeId",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/controllers.HistoryEntry"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/employee/{employeeId}/rollback/{version}": {
+            "post": {
+                "description": "Restores the fields of an employee as they were in the given version. The password is left unchanged. The rollback is itself recorded as a new version.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Employees"
+                ],
+                "summary": "Roll an employee back to a previous version",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Employee ID",
+                        "name": "employeeId",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Version to restore",
+                        "name": "version",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "ETag the client last read; the write is refused with 412 when the record has changed since",
+                        "name": "If-Match",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        },
+                        "headers": {
+                            "ETag": {
+                                "type": "string",
+                                "description": "Version of the employee"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "412": {
+                        "description": "Precondition Failed",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/employees": {
+            "get": {
+                "description": "Lists employees a page at a time with the assets currently assigned to them: their count, a breakdown by asset type, their total purchase cost by currency, and when the employee was last assigned an asset. Pass the NextPageToken of a page as page_token, with the same sort, to fetch the one after it.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Employees"
+                ],
+                "summary": "Get all employees with asset count",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Only employees whose first or last name starts with this, ignoring case",
+                        "name": "name",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Only employees holding this role",
+                        "name": "role",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Only employees created after this RFC 3339 time",
+                        "name": "created_after",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Only employees created before this RFC 3339 time",
+                        "name": "created_before",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Sort field (created_at, updated_at, first_name, last_name, employee_email, emp_id), prefixed with - for descending order",
+                        "name": "sort",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Employees per page (default 50, at most 200)",
+                        "name": "page_size",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Token of the page to fetch",
+                        "name": "page_token",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.EmployeeList"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "Adds a new employee to the database",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Employees"
+                ],
+                "summary": "Create a new employee",
+                "parameters": [
+                    {
+                        "description": "Employee data",
+                        "name": "employee",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.EmployeePatch"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        },
+                        "headers": {
+                            "ETag": {
+                                "type": "string",
+                                "description": "Version of the employee"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "422": {
+                        "description": "Unprocessable Entity",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ValidationErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/employees/{employeeId}": {
+            "get": {
+                "description": "Fetches details of a single employee",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Employees"
+                ],
+                "summary": "Get an employee by ID",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Employee ID",
+                        "name": "employeeId",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "ETag of the copy the client holds",
+                        "name": "If-None-Match",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.Employee"
+                        },
+                        "headers": {
+                            "ETag": {
+                                "type": "string",
+                                "description": "Version of the employee"
+                            }
+                        }
+                    },
+                    "304": {
+                        "description": "Not modified"
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "description": "Soft deletes an employee by ID. It can be restored until it is purged. The delete is refused while the employee has active asset mappings unless cascade is set, which returns them first with the given reason. Closed mappings are kept as history.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Employees"
+                ],
+                "summary": "Delete an employee",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Employee ID",
+                        "name": "employeeId",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Return the employee's active mappings instead of refusing the delete",
+                        "name": "cascade",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Reason recorded on mappings closed by cascade",
+                        "name": "reason",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "ETag the client last read; the write is refused with 412 when the record has changed since",
+                        "name": "If-Match",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "412": {
+                        "description": "Precondition Failed",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/export/assets": {
+            "get": {
+                "description": "Streams the assets as CSV or XLSX. Takes the filters and sort of the asset list; every matching asset is exported. The format is chosen by the format parameter or else the Accept header, and defaults to CSV.",
+                "produces": [
+                    "text/csv",
+                    "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+                ],
+                "tags": [
+                    "Export"
+                ],
+                "summary": "Export assets",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "csv or xlsx",
+                        "name": "format",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Only assets of this type",
+                        "name": "asset_type",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Only assets whose name starts with this, ignoring case",
+                        "name": "name",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Only shared or only exclusive assets",
+                        "name": "shared",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Only assets created after this RFC 3339 time",
+                        "name": "created_after",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Only assets created before this RFC 3339 time",
+                        "name": "created_before",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Only the asset with this serial number",
+                        "name": "serial_number",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Only assets of this manufacturer, ignoring case",
+                        "name": "manufacturer",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Only assets of this model, ignoring case",
+                        "name": "model",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Only assets bought from this vendor, ignoring case",
+                        "name": "vendor",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Only assets on this invoice",
+                        "name": "invoice_number",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Only assets purchased after this date or RFC 3339 time",
+                        "name": "purchased_after",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Only assets purchased before this date or RFC 3339 time",
+                        "name": "purchased_before",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Only assets whose warranty expires after this date or RFC 3339 time",
+                        "name": "warranty_expires_after",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Only assets whose warranty expires before this date or RFC 3339 time",
+                        "name": "warranty_expires_before",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Sort field (created_at, updated_at, asset_name, asset_type, asset_id), prefixed with - for descending order",
+                        "name": "sort",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "file"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "406": {
+                        "description": "Not Acceptable",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/export/employees": {
+            "get": {
+                "description": "Streams the employees of the dashboard, with the assets they hold, as CSV or XLSX. Takes the filters and sort of the dashboard; every matching employee is exported. The format is chosen by the format parameter or else the Accept header, and defaults to CSV.",
+                "produces": [
+                    "text/csv",
+                    "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+                ],
+                "tags": [
+                    "Export"
+                ],
+                "summary": "Export employees",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "csv or xlsx",
+                        "name": "format",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Only employees whose first or last name starts with this, ignoring case",
+                        "name": "name",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Only employees holding this role",
+                        "name": "role",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Only employees created after this RFC 3339 time",
+                        "name": "created_after",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Only employees created before this RFC 3339 time",
+                        "name": "created_before",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Sort field (created_at, updated_at, first_name, last_name, employee_email, emp_id), prefixed with - for descending order",
+                        "name": "sort",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "file"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "406": {
+                        "description": "Not Acceptable",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/export/mappings": {
+            "get": {
+                "description": "Streams asset mappings, active and closed, as CSV or XLSX. Takes the filters and sort of the mapping lists; every matching mapping is exported. The format is chosen by the format parameter or else the Accept header, and defaults to CSV.",
+                "produces": [
+                    "text/csv",
+                    "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+                ],
+                "tags": [
+                    "Export"
+                ],
+                "summary": "Export the mapping history",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "csv or xlsx",
+                        "name": "format",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Only mappings of this employee",
+                        "name": "employee_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Only mappings of this asset",
+                        "name": "asset_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Only mappings with this status (active, returned, lost, damaged)",
+                        "name": "status",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Only mappings assigned after this RFC 3339 time",
+                        "name": "assigned_after",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Only mappings assigned before this RFC 3339 time",
+                        "name": "assigned_before",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Sort field (assigned_date, status, mapping_id), prefixed with - for descending order",
+                        "name": "sort",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "file"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "406": {
+                        "description": "Not Acceptable",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/login/auth": {
+            "post": {
+                "description": "Employee login using phone number or email and password",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Login"
+                ],
+                "summary": "Login for employees",
+                "parameters": [
+                    {
+                        "description": "Login Request Body",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/controllers.LoginRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.LoginResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/login/logout": {
+            "post": {
+                "description": "Revokes the login session the refresh token belongs to. Access tokens issued for the session stop working immediately.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Login"
+                ],
+                "summary": "Log out",
+                "parameters": [
+                    {
+                        "description": "Refresh token",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/controllers.RefreshRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/login/refresh": {
+            "post": {
+                "description": "Rotates a refresh token, returning a new access token and refresh token. Presenting an already used refresh token revokes every token of its login session.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Login"
+                ],
+                "summary": "Exchange a refresh token",
+                "parameters": [
+                    {
+                        "description": "Refresh token",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/controllers.RefreshRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.LoginResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/mapping/assethistory/{assetId}": {
+            "get": {
+                "description": "Fetches every mapping of an asset, oldest first, showing who held it and how it came back",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Asset Mapping"
+                ],
+                "summary": "Get the assignment history of an asset",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Asset ID",
+                        "name": "assetId",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Only mappings with this status (active, returned, lost, damaged)",
+                        "name": "status",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.EmployeeAssetMapping"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/mapping/bulk/assign": {
+            "post": {
+                "description": "Assigns each asset to its employee as AssignAssetMapping would. Items fail on their own with their reason reported. Without all_or_nothing the items that succeed are kept whatever happens to the others, and on MongoDB they become visible one by one. With all_or_nothing set the items run as one transaction: nothing is kept unless every item succeeds and the reply is 409 otherwise. MongoDB deployments without transactions, such as a standalone server, refuse all_or_nothing with 501.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Asset Mapping"
+                ],
+                "summary": "Assign assets in bulk",
+                "parameters": [
+                    {
+                        "description": "Assignments",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/controllers.BulkAssignRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Every item was applied",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.BulkReport"
+                        }
+                    },
+                    "207": {
+                        "description": "Some items failed",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.BulkReport"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "409": {
+                        "description": "An all-or-nothing request failed",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.BulkReport"
+                        }
+                    },
+                    "422": {
+                        "description": "Unprocessable Entity",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ValidationErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "501": {
+                        "description": "Not Implemented",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/mapping/bulk/return": {
+            "post": {
+                "description": "Closes each mapping as ReturnAssetMapping would. A mapping is named by mapping_id or by the employee_id and asset_id of the active mapping. Items fail on their own with their reason reported. Without all_or_nothing the items that succeed are kept whatever happens to the others, and on MongoDB they become visible one by one. With all_or_nothing set the items run as one transaction: nothing is kept unless every item succeeds and the reply is 409 otherwise. MongoDB deployments without transactions, such as a standalone server, refuse all_or_nothing with 501.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Asset Mapping"
+                ],
+                "summary": "Return assets in bulk",
+                "parameters": [
+                    {
+                        "description": "Returns",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/controllers.BulkReturnRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Every item was applied",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.BulkReport"
+                        }
+                    },
+                    "207": {
+                        "description": "Some items failed",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.BulkReport"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "409": {
+                        "description": "An all-or-nothing request failed",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.BulkReport"
+                        }
+                    },
+                    "422": {
+                        "description": "Unprocessable Entity",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ValidationErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "501": {
+                        "description": "Not Implemented",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/mapping/editmapping/{mappingId}": {
+            "put": {
+                "description": "Replaces the notes of a mapping and, once it is closed, its return condition and close reason. Fields missing from the body are cleared. The status only changes by returning the asset.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Asset Mapping"
+                ],
+                "summary": "Replace the editable details of an asset mapping",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Mapping ID",
+                        "name": "mappingId",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Mapping data",
+                        "name": "data",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.MappingPatch"
+                        }
+                    },
+                    {
+                        "type": "string",
+                        "description": "ETag the client last read; the write is refused with 412 when the record has changed since",
+                        "name": "If-Match",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        },
+                        "headers": {
+                            "ETag": {
+                                "type": "string",
+                                "description": "Version of the mapping"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "412": {
+                        "description": "Precondition Failed",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "422": {
+                        "description": "Unprocessable Entity",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ValidationErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            },
+            "patch": {
+                "description": "Applies a JSON Merge Patch (RFC 7386) or JSON Patch (RFC 6902) to the editable fields of a mapping, chosen by Content-Type. Removing a field clears it. A failed test operation is rejected with 409.",
+                "consumes": [
+                    "application/merge-patch+json",
+                    "application/json-patch+json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Asset Mapping"
+                ],
+                "summary": "Partially update an asset mapping",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Mapping ID",
+                        "name": "mappingId",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Merge patch object or JSON Patch operation array",
+                        "name": "patch",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "type": "object"
+                        }
+                    },
+                    {
+                        "type": "string",
+                        "description": "ETag the client last read; the write is refused with 412 when the record has changed since",
+                        "name": "If-Match",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        },
+                        "headers": {
+                            "ETag": {
+                                "type": "string",
+                                "description": "Version of the mapping"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "412": {
+                        "description": "Precondition Failed",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "415": {
+                        "description": "Unsupported Media Type",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "422": {
+                        "description": "Unprocessable Entity",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ValidationErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/mapping/mapping/{mappingId}": {
+            "get": {
+                "description": "Fetches a single asset mapping together with its ETag",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Asset Mapping"
+                ],
+                "summary": "Get an asset mapping by ID",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Mapping ID",
+                        "name": "mappingId",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "ETag of the copy the client holds",
+                        "name": "If-None-Match",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.EmployeeAssetMapping"
+                        },
+                        "headers": {
+                            "ETag": {
+                                "type": "string",
+                                "description": "Version of the mapping"
+                            }
+                        }
+                    },
+                    "304": {
+                        "description": "Not modified"
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/mapping/returnasset/{mappingId}": {
+            "post": {
+                "description": "Closes an active mapping, recording when and by whom the asset was returned, its condition and whether it came back, was lost or damaged",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Asset Mapping"
+                ],
+                "summary": "Return an assigned asset",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Mapping ID",
+                        "name": "mappingId",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Return details",
+                        "name": "request",
+                        "in": "body",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ReturnRequest"
+                        }
+                    },
+                    {
+                        "type": "string",
+                        "description": "ETag the client last read; the write is refused with 412 when the record has changed since",
+                        "name": "If-Match",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.EmployeeAssetMapping"
+                        },
+                        "headers": {
+                            "ETag": {
+                                "type": "string",
+                                "description": "Version of the mapping"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "412": {
+                        "description": "Precondition Failed",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/reports/assets": {
+            "get": {
+                "description": "Counts the live assets created in the period by type and status. An asset is assigned while it has an active mapping; otherwise it is lost or damaged if its latest mapping was closed as such, and available if not.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Reports"
+                ],
+                "summary": "Report assets by type and status",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Only assets created at or after this RFC 3339 time",
+                        "name": "from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Only assets created at or before this RFC 3339 time",
+                        "name": "to",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.AssetReport"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/reports/assignment-duration": {
+            "get": {
+                "description": "Averages how long the assignments made in the period lasted, over those that have been closed since.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Reports"
+                ],
+                "summary": "Report the average assignment duration",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Only assignments made at or after this RFC 3339 time",
+                        "name": "from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Only assignments made at or before this RFC 3339 time",
+                        "name": "to",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.AssignmentDurationReport"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/reports/departments": {
+            "get": {
+                "description": "Counts the assignments made in the period by the department of the employee, and how many of them are still active. Employees without a department are grouped under an empty name.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Reports"
+                ],
+                "summary": "Report assignments by department",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Only assignments made at or after this RFC 3339 time",
+                        "name": "from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Only assignments made at or before this RFC 3339 time",
+                        "name": "to",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.DepartmentReport"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/reports/top-holders": {
+            "get": {
+                "description": "Lists the employees holding the most assets through active assignments made in the period, most first.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Reports"
+                ],
+                "summary": "Report the top asset holders",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Only assignments made at or after this RFC 3339 time",
+                        "name": "from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Only assignments made at or before this RFC 3339 time",
+                        "name": "to",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Number of employees (default 10, at most 100)",
+                        "name": "limit",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.TopHoldersReport"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/search": {
+            "get": {
+                "description": "Finds employees by name, email, phone number and address and assets by name, type, asset tag, serial number, manufacturer, model, vendor and invoice number, best matches first. Every word of the query must match. In prefix mode words only need to start with the query words, for typeahead.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Search"
+                ],
+                "summary": "Search employees and assets",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Words to search for",
+                        "name": "q",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Only this entity type (employee, asset)",
+                        "name": "type",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Match the start of words",
+                        "name": "prefix",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Maximum number of results (default 20, at most 100)",
+                        "name": "limit",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.SearchResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        }
+    },
+    "definitions": {
+        "controllers.AssetPage": {
+            "type": "object",
+            "properties": {
+                "assets": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.Asset"
+                    }
+                },
+                "next_page_token": {
+                    "description": "empty on the last page",
+                    "type": "string"
+                },
+                "total": {
+                    "type": "integer"
+                }
+            }
+        },
+        "controllers.AssetReport": {
+            "type": "object",
+            "properties": {
+                "assigned": {
+                    "type": "integer"
+                },
+                "by_status": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "integer"
+                    }
+                },
+                "by_type": {
+                    "description": "ByType and ByStatus total Counts over the other dimension.",
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "integer"
+                    }
+                },
+                "counts": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.AssetStatusCount"
+                    }
+                },
+                "from": {
+                    "type": "string"
+                },
+                "to": {
+                    "type": "string"
+                },
+                "total": {
+                    "type": "integer"
+                },
+                "unassigned": {
+                    "type": "integer"
+                }
+            }
+        },
+        "controllers.AssignRequest": {
+            "type": "object",
+            "properties": {
+                "asset_id": {
+                    "type": "string"
+                },
+                "assigned_date": {
+                    "description": "defaults to now",
+                    "type": "string"
+                },
+                "employee_id": {
+                    "type": "string"
+                },
+                "notes": {
+                    "type": "string"
+                }
+            }
+        },
+        "controllers.AssignmentDurationReport": {
+            "type": "object",
+            "properties": {
+                "average_duration_days": {
+                    "type": "number"
+                },
+                "average_duration_seconds": {
+                    "type": "number"
+                },
+                "closed_assignments": {
+                    "type": "integer"
+                },
+                "from": {
+                    "type": "string"
+                },
+                "to": {
+                    "type": "string"
+                }
+            }
+        },
+        "controllers.AuditPage": {
+            "type": "object",
+            "properties": {
+                "entries": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.AuditEntry"
+                    }
+                },
+                "page": {
+                    "type": "integer"
+                },
+                "page_size": {
+                    "type": "integer"
+                },
+                "total": {
+                    "type": "integer"
+                }
+            }
+        },
+        "controllers.BulkAssignItem": {
+            "type": "object",
+            "properties": {
+                "asset_id": {
+                    "type": "string"
+                },
+                "employee_id": {
+                    "type": "string"
+                },
+                "notes": {
+                    "type": "string"
+                }
+            }
+        },
+        "controllers.BulkAssignRequest": {
+            "type": "object",
+            "properties": {
+                "all_or_nothing": {
+                    "description": "keep nothing unless every item succeeds",
+                    "type": "boolean"
+                },
+                "items": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/controllers.BulkAssignItem"
+                    }
+                }
+            }
+        },
+        "controllers.BulkItemResult": {
+            "type": "object",
+            "properties": {
+                "error": {
+                    "type": "string"
+                },
+                "index": {
+                    "type": "integer"
+                },
+                "mapping_id": {
+                    "type": "string"
+                },
+                "status": {
+                    "description": "applied, failed or skipped",
+                    "type": "string"
+                }
+            }
+        },
+        "controllers.BulkReport": {
+            "type": "object",
+            "properties": {
+                "all_or_nothing": {
+                    "type": "boolean"
+                },
+                "applied": {
+                    "type": "integer"
+                },
+                "failed": {
+                    "type": "integer"
+                },
+                "results": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/controllers.BulkItemResult"
+                    }
+                }
+            }
+        },
+        "controllers.BulkReturnItem": {
+            "type": "object",
+            "properties": {
+                "asset_id": {
+                    "type": "string"
+                },
+                "employee_id": {
+                    "type": "string"
+                },
+                "mapping_id": {
+                    "type": "string"
+                }
+            }
+        },
+        "controllers.BulkReturnRequest": {
+            "type": "object",
+            "properties": {
+                "all_or_nothing": {
+                    "description": "keep nothing unless every item succeeds",
+                    "type": "boolean"
+                },
+                "items": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/controllers.BulkReturnItem"
+                    }
+                },
+                "reason": {
+                    "type": "string"
+                },
+                "return_condition": {
+                    "type": "string"
+                },
+                "status": {
+                    "description": "returned (default), lost or damaged",
+                    "type": "string"
+                }
+            }
+        },
+        "controllers.DepartmentReport": {
+            "type": "object",
+            "properties": {
+                "departments": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.DepartmentAssignments"
+                    }
+                },
+                "from": {
+                    "type": "string"
+                },
+                "to": {
+                    "type": "string"
+                }
+            }
+        },
+        "controllers.HistoryEntry": {
+            "type": "object",
+            "properties": {
+                "action": {
+                    "type": "string"
+                },
+                "changed_at": {
+                    "type": "string"
+                },
+                "changed_by": {
+                    "type": "string"
+                },
+                "changes": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "$ref": "#/definitions/models.FieldChange"
+                    }
+                },
+                "rolled_back_to": {
+                    "type": "integer"
+                },
+                "snapshot": {
+                    "type": "object",
+                    "additionalProperties": true
+                },
+                "version": {
+                    "type": "integer"
+                }
+            }
+        },
+        "controllers.ImportReport": {
+            "type": "object",
+            "properties": {
+                "created": {
+                    "type": "integer"
+                },
+                "dry_run": {
+                    "type": "boolean"
+                },
+                "errors": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/controllers.ImportRowError"
+                    }
+                },
+                "ids": {
+                    "description": "of the records created, in row order",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "rows": {
+                    "type": "integer"
+                }
+            }
+        },
+        "controllers.ImportRowError": {
+            "type": "object",
+            "properties": {
+                "errors": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.FieldError"
+                    }
+                },
+                "line": {
+                    "description": "line of the file the row starts on",
+                    "type": "integer"
+                }
+            }
+        },
+        "controllers.IntakeManifest": {
+            "type": "object",
+            "properties": {
+                "asset_name": {
+                    "description": "defaults to the manufacturer, or else the vendor, and model",
+                    "type": "string"
+                },
+                "asset_type": {
+                    "type": "string"
+                },
+                "currency": {
+                    "type": "string"
+                },
+                "invoice_number": {
+                    "type": "string"
+                },
+                "manufacturer": {
+                    "type": "string"
+                },
+                "model": {
+                    "type": "string"
+                },
+                "purchase_cost": {
+                    "description": "per unit",
+                    "type": "number"
+                },
+                "purchase_date": {
+                    "description": "YYYY-MM-DD",
+                    "type": "string"
+                },
+                "serial_numbers": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "shared": {
+                    "type": "boolean"
+                },
+                "tag_prefix": {
+                    "description": "defaults to AT",
+                    "type": "string"
+                },
+                "vendor": {
+                    "type": "string"
+                },
+                "warranty_expiry": {
+                    "description": "YYYY-MM-DD",
+                    "type": "string"
+                }
+            }
+        },
+        "controllers.IntakeReport": {
+            "type": "object",
+            "properties": {
+                "created": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/controllers.IntakeUnit"
+                    }
+                },
+                "existing": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/controllers.IntakeUnit"
+                    }
+                }
+            }
+        },
+        "controllers.IntakeUnit": {
+            "type": "object",
+            "properties": {
+                "asset_id": {
+                    "type": "string"
+                },
+                "asset_tag": {
+                    "type": "string"
+                },
+                "serial_number": {
+                    "type": "string"
+                }
+            }
+        },
+        "controllers.LoginRequest": {
+            "type": "object",
+            "properties": {
+                "identifier": {
+                    "type": "string"
+                },
+                "password": {
+                    "type": "string"
+                }
+            }
         },
         "controllers.LoginResponse": {
             "type": "object",
             "properties": {
-                "token": {
+                "expires_in": {
+                    "description": "access token lifetime in seconds",
+                    "type": "integer"
+                },
+                "refresh_token": {
+                    "type": "string"
+                },
+                "token": {
+                    "type": "string"
+                }
+            }
+        },
+        "controllers.MappingPage": {
+            "type": "object",
+            "properties": {
+                "mappings": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.EmployeeAssetMapping"
+                    }
+                },
+                "next_page_token": {
+                    "description": "empty on the last page",
+                    "type": "string"
+                },
+                "total": {
+                    "type": "integer"
+                }
+            }
+        },
+        "controllers.PurgeResponse": {
+            "type": "object",
+            "properties": {
+                "assets_purged": {
+                    "type": "integer"
+                },
+                "deleted_before": {
+                    "type": "string"
+                },
+                "employees_purged": {
+                    "type": "integer"
+                },
+                "refresh_tokens_purged": {
+                    "description": "expired refresh tokens",
+                    "type": "integer"
+                }
+            }
+        },
+        "controllers.RefreshRequest": {
+            "type": "object",
+            "properties": {
+                "refresh_token": {
+                    "type": "string"
+                }
+            }
+        },
+        "controllers.ReturnRequest": {
+            "type": "object",
+            "properties": {
+                "reason": {
+                    "type": "string"
+                },
+                "return_condition": {
+                    "type": "string"
+                },
+                "status": {
+                    "description": "returned (default), lost or damaged",
+                    "type": "string"
+                }
+            }
+        },
+        "controllers.SearchResponse": {
+            "type": "object",
+            "properties": {
+                "query": {
+                    "type": "string"
+                },
+                "results": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/controllers.SearchResult"
+                    }
+                }
+            }
+        },
+        "controllers.SearchResult": {
+            "type": "object",
+            "properties": {
+                "highlights": {
+                    "description": "Highlights holds the fields that matched, HTML escaped, with the\nmatching words wrapped in \u003cmark\u003e tags.",
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "string"
+                    }
+                },
+                "id": {
+                    "type": "string"
+                },
+                "score": {
+                    "type": "number"
+                },
+                "title": {
+                    "type": "string"
+                },
+                "type": {
+                    "description": "employee or asset",
+                    "type": "string"
+                }
+            }
+        },
+        "controllers.TopHoldersReport": {
+            "type": "object",
+            "properties": {
+                "from": {
+                    "type": "string"
+                },
+                "holders": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.AssetHolder"
+                    }
+                },
+                "to": {
+                    "type": "string"
+                }
+            }
+        },
+        "controllers.ValidationErrorResponse": {
+            "type": "object",
+            "properties": {
+                "errors": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.FieldError"
+                    }
+                },
+                "message": {
+                    "type": "string"
+                }
+            }
+        },
+        "middleware.JWK": {
+            "type": "object",
+            "properties": {
+                "alg": {
+                    "type": "string"
+                },
+                "crv": {
+                    "type": "string"
+                },
+                "e": {
+                    "type": "string"
+                },
+                "kid": {
+                    "type": "string"
+                },
+                "kty": {
                     "type": "string"
+                },
+                "n": {
+                    "type": "string"
+                },
+                "use": {
+                    "type": "string"
+                },
+                "x": {
+                    "type": "string"
+                },
+                "y": {
+                    "type": "string"
+                }
+            }
+        },
+        "middleware.JWKS": {
+            "type": "object",
+            "properties": {
+                "keys": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/middleware.JWK"
+                    }
                 }
             }
         },
@@ -668,17 +3967,183 @@ const docTemplate = `{
                 "asset_name": {
                     "type": "string"
                 },
+                "asset_tag": {
+                    "description": "label generated at intake; unique when set",
+                    "type": "string"
+                },
                 "asset_type": {
                     "type": "string"
                 },
                 "created_at": {
                     "type": "string"
                 },
+                "created_by": {
+                    "type": "string"
+                },
+                "currency": {
+                    "description": "ISO 4217 code of PurchaseCost",
+                    "type": "string"
+                },
+                "deleted_at": {
+                    "type": "string"
+                },
+                "deleted_by": {
+                    "type": "string"
+                },
                 "id": {
                     "type": "string"
                 },
+                "invoice_number": {
+                    "type": "string"
+                },
+                "manufacturer": {
+                    "type": "string"
+                },
+                "model": {
+                    "type": "string"
+                },
+                "purchase_cost": {
+                    "type": "number"
+                },
+                "purchase_date": {
+                    "type": "string"
+                },
+                "serial_number": {
+                    "description": "unique when set",
+                    "type": "string"
+                },
+                "shared": {
+                    "description": "shared assets may be assigned to several employees at once",
+                    "type": "boolean"
+                },
                 "updated_at": {
                     "type": "string"
+                },
+                "updated_by": {
+                    "type": "string"
+                },
+                "vendor": {
+                    "description": "who the asset was bought from",
+                    "type": "string"
+                },
+                "version": {
+                    "description": "bumped by every write, used for optimistic locking",
+                    "type": "integer"
+                },
+                "warranty_expiry": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.AssetHolder": {
+            "type": "object",
+            "properties": {
+                "active_assets": {
+                    "type": "integer"
+                },
+                "department": {
+                    "type": "string"
+                },
+                "employee_id": {
+                    "type": "string"
+                },
+                "first_name": {
+                    "type": "string"
+                },
+                "last_name": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.AssetPatch": {
+            "type": "object",
+            "properties": {
+                "asset_name": {
+                    "type": "string"
+                },
+                "asset_type": {
+                    "type": "string"
+                },
+                "currency": {
+                    "type": "string"
+                },
+                "invoice_number": {
+                    "type": "string"
+                },
+                "manufacturer": {
+                    "type": "string"
+                },
+                "model": {
+                    "type": "string"
+                },
+                "purchase_cost": {
+                    "type": "number"
+                },
+                "purchase_date": {
+                    "type": "string"
+                },
+                "serial_number": {
+                    "description": "unique among all assets, deleted ones included",
+                    "type": "string"
+                },
+                "shared": {
+                    "type": "boolean"
+                },
+                "vendor": {
+                    "type": "string"
+                },
+                "warranty_expiry": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.AssetStatusCount": {
+            "type": "object",
+            "properties": {
+                "asset_type": {
+                    "type": "string"
+                },
+                "count": {
+                    "type": "integer"
+                },
+                "status": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.AuditEntry": {
+            "type": "object",
+            "properties": {
+                "action": {
+                    "type": "string"
+                },
+                "actor_id": {
+                    "type": "string"
+                },
+                "changes": {
+                    "description": "keyed by JSON field name",
+                    "type": "object",
+                    "additionalProperties": {
+                        "$ref": "#/definitions/models.FieldChange"
+                    }
+                },
+                "entity_id": {
+                    "type": "string"
+                },
+                "entity_type": {
+                    "type": "string"
+                },
+                "entry_id": {
+                    "type": "string"
+                },
+                "request_id": {
+                    "type": "string"
+                },
+                "source_ip": {
+                    "type": "string"
+                },
+                "timestamp": {
+                    "type": "string"
                 }
             }
         },
@@ -689,11 +4154,32 @@ const docTemplate = `{
                     "type": "string"
                 },
                 "AssetCount": {
+                    "description": "assets currently assigned",
                     "type": "integer"
                 },
+                "AssetsByType": {
+                    "description": "AssetsByType counts the assets currently assigned by asset type.",
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "integer"
+                    }
+                },
+                "AssignedValue": {
+                    "description": "AssignedValue totals the purchase cost of the assets currently\nassigned, by currency.",
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "number"
+                    }
+                },
                 "BloodGroup": {
                     "type": "string"
                 },
+                "CreatedAt": {
+                    "type": "string"
+                },
+                "Department": {
+                    "type": "string"
+                },
                 "EmergencyContactNumber": {
                     "type": "string"
                 },
@@ -709,11 +4195,33 @@ const docTemplate = `{
                 "Gender": {
                     "type": "string"
                 },
+                "LastAssignedAt": {
+                    "description": "LastAssignedAt is when the employee was last assigned an asset, nil\nif never.",
+                    "type": "string"
+                },
                 "LastName": {
                     "type": "string"
                 },
                 "PhoneNumber": {
                     "type": "string"
+                },
+                "UpdatedAt": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.DepartmentAssignments": {
+            "type": "object",
+            "properties": {
+                "active": {
+                    "type": "integer"
+                },
+                "assignments": {
+                    "type": "integer"
+                },
+                "department": {
+                    "description": "empty for employees without one",
+                    "type": "string"
                 }
             }
         },
@@ -729,6 +4237,18 @@ const docTemplate = `{
                 "created_at": {
                     "type": "string"
                 },
+                "created_by": {
+                    "type": "string"
+                },
+                "deleted_at": {
+                    "type": "string"
+                },
+                "deleted_by": {
+                    "type": "string"
+                },
+                "department": {
+                    "type": "string"
+                },
                 "emergency_contact_number": {
                     "type": "string"
                 },
@@ -757,8 +4277,21 @@ const docTemplate = `{
                 "phone_number": {
                     "type": "string"
                 },
+                "roles": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
                 "updated_at": {
                     "type": "string"
+                },
+                "updated_by": {
+                    "type": "string"
+                },
+                "version": {
+                    "description": "bumped by every write, used for optimistic locking",
+                    "type": "integer"
                 }
             }
         },
@@ -768,12 +4301,22 @@ const docTemplate = `{
                 "asset_id": {
                     "type": "string"
                 },
+                "assigned_by": {
+                    "type": "string"
+                },
                 "assigned_date": {
                     "type": "string"
                 },
+                "close_reason": {
+                    "type": "string"
+                },
                 "employee_id": {
                     "type": "string"
                 },
+                "exclusive": {
+                    "description": "copied from !Asset.Shared at checkout",
+                    "type": "boolean"
+                },
                 "id": {
                     "type": "string"
                 },
@@ -783,8 +4326,21 @@ const docTemplate = `{
                 "notes": {
                     "type": "string"
                 },
+                "return_condition": {
+                    "type": "string"
+                },
+                "returned_by": {
+                    "type": "string"
+                },
+                "returned_date": {
+                    "type": "string"
+                },
                 "status": {
                     "type": "string"
+                },
+                "version": {
+                    "description": "bumped by every write, used for optimistic locking",
+                    "type": "integer"
                 }
             }
         },
@@ -796,6 +4352,87 @@ const docTemplate = `{
                     "items": {
                         "$ref": "#/definitions/models.DashboardEmployee"
                     }
+                },
+                "NextPageToken": {
+                    "description": "empty on the last page",
+                    "type": "string"
+                },
+                "Total": {
+                    "type": "integer"
+                }
+            }
+        },
+        "models.EmployeePatch": {
+            "type": "object",
+            "properties": {
+                "address": {
+                    "type": "string"
+                },
+                "blood_group": {
+                    "type": "string"
+                },
+                "department": {
+                    "type": "string"
+                },
+                "emergency_contact_number": {
+                    "type": "string"
+                },
+                "employee_email": {
+                    "type": "string"
+                },
+                "first_name": {
+                    "type": "string"
+                },
+                "gender": {
+                    "type": "string"
+                },
+                "last_name": {
+                    "type": "string"
+                },
+                "password": {
+                    "description": "write-only plaintext; hashed by the caller before it is stored",
+                    "type": "string"
+                },
+                "phone_number": {
+                    "type": "string"
+                },
+                "roles": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
+        "models.FieldChange": {
+            "type": "object",
+            "properties": {
+                "after": {},
+                "before": {}
+            }
+        },
+        "models.FieldError": {
+            "type": "object",
+            "properties": {
+                "field": {
+                    "type": "string"
+                },
+                "message": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.MappingPatch": {
+            "type": "object",
+            "properties": {
+                "close_reason": {
+                    "type": "string"
+                },
+                "notes": {
+                    "type": "string"
+                },
+                "return_condition": {
+                    "type": "string"
                 }
             }
         }
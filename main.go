@@ -1,28 +1,58 @@
 package main
 
 import (
+	"context"
+	"employee-asset-system/config"
+	"employee-asset-system/controllers"
 	"employee-asset-system/db"
+	"employee-asset-system/middleware"
 	"employee-asset-system/routes"
+	"flag"
 	"log"
 	"net/http"
+	"time"
 
 	"github.com/gorilla/mux"
 )
 
 func main() {
-	r := mux.NewRouter()
-	routes.RegisterRoutes(r)
-
-	// MongoDB URI and database name
-	mongoURI := "mongodb://localhost:27017"
-	databaseName := "db"
+	cfg := config.Load()
 
-	// Initialize the database connection
-	err := db.InitializeDatabase(mongoURI, databaseName)
+	// Connect to the configured backend and bring its schema up to date
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	store, err := db.Open(ctx, cfg.DBDriver, cfg.DBURL, cfg.DBName)
 	if err != nil {
 		log.Fatalf("Failed to initialize database: %v", err)
 	}
 
-	log.Println("Server running on port 8080")
-	log.Fatal(http.ListenAndServe(":8080", r))
+	keys, err := middleware.LoadKeySet(cfg.JWTKeysFile, cfg.JWTSecret)
+	if err != nil {
+		log.Fatalf("Failed to load JWT signing keys: %v", err)
+	}
+
+	server := controllers.NewServer(store, keys)
+	server.AccessTokenTTL = cfg.AccessTokenTTL
+	server.RefreshTokenTTL = cfg.RefreshTokenTTL
+	server.PurgeRetention = cfg.PurgeRetention
+	if cfg.AdminEmail != "" && cfg.AdminPassword != "" {
+		if err := server.BootstrapAdmin(ctx, cfg.AdminEmail, cfg.AdminPassword); err != nil {
+			log.Fatalf("Failed to bootstrap admin account: %v", err)
+		}
+	}
+
+	// Arguments left after the flags name a command to run instead of the
+	// server.
+	if flag.NArg() > 0 {
+		if err := runCommand(server, flag.Args()); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+
+	r := mux.NewRouter()
+	routes.RegisterRoutes(r, server)
+
+	log.Printf("Server running on port %s", cfg.Port)
+	log.Fatal(http.ListenAndServe(":"+cfg.Port, r))
 }
@@ -1,15 +1,38 @@
 package middleware
 
 import (
+	"context"
+	"employee-asset-system/models"
 	"net/http"
 	"strings"
 
 	"github.com/golang-jwt/jwt/v4"
 )
 
-var jwtKey = []byte("your_secret_key")
+// Claims is the JWT payload issued at login: the standard claims with the
+// employee's emp_id as Subject, plus the roles they held when signing in and
+// the login session (refresh token family) the token belongs to.
+type Claims struct {
+	Roles     []string `json:"roles,omitempty"`
+	SessionID string   `json:"sid"`
+	jwt.RegisteredClaims
+}
+
+// SessionChecker reports whether a login session has been revoked, either by
+// logout or because one of its refresh tokens was reused.
+type SessionChecker interface {
+	IsTokenFamilyRevoked(ctx context.Context, familyID string) (bool, error)
+}
+
+// AuthMiddleware verifies the bearer access token against keys and rejects
+// tokens whose session has been revoked.
+func AuthMiddleware(keys *KeySet, sessions SessionChecker) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return authMiddleware(keys, sessions, next)
+	}
+}
 
-func AuthMiddleware(next http.Handler) http.Handler {
+func authMiddleware(keys *KeySet, sessions SessionChecker, next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		authHeader := r.Header.Get("Authorization")
 		if authHeader == "" {
@@ -17,17 +40,36 @@ func AuthMiddleware(next http.Handler) http.Handler {
 			return
 		}
 
-		tokenString := strings.Split(authHeader, " ")[1]
-		claims := &jwt.RegisteredClaims{}
-		token, err := jwt.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (interface{}, error) {
-			return jwtKey, nil
-		})
+		parts := strings.Split(authHeader, " ")
+		if len(parts) != 2 {
+			http.Error(w, "Invalid token", http.StatusUnauthorized)
+			return
+		}
+		tokenString := parts[1]
+		claims := &Claims{}
+		token, err := jwt.ParseWithClaims(tokenString, claims, keys.Keyfunc)
 
-		if err != nil || !token.Valid {
+		if err != nil || !token.Valid || claims.SessionID == "" {
 			http.Error(w, "Invalid token", http.StatusUnauthorized)
 			return
 		}
 
-		next.ServeHTTP(w, r)
+		revoked, err := sessions.IsTokenFamilyRevoked(r.Context(), claims.SessionID)
+		if err != nil {
+			http.Error(w, "Failed to verify token", http.StatusInternalServerError)
+			return
+		}
+		if revoked {
+			http.Error(w, "Invalid token", http.StatusUnauthorized)
+			return
+		}
+
+		// Tokens that carry no roles belong to a regular employee.
+		roles := claims.Roles
+		if len(roles) == 0 {
+			roles = []string{models.RoleEmployee}
+		}
+		principal := &Principal{EmpID: claims.Subject, Roles: roles, TokenID: claims.ID, SessionID: claims.SessionID}
+		next.ServeHTTP(w, r.WithContext(WithPrincipal(r.Context(), principal)))
 	})
 }
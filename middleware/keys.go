@@ -0,0 +1,270 @@
+package middleware
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"math/big"
+	"os"
+	"sort"
+
+	"github.com/golang-jwt/jwt/v4"
+)
+
+// SigningKey is one entry of a KeySet. Verify holds the key used to check
+// signatures; Sign is nil for keys that are only kept around to verify
+// tokens issued before a rotation.
+type SigningKey struct {
+	ID     string
+	Method jwt.SigningMethod
+	Sign   interface{} // []byte, *rsa.PrivateKey or *ecdsa.PrivateKey
+	Verify interface{} // []byte, *rsa.PublicKey or *ecdsa.PublicKey
+}
+
+// KeySet holds every key tokens may be signed with, indexed by `kid`.
+// Exactly one of them is the active key used for new tokens; the others
+// still verify tokens issued before a rotation.
+type KeySet struct {
+	active *SigningKey
+	keys   map[string]*SigningKey
+}
+
+// NewKeySet builds a KeySet from keys, signing new tokens with the key whose
+// ID is active.
+func NewKeySet(active string, keys ...*SigningKey) (*KeySet, error) {
+	ks := &KeySet{keys: make(map[string]*SigningKey)}
+	for _, key := range keys {
+		if key.ID == "" {
+			return nil, errors.New("signing key without kid")
+		}
+		if _, dup := ks.keys[key.ID]; dup {
+			return nil, fmt.Errorf("duplicate signing key %q", key.ID)
+		}
+		ks.keys[key.ID] = key
+	}
+
+	ks.active = ks.keys[active]
+	if ks.active == nil {
+		return nil, fmt.Errorf("active signing key %q is not configured", active)
+	}
+	if ks.active.Sign == nil {
+		return nil, fmt.Errorf("active signing key %q has no private key", active)
+	}
+	return ks, nil
+}
+
+// Sign returns claims signed with the active key, with its ID in the `kid`
+// header.
+func (ks *KeySet) Sign(claims jwt.Claims) (string, error) {
+	token := jwt.NewWithClaims(ks.active.Method, claims)
+	token.Header["kid"] = ks.active.ID
+	return token.SignedString(ks.active.Sign)
+}
+
+// Keyfunc picks the verification key named by the token's `kid` header. The
+// token's algorithm must match the key's, so an RSA public key can never be
+// used as an HMAC secret.
+func (ks *KeySet) Keyfunc(token *jwt.Token) (interface{}, error) {
+	kid, _ := token.Header["kid"].(string)
+	key, ok := ks.keys[kid]
+	if !ok {
+		return nil, fmt.Errorf("unknown signing key %q", kid)
+	}
+	if token.Method.Alg() != key.Method.Alg() {
+		return nil, fmt.Errorf("signing key %q does not use %s", kid, token.Method.Alg())
+	}
+	return key.Verify, nil
+}
+
+// JWK is a public key in JSON Web Key format (RFC 7517).
+type JWK struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	N   string `json:"n,omitempty"`
+	E   string `json:"e,omitempty"`
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+	Y   string `json:"y,omitempty"`
+}
+
+// JWKS is the document served at /.well-known/jwks.json.
+type JWKS struct {
+	Keys []JWK `json:"keys"`
+}
+
+// JWKS returns the public half of every asymmetric key. HMAC secrets are
+// never published.
+func (ks *KeySet) JWKS() JWKS {
+	set := JWKS{Keys: []JWK{}}
+	for _, key := range ks.keys {
+		switch pub := key.Verify.(type) {
+		case *rsa.PublicKey:
+			set.Keys = append(set.Keys, JWK{
+				Kty: "RSA",
+				Kid: key.ID,
+				Use: "sig",
+				Alg: key.Method.Alg(),
+				N:   base64.RawURLEncoding.EncodeToString(pub.N.Bytes()),
+				E:   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(pub.E)).Bytes()),
+			})
+		case *ecdsa.PublicKey:
+			size := (pub.Curve.Params().BitSize + 7) / 8
+			set.Keys = append(set.Keys, JWK{
+				Kty: "EC",
+				Kid: key.ID,
+				Use: "sig",
+				Alg: key.Method.Alg(),
+				Crv: pub.Curve.Params().Name,
+				X:   base64.RawURLEncoding.EncodeToString(pub.X.FillBytes(make([]byte, size))),
+				Y:   base64.RawURLEncoding.EncodeToString(pub.Y.FillBytes(make([]byte, size))),
+			})
+		}
+	}
+	sort.Slice(set.Keys, func(i, j int) bool { return set.Keys[i].Kid < set.Keys[j].Kid })
+	return set
+}
+
+// keyFileEntry is one key in the JSON keys file.
+type keyFileEntry struct {
+	KID            string `json:"kid"`
+	Alg            string `json:"alg"`                        // HS256, RS256 or ES256
+	Secret         string `json:"secret,omitempty"`           // HS256 only
+	PrivateKeyFile string `json:"private_key_file,omitempty"` // PEM, RS256/ES256
+	PublicKeyFile  string `json:"public_key_file,omitempty"`  // PEM, for verify-only keys
+}
+
+// keyFile is the layout of the file named by the -jwt-keys-file flag:
+//
+//	{
+//	  "active": "2026-10",
+//	  "keys": [
+//	    {"kid": "2026-10", "alg": "RS256", "private_key_file": "/etc/eas/2026-10.pem"},
+//	    {"kid": "2026-04", "alg": "RS256", "public_key_file": "/etc/eas/2026-04.pub.pem"},
+//	    {"kid": "legacy", "alg": "HS256", "secret": "..."}
+//	  ]
+//	}
+type keyFile struct {
+	Active string         `json:"active"`
+	Keys   []keyFileEntry `json:"keys"`
+}
+
+// LoadKeySet reads the keys file at path. When path is empty a single HS256
+// key is built from secret instead, and when that is empty too a random
+// secret is generated, which means tokens do not survive a restart.
+func LoadKeySet(path, secret string) (*KeySet, error) {
+	if path == "" {
+		if secret == "" {
+			log.Println("No JWT signing key configured, using a random key; tokens will not survive a restart")
+			buf := make([]byte, 32)
+			if _, err := rand.Read(buf); err != nil {
+				return nil, err
+			}
+			secret = string(buf)
+		}
+		return NewKeySet("default", &SigningKey{
+			ID:     "default",
+			Method: jwt.SigningMethodHS256,
+			Sign:   []byte(secret),
+			Verify: []byte(secret),
+		})
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var file keyFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("parse %s: %w", path, err)
+	}
+
+	keys := make([]*SigningKey, 0, len(file.Keys))
+	for _, entry := range file.Keys {
+		key, err := loadKey(entry)
+		if err != nil {
+			return nil, fmt.Errorf("key %q: %w", entry.KID, err)
+		}
+		keys = append(keys, key)
+	}
+	return NewKeySet(file.Active, keys...)
+}
+
+func loadKey(entry keyFileEntry) (*SigningKey, error) {
+	key := &SigningKey{ID: entry.KID}
+	switch entry.Alg {
+	case "HS256":
+		if entry.Secret == "" {
+			return nil, errors.New("HS256 keys need a secret")
+		}
+		key.Method = jwt.SigningMethodHS256
+		key.Sign = []byte(entry.Secret)
+		key.Verify = []byte(entry.Secret)
+
+	case "RS256":
+		key.Method = jwt.SigningMethodRS256
+		if entry.PrivateKeyFile != "" {
+			pem, err := os.ReadFile(entry.PrivateKeyFile)
+			if err != nil {
+				return nil, err
+			}
+			private, err := jwt.ParseRSAPrivateKeyFromPEM(pem)
+			if err != nil {
+				return nil, err
+			}
+			key.Sign, key.Verify = private, &private.PublicKey
+		} else if entry.PublicKeyFile != "" {
+			pem, err := os.ReadFile(entry.PublicKeyFile)
+			if err != nil {
+				return nil, err
+			}
+			public, err := jwt.ParseRSAPublicKeyFromPEM(pem)
+			if err != nil {
+				return nil, err
+			}
+			key.Verify = public
+		} else {
+			return nil, errors.New("RS256 keys need a private_key_file or public_key_file")
+		}
+
+	case "ES256":
+		key.Method = jwt.SigningMethodES256
+		if entry.PrivateKeyFile != "" {
+			pem, err := os.ReadFile(entry.PrivateKeyFile)
+			if err != nil {
+				return nil, err
+			}
+			private, err := jwt.ParseECPrivateKeyFromPEM(pem)
+			if err != nil {
+				return nil, err
+			}
+			key.Sign, key.Verify = private, &private.PublicKey
+		} else if entry.PublicKeyFile != "" {
+			pem, err := os.ReadFile(entry.PublicKeyFile)
+			if err != nil {
+				return nil, err
+			}
+			public, err := jwt.ParseECPublicKeyFromPEM(pem)
+			if err != nil {
+				return nil, err
+			}
+			key.Verify = public
+		} else {
+			return nil, errors.New("ES256 keys need a private_key_file or public_key_file")
+		}
+		if key.Verify.(*ecdsa.PublicKey).Curve != elliptic.P256() {
+			return nil, errors.New("ES256 keys must use the P-256 curve")
+		}
+
+	default:
+		return nil, fmt.Errorf("unsupported algorithm %q", entry.Alg)
+	}
+	return key, nil
+}
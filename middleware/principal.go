@@ -0,0 +1,42 @@
+package middleware
+
+import "context"
+
+// Principal is the authenticated caller of a request, as established by
+// AuthMiddleware from the access token.
+type Principal struct {
+	EmpID     string
+	Roles     []string
+	TokenID   string
+	SessionID string
+}
+
+// HasAnyRole reports whether the principal holds at least one of roles.
+func (p *Principal) HasAnyRole(roles ...string) bool {
+	for _, held := range p.Roles {
+		for _, role := range roles {
+			if held == role {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+type contextKey int
+
+const (
+	principalKey contextKey = iota
+	requestIDKey
+)
+
+// WithPrincipal returns a copy of ctx carrying p.
+func WithPrincipal(ctx context.Context, p *Principal) context.Context {
+	return context.WithValue(ctx, principalKey, p)
+}
+
+// PrincipalFromContext returns the caller attached by AuthMiddleware, if any.
+func PrincipalFromContext(ctx context.Context) (*Principal, bool) {
+	p, ok := ctx.Value(principalKey).(*Principal)
+	return p, ok
+}
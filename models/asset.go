@@ -7,10 +7,26 @@ import (
 )
 
 type Asset struct {
-	ID        primitive.ObjectID `bson:"_id,omitempty" json:"id,omitempty"`
-	AssetID   string             `bson:"asset_id" json:"asset_id"`
-	AssetName string             `bson:"asset_name" json:"asset_name"`
-	AssetType string             `bson:"asset_type" json:"asset_type"`
-	CreatedAt time.Time          `bson:"created_at" json:"created_at"`
-	UpdatedAt time.Time          `bson:"updated_at" json:"updated_at"`
+	ID             primitive.ObjectID `bson:"_id,omitempty" json:"id,omitempty"`
+	AssetID        string             `bson:"asset_id" json:"asset_id"`
+	AssetName      string             `bson:"asset_name" json:"asset_name"`
+	AssetType      string             `bson:"asset_type" json:"asset_type"`
+	Shared         bool               `bson:"shared" json:"shared"` // shared assets may be assigned to several employees at once
+	PurchaseCost   float64            `bson:"purchase_cost" json:"purchase_cost"`
+	Currency       string             `bson:"currency" json:"currency"`           // ISO 4217 code of PurchaseCost
+	AssetTag       string             `bson:"asset_tag" json:"asset_tag"`         // label generated at intake; unique when set
+	SerialNumber   string             `bson:"serial_number" json:"serial_number"` // unique when set
+	Manufacturer   string             `bson:"manufacturer" json:"manufacturer"`
+	Model          string             `bson:"model" json:"model"`
+	Vendor         string             `bson:"vendor" json:"vendor"` // who the asset was bought from
+	InvoiceNumber  string             `bson:"invoice_number" json:"invoice_number"`
+	PurchaseDate   *time.Time         `bson:"purchase_date,omitempty" json:"purchase_date,omitempty"`
+	WarrantyExpiry *time.Time         `bson:"warranty_expiry,omitempty" json:"warranty_expiry,omitempty"`
+	CreatedAt      time.Time          `bson:"created_at" json:"created_at"`
+	UpdatedAt      time.Time          `bson:"updated_at" json:"updated_at"`
+	CreatedBy      string             `bson:"created_by,omitempty" json:"created_by,omitempty"`
+	UpdatedBy      string             `bson:"updated_by,omitempty" json:"updated_by,omitempty"`
+	DeletedAt      *time.Time         `bson:"deleted_at,omitempty" json:"deleted_at,omitempty"`
+	DeletedBy      string             `bson:"deleted_by,omitempty" json:"deleted_by,omitempty"`
+	Version        int64              `bson:"version" json:"version"` // bumped by every write, used for optimistic locking
 }
@@ -0,0 +1,48 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Actions recorded in the audit log.
+const (
+	AuditActionCreate   = "create"
+	AuditActionUpdate   = "update"
+	AuditActionDelete   = "delete"
+	AuditActionRestore  = "restore"
+	AuditActionPurge    = "purge"
+	AuditActionAssign   = "assign"
+	AuditActionReturn   = "return"
+	AuditActionRollback = "rollback"
+)
+
+// Entity types recorded in the audit log.
+const (
+	AuditEntityEmployee = "employee"
+	AuditEntityAsset    = "asset"
+	AuditEntityMapping  = "mapping"
+)
+
+// FieldChange is the value of one field before and after a change. Before is
+// nil for created fields and After is nil for removed ones.
+type FieldChange struct {
+	Before interface{} `bson:"before" json:"before"`
+	After  interface{} `bson:"after" json:"after"`
+}
+
+// AuditEntry records one change made through the API. Entries are only ever
+// appended, never updated or deleted.
+type AuditEntry struct {
+	ID         primitive.ObjectID     `bson:"_id,omitempty" json:"-"`
+	EntryID    string                 `bson:"entry_id" json:"entry_id"`
+	Timestamp  time.Time              `bson:"timestamp" json:"timestamp"`
+	ActorID    string                 `bson:"actor_id" json:"actor_id"`
+	Action     string                 `bson:"action" json:"action"`
+	EntityType string                 `bson:"entity_type" json:"entity_type"`
+	EntityID   string                 `bson:"entity_id" json:"entity_id"`
+	Changes    map[string]FieldChange `bson:"changes" json:"changes"` // keyed by JSON field name
+	RequestID  string                 `bson:"request_id" json:"request_id"`
+	SourceIP   string                 `bson:"source_ip" json:"source_ip"`
+}
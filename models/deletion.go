@@ -0,0 +1,16 @@
+package models
+
+import "time"
+
+// Deletion describes a soft delete. The record is kept as a tombstone until
+// it is purged, and can be restored until then.
+type Deletion struct {
+	DeletedAt time.Time
+	DeletedBy string
+	// Cascade closes the record's active mappings; when nil the delete is
+	// refused while there are any.
+	Cascade *MappingReturn
+	// IfVersion, when non-zero, refuses the delete unless the record is
+	// still at this version.
+	IfVersion int64
+}
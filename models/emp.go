@@ -17,24 +17,61 @@ type Employee struct {
 	Address                string             `bson:"address" json:"address"`
 	BloodGroup             string             `bson:"blood_group" json:"blood_group"`
 	EmergencyContactNumber string             `bson:"emergency_contact_number" json:"emergency_contact_number"`
+	Department             string             `bson:"department" json:"department"`
 	Password               string             `bson:"password" json:"password,omitempty"` // Use `omitempty` to exclude in JSON responses.
+	Roles                  []string           `bson:"roles" json:"roles"`
 	CreatedAt              time.Time          `bson:"created_at" json:"created_at"`
 	UpdatedAt              time.Time          `bson:"updated_at" json:"updated_at"`
+	CreatedBy              string             `bson:"created_by,omitempty" json:"created_by,omitempty"`
+	UpdatedBy              string             `bson:"updated_by,omitempty" json:"updated_by,omitempty"`
+	DeletedAt              *time.Time         `bson:"deleted_at,omitempty" json:"deleted_at,omitempty"`
+	DeletedBy              string             `bson:"deleted_by,omitempty" json:"deleted_by,omitempty"`
+	Version                int64              `bson:"version" json:"version"` // bumped by every write, used for optimistic locking
+}
+
+// Roles an employee can hold. They are carried in the JWT and checked per route.
+const (
+	RoleAdmin          = "admin"
+	RoleITAssetManager = "it-asset-manager"
+	RoleManager        = "manager"
+	RoleEmployee       = "employee"
+)
+
+// IsValidRole reports whether role is one of the known roles.
+func IsValidRole(role string) bool {
+	switch role {
+	case RoleAdmin, RoleITAssetManager, RoleManager, RoleEmployee:
+		return true
+	}
+	return false
 }
 
 type DashboardEmployee struct {
-	EmpId                  string `bson:"emp_id" json:"EmpId"`
-	FirstName              string `bson:"first_name" json:"FirstName"`
-	LastName               string `bson:"last_name" json:"LastName"`
-	Gender                 string `bson:"gender" json:"Gender"`
-	PhoneNumber            string `bson:"phone_number" json:"PhoneNumber"`
-	EmployeeEmail          string `bson:"employee_email" json:"EmployeeEmail"`
-	Address                string `bson:"address" json:"Address"`
-	BloodGroup             string `bson:"blood_group" json:"BloodGroup"`
-	EmergencyContactNumber string `bson:"emergency_contact_number" json:"EmergencyContactNumber"`
-	AssetCount             int    `bson:"asset_count" json:"AssetCount"`
+	EmpId                  string    `bson:"emp_id" json:"EmpId"`
+	FirstName              string    `bson:"first_name" json:"FirstName"`
+	LastName               string    `bson:"last_name" json:"LastName"`
+	Gender                 string    `bson:"gender" json:"Gender"`
+	PhoneNumber            string    `bson:"phone_number" json:"PhoneNumber"`
+	EmployeeEmail          string    `bson:"employee_email" json:"EmployeeEmail"`
+	Address                string    `bson:"address" json:"Address"`
+	BloodGroup             string    `bson:"blood_group" json:"BloodGroup"`
+	EmergencyContactNumber string    `bson:"emergency_contact_number" json:"EmergencyContactNumber"`
+	Department             string    `bson:"department" json:"Department"`
+	AssetCount             int       `bson:"asset_count" json:"AssetCount"` // assets currently assigned
+	CreatedAt              time.Time `bson:"created_at" json:"CreatedAt"`
+	UpdatedAt              time.Time `bson:"updated_at" json:"UpdatedAt"`
+	// AssetsByType counts the assets currently assigned by asset type.
+	AssetsByType map[string]int `bson:"-" json:"AssetsByType"`
+	// AssignedValue totals the purchase cost of the assets currently
+	// assigned, by currency.
+	AssignedValue map[string]float64 `bson:"-" json:"AssignedValue"`
+	// LastAssignedAt is when the employee was last assigned an asset, nil
+	// if never.
+	LastAssignedAt *time.Time `bson:"last_assigned_at,omitempty" json:"LastAssignedAt,omitempty"`
 }
 
 type EmployeeList struct {
-	Employees []DashboardEmployee `bson:"employees" json:"EmployeeList"`
+	Employees     []DashboardEmployee `bson:"employees" json:"EmployeeList"`
+	NextPageToken string              `bson:"-" json:"NextPageToken,omitempty"` // empty on the last page
+	Total         int64               `bson:"-" json:"Total"`
 }
@@ -6,12 +6,50 @@ import (
 	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
+// Mapping statuses. A mapping starts active when the asset is checked out
+// and moves to one of the closed statuses when it comes back (or does not).
+// Closed mappings are kept as the assignment history of the asset.
+const (
+	MappingStatusActive   = "active"
+	MappingStatusReturned = "returned"
+	MappingStatusLost     = "lost"
+	MappingStatusDamaged  = "damaged"
+)
+
+// IsClosedMappingStatus reports whether status ends an assignment.
+func IsClosedMappingStatus(status string) bool {
+	switch status {
+	case MappingStatusReturned, MappingStatusLost, MappingStatusDamaged:
+		return true
+	}
+	return false
+}
+
 type EmployeeAssetMapping struct {
-	ID           primitive.ObjectID `bson:"_id,omitempty" json:"id,omitempty"`
-	MappingID    string             `bson:"mapping_id" json:"mapping_id"`
-	EmployeeID   string             `bson:"employee_id" json:"employee_id"`
-	AssetID      string             `bson:"asset_id" json:"asset_id"`
-	AssignedDate time.Time          `bson:"assigned_date" json:"assigned_date"`
-	Status       string             `bson:"status" json:"status"`
-	Notes        string             `bson:"notes" json:"notes"`
+	ID              primitive.ObjectID `bson:"_id,omitempty" json:"id,omitempty"`
+	MappingID       string             `bson:"mapping_id" json:"mapping_id"`
+	EmployeeID      string             `bson:"employee_id" json:"employee_id"`
+	AssetID         string             `bson:"asset_id" json:"asset_id"`
+	AssignedDate    time.Time          `bson:"assigned_date" json:"assigned_date"`
+	AssignedBy      string             `bson:"assigned_by,omitempty" json:"assigned_by,omitempty"`
+	Status          string             `bson:"status" json:"status"`
+	Exclusive       bool               `bson:"exclusive" json:"exclusive"` // copied from !Asset.Shared at checkout
+	Notes           string             `bson:"notes" json:"notes"`
+	ReturnedDate    *time.Time         `bson:"returned_date,omitempty" json:"returned_date,omitempty"`
+	ReturnedBy      string             `bson:"returned_by,omitempty" json:"returned_by,omitempty"`
+	ReturnCondition string             `bson:"return_condition,omitempty" json:"return_condition,omitempty"`
+	CloseReason     string             `bson:"close_reason,omitempty" json:"close_reason,omitempty"`
+	Version         int64              `bson:"version" json:"version"` // bumped by every write, used for optimistic locking
+}
+
+// MappingReturn describes how an active mapping is closed.
+type MappingReturn struct {
+	Status       string // one of the closed statuses
+	ReturnedDate time.Time
+	ReturnedBy   string
+	Condition    string // condition of the asset on return, free text
+	Reason       string // why the mapping was closed, free text
+	// IfVersion, when non-zero, refuses the return unless the mapping is
+	// still at this version. Cascading deletes ignore it.
+	IfVersion int64
 }
@@ -0,0 +1,312 @@
+package models
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// EmployeePatch holds the employee fields a client may set. Nil fields are
+// left unchanged by Apply and cleared by Replace. Anything else in a request
+// body, such as emp_id or created_at, is rejected.
+type EmployeePatch struct {
+	FirstName              *string   `json:"first_name"`
+	LastName               *string   `json:"last_name"`
+	Gender                 *string   `json:"gender"`
+	PhoneNumber            *string   `json:"phone_number"`
+	EmployeeEmail          *string   `json:"employee_email"`
+	Address                *string   `json:"address"`
+	BloodGroup             *string   `json:"blood_group"`
+	EmergencyContactNumber *string   `json:"emergency_contact_number"`
+	Department             *string   `json:"department"`
+	Password               *string   `json:"password,omitempty"` // write-only plaintext; hashed by the caller before it is stored
+	Roles                  *[]string `json:"roles"`
+}
+
+// Validate checks every field set in p. When creating, the required fields
+// must be set as well.
+func (p *EmployeePatch) Validate(creating bool) ValidationErrors {
+	var errs ValidationErrors
+	requireName := func(field string, value *string) {
+		if value == nil && !creating {
+			return
+		}
+		if value == nil || strings.TrimSpace(*value) == "" {
+			errs.Add(field, "is required")
+		}
+	}
+	requireName("first_name", p.FirstName)
+	requireName("last_name", p.LastName)
+
+	if p.Gender != nil && *p.Gender != "" && !isOneOf(*p.Gender, Genders) {
+		errs.Add("gender", "must be one of "+strings.Join(Genders, ", "))
+	}
+	if p.PhoneNumber != nil && *p.PhoneNumber != "" && !IsValidPhone(*p.PhoneNumber) {
+		errs.Add("phone_number", "must be an E.164 phone number such as +14155550123")
+	}
+	if p.EmployeeEmail != nil && *p.EmployeeEmail != "" && !IsValidEmail(*p.EmployeeEmail) {
+		errs.Add("employee_email", "must be a valid email address")
+	}
+	if p.BloodGroup != nil && *p.BloodGroup != "" && !isOneOf(*p.BloodGroup, BloodGroups) {
+		errs.Add("blood_group", "must be one of "+strings.Join(BloodGroups, ", "))
+	}
+	if p.EmergencyContactNumber != nil && *p.EmergencyContactNumber != "" && !IsValidPhone(*p.EmergencyContactNumber) {
+		errs.Add("emergency_contact_number", "must be an E.164 phone number such as +14155550123")
+	}
+	if p.Password != nil && len(*p.Password) < MinPasswordLength {
+		errs.Add("password", fmt.Sprintf("must be at least %d characters long", MinPasswordLength))
+	}
+	if p.Roles != nil {
+		for _, role := range *p.Roles {
+			if !IsValidRole(role) {
+				errs.Add("roles", "contains unknown role "+role)
+			}
+		}
+	}
+	return errs
+}
+
+// Apply copies the fields set in p onto employee, except the password,
+// which the caller hashes itself.
+func (p *EmployeePatch) Apply(employee *Employee) {
+	setString(&employee.FirstName, p.FirstName)
+	setString(&employee.LastName, p.LastName)
+	setString(&employee.Gender, p.Gender)
+	setString(&employee.PhoneNumber, p.PhoneNumber)
+	setString(&employee.EmployeeEmail, p.EmployeeEmail)
+	setString(&employee.Address, p.Address)
+	setString(&employee.BloodGroup, p.BloodGroup)
+	setString(&employee.EmergencyContactNumber, p.EmergencyContactNumber)
+	setString(&employee.Department, p.Department)
+	if p.Roles != nil {
+		employee.Roles = append([]string(nil), *p.Roles...)
+	}
+}
+
+// Replace overwrites every editable field of employee with p, clearing the
+// ones p leaves nil. The password is left to the caller.
+func (p *EmployeePatch) Replace(employee *Employee) {
+	employee.FirstName, employee.LastName, employee.Gender = "", "", ""
+	employee.PhoneNumber, employee.EmployeeEmail, employee.Address = "", "", ""
+	employee.BloodGroup, employee.EmergencyContactNumber, employee.Department = "", "", ""
+	employee.Roles = nil
+	p.Apply(employee)
+}
+
+// NewEmployeePatch returns the editable fields of employee, the document
+// PATCH requests are applied to. The password is never included.
+func NewEmployeePatch(employee *Employee) EmployeePatch {
+	roles := append([]string{}, employee.Roles...)
+	return EmployeePatch{
+		FirstName:              stringPtr(employee.FirstName),
+		LastName:               stringPtr(employee.LastName),
+		Gender:                 stringPtr(employee.Gender),
+		PhoneNumber:            stringPtr(employee.PhoneNumber),
+		EmployeeEmail:          stringPtr(employee.EmployeeEmail),
+		Address:                stringPtr(employee.Address),
+		BloodGroup:             stringPtr(employee.BloodGroup),
+		EmergencyContactNumber: stringPtr(employee.EmergencyContactNumber),
+		Department:             stringPtr(employee.Department),
+		Roles:                  &roles,
+	}
+}
+
+// AssetPatch holds the asset fields a client may set. Dates are given in
+// DateLayout; an empty one clears the date.
+type AssetPatch struct {
+	AssetName      *string  `json:"asset_name"`
+	AssetType      *string  `json:"asset_type"`
+	Shared         *bool    `json:"shared"`
+	PurchaseCost   *float64 `json:"purchase_cost"`
+	Currency       *string  `json:"currency"`
+	SerialNumber   *string  `json:"serial_number"` // unique among all assets, deleted ones included
+	Manufacturer   *string  `json:"manufacturer"`
+	Model          *string  `json:"model"`
+	Vendor         *string  `json:"vendor"`
+	InvoiceNumber  *string  `json:"invoice_number"`
+	PurchaseDate   *string  `json:"purchase_date"`
+	WarrantyExpiry *string  `json:"warranty_expiry"`
+}
+
+// Validate checks every field set in p. When creating, the required fields
+// must be set as well.
+func (p *AssetPatch) Validate(creating bool) ValidationErrors {
+	var errs ValidationErrors
+	for _, f := range []struct {
+		field string
+		value *string
+	}{{"asset_name", p.AssetName}, {"asset_type", p.AssetType}} {
+		if f.value == nil && !creating {
+			continue
+		}
+		if f.value == nil || strings.TrimSpace(*f.value) == "" {
+			errs.Add(f.field, "is required")
+		}
+	}
+	if p.PurchaseCost != nil && *p.PurchaseCost < 0 {
+		errs.Add("purchase_cost", "must not be negative")
+	}
+	if p.Currency != nil && *p.Currency != "" && !IsValidCurrency(*p.Currency) {
+		errs.Add("currency", "must be an ISO 4217 code such as USD")
+	}
+	if p.PurchaseCost != nil && *p.PurchaseCost > 0 && (p.Currency == nil || *p.Currency == "") {
+		errs.Add("currency", "is required with a purchase_cost")
+	}
+	if p.SerialNumber != nil && *p.SerialNumber != "" && !IsValidSerialNumber(*p.SerialNumber) {
+		errs.Add("serial_number", "must be up to 64 printable characters without spaces")
+	}
+	for _, f := range []struct {
+		field string
+		value *string
+	}{{"manufacturer", p.Manufacturer}, {"model", p.Model}, {"vendor", p.Vendor}, {"invoice_number", p.InvoiceNumber}} {
+		if f.value != nil && len(*f.value) > MaxTextLength {
+			errs.Add(f.field, fmt.Sprintf("must be at most %d characters long", MaxTextLength))
+		}
+	}
+
+	purchased, purchasedOK := p.date(p.PurchaseDate)
+	if !purchasedOK {
+		errs.Add("purchase_date", "must be a date such as 2026-01-31")
+	} else if purchased != nil && purchased.After(time.Now()) {
+		errs.Add("purchase_date", "must not be in the future")
+	}
+	expiry, expiryOK := p.date(p.WarrantyExpiry)
+	if !expiryOK {
+		errs.Add("warranty_expiry", "must be a date such as 2026-01-31")
+	} else if expiry != nil && purchased != nil && expiry.Before(*purchased) {
+		errs.Add("warranty_expiry", "must not be before the purchase_date")
+	}
+	return errs
+}
+
+// date parses one of the date fields of p. It returns nil for an unset or
+// empty date and false for an invalid one.
+func (p *AssetPatch) date(value *string) (*time.Time, bool) {
+	if value == nil || *value == "" {
+		return nil, true
+	}
+	t, ok := ParseDate(*value)
+	if !ok {
+		return nil, false
+	}
+	return &t, true
+}
+
+// Apply copies the fields set in p onto asset. The dates must be valid.
+func (p *AssetPatch) Apply(asset *Asset) {
+	setString(&asset.AssetName, p.AssetName)
+	setString(&asset.AssetType, p.AssetType)
+	if p.Shared != nil {
+		asset.Shared = *p.Shared
+	}
+	if p.PurchaseCost != nil {
+		asset.PurchaseCost = *p.PurchaseCost
+	}
+	setString(&asset.Currency, p.Currency)
+	setString(&asset.SerialNumber, p.SerialNumber)
+	setString(&asset.Manufacturer, p.Manufacturer)
+	setString(&asset.Model, p.Model)
+	setString(&asset.Vendor, p.Vendor)
+	setString(&asset.InvoiceNumber, p.InvoiceNumber)
+	if p.PurchaseDate != nil {
+		asset.PurchaseDate, _ = p.date(p.PurchaseDate)
+	}
+	if p.WarrantyExpiry != nil {
+		asset.WarrantyExpiry, _ = p.date(p.WarrantyExpiry)
+	}
+}
+
+// Replace overwrites every editable field of asset with p, clearing the ones
+// p leaves nil. The asset tag is not editable and is kept.
+func (p *AssetPatch) Replace(asset *Asset) {
+	asset.AssetName, asset.AssetType, asset.Shared = "", "", false
+	asset.PurchaseCost, asset.Currency = 0, ""
+	asset.SerialNumber, asset.Manufacturer, asset.Model, asset.Vendor, asset.InvoiceNumber = "", "", "", "", ""
+	asset.PurchaseDate, asset.WarrantyExpiry = nil, nil
+	p.Apply(asset)
+}
+
+// NewAssetPatch returns the editable fields of asset, the document PATCH
+// requests are applied to.
+func NewAssetPatch(asset *Asset) AssetPatch {
+	shared, cost := asset.Shared, asset.PurchaseCost
+	return AssetPatch{
+		AssetName:      stringPtr(asset.AssetName),
+		AssetType:      stringPtr(asset.AssetType),
+		Shared:         &shared,
+		PurchaseCost:   &cost,
+		Currency:       stringPtr(asset.Currency),
+		SerialNumber:   stringPtr(asset.SerialNumber),
+		Manufacturer:   stringPtr(asset.Manufacturer),
+		Model:          stringPtr(asset.Model),
+		Vendor:         stringPtr(asset.Vendor),
+		InvoiceNumber:  stringPtr(asset.InvoiceNumber),
+		PurchaseDate:   datePtr(asset.PurchaseDate),
+		WarrantyExpiry: datePtr(asset.WarrantyExpiry),
+	}
+}
+
+// MappingPatch holds the mapping fields a client may edit. The status and
+// the assignment itself only change by returning the asset.
+type MappingPatch struct {
+	Notes           *string `json:"notes"`
+	ReturnCondition *string `json:"return_condition"`
+	CloseReason     *string `json:"close_reason"`
+}
+
+// Validate checks p against the mapping it is applied to: only closed
+// mappings have a return condition and close reason.
+func (p *MappingPatch) Validate(mapping *EmployeeAssetMapping) ValidationErrors {
+	var errs ValidationErrors
+	if mapping.Status == MappingStatusActive {
+		if p.ReturnCondition != nil && *p.ReturnCondition != "" {
+			errs.Add("return_condition", "can only be set once the asset is returned")
+		}
+		if p.CloseReason != nil && *p.CloseReason != "" {
+			errs.Add("close_reason", "can only be set once the asset is returned")
+		}
+	}
+	return errs
+}
+
+// Apply copies the fields set in p onto mapping.
+func (p *MappingPatch) Apply(mapping *EmployeeAssetMapping) {
+	setString(&mapping.Notes, p.Notes)
+	setString(&mapping.ReturnCondition, p.ReturnCondition)
+	setString(&mapping.CloseReason, p.CloseReason)
+}
+
+// Replace overwrites every editable field of mapping with p, clearing the
+// ones p leaves nil.
+func (p *MappingPatch) Replace(mapping *EmployeeAssetMapping) {
+	mapping.Notes, mapping.ReturnCondition, mapping.CloseReason = "", "", ""
+	p.Apply(mapping)
+}
+
+// NewMappingPatch returns the editable fields of mapping, the document PATCH
+// requests are applied to.
+func NewMappingPatch(mapping *EmployeeAssetMapping) MappingPatch {
+	return MappingPatch{
+		Notes:           stringPtr(mapping.Notes),
+		ReturnCondition: stringPtr(mapping.ReturnCondition),
+		CloseReason:     stringPtr(mapping.CloseReason),
+	}
+}
+
+func setString(dst *string, value *string) {
+	if value != nil {
+		*dst = *value
+	}
+}
+
+func stringPtr(s string) *string {
+	return &s
+}
+
+// datePtr formats t in DateLayout, or returns nil for a nil t.
+func datePtr(t *time.Time) *string {
+	if t == nil {
+		return nil
+	}
+	return stringPtr(t.UTC().Format(DateLayout))
+}
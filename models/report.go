@@ -0,0 +1,39 @@
+package models
+
+// Statuses of assets in reports. An asset is assigned while it has an active
+// mapping. Otherwise it is lost or damaged when its latest mapping was closed
+// as such, and available if not.
+const (
+	AssetStatusAssigned  = "assigned"
+	AssetStatusAvailable = "available"
+)
+
+// AssetStatusCount counts the assets of one type in one status.
+type AssetStatusCount struct {
+	AssetType string `bson:"asset_type" json:"asset_type"`
+	Status    string `bson:"status" json:"status"`
+	Count     int64  `bson:"count" json:"count"`
+}
+
+// DepartmentAssignments counts the assignments made to the employees of one
+// department, and how many of them are still active.
+type DepartmentAssignments struct {
+	Department  string `bson:"department" json:"department"` // empty for employees without one
+	Assignments int64  `bson:"assignments" json:"assignments"`
+	Active      int64  `bson:"active" json:"active"`
+}
+
+// AssignmentDuration is how long closed assignments lasted on average.
+type AssignmentDuration struct {
+	ClosedAssignments      int64   `bson:"closed_assignments" json:"closed_assignments"`
+	AverageDurationSeconds float64 `bson:"average_duration_seconds" json:"average_duration_seconds"`
+}
+
+// AssetHolder is an employee and the number of assets they hold.
+type AssetHolder struct {
+	EmployeeID   string `bson:"employee_id" json:"employee_id"`
+	FirstName    string `bson:"first_name" json:"first_name"`
+	LastName     string `bson:"last_name" json:"last_name"`
+	Department   string `bson:"department" json:"department"`
+	ActiveAssets int64  `bson:"active_assets" json:"active_assets"`
+}
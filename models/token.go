@@ -0,0 +1,17 @@
+package models
+
+import "time"
+
+// RefreshToken is the server-side record of an issued refresh token. Only a
+// hash of the token is stored. Every token obtained by rotating another one
+// shares its FamilyID, which is also carried in the access tokens as the
+// session ID, so revoking a family ends the whole login session.
+type RefreshToken struct {
+	TokenHash string     `bson:"token_hash" json:"-"`
+	FamilyID  string     `bson:"family_id" json:"family_id"`
+	EmpID     string     `bson:"emp_id" json:"emp_id"`
+	CreatedAt time.Time  `bson:"created_at" json:"created_at"`
+	ExpiresAt time.Time  `bson:"expires_at" json:"expires_at"`
+	UsedAt    *time.Time `bson:"used_at,omitempty" json:"used_at,omitempty"`
+	RevokedAt *time.Time `bson:"revoked_at,omitempty" json:"revoked_at,omitempty"`
+}
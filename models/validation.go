@@ -0,0 +1,93 @@
+package models
+
+import (
+	"net/mail"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// FieldError describes why one field of a request was rejected.
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// ValidationErrors lists every field a request got wrong.
+type ValidationErrors []FieldError
+
+func (errs ValidationErrors) Error() string {
+	messages := make([]string, len(errs))
+	for i, e := range errs {
+		messages[i] = e.Field + " " + e.Message
+	}
+	return strings.Join(messages, "; ")
+}
+
+// Add records that field was rejected with message.
+func (errs *ValidationErrors) Add(field, message string) {
+	*errs = append(*errs, FieldError{Field: field, Message: message})
+}
+
+// Genders accepted on employee records.
+var Genders = []string{"male", "female", "non-binary", "other", "prefer-not-to-say"}
+
+// BloodGroups accepted on employee records.
+var BloodGroups = []string{"A+", "A-", "B+", "B-", "AB+", "AB-", "O+", "O-"}
+
+// currencyCode matches ISO 4217 currency codes such as USD or INR.
+var currencyCode = regexp.MustCompile(`^[A-Z]{3}$`)
+
+// IsValidCurrency reports whether code looks like an ISO 4217 currency code.
+func IsValidCurrency(code string) bool {
+	return currencyCode.MatchString(code)
+}
+
+// serialNumber matches serial numbers: up to 64 printable ASCII characters
+// without spaces.
+var serialNumber = regexp.MustCompile(`^[!-~]{1,64}$`)
+
+// IsValidSerialNumber reports whether s can be an asset serial number.
+func IsValidSerialNumber(s string) bool {
+	return serialNumber.MatchString(s)
+}
+
+// DateLayout is the format of calendar dates such as purchase dates.
+const DateLayout = "2006-01-02"
+
+// ParseDate parses a date in DateLayout, returning midnight UTC of that day.
+func ParseDate(s string) (time.Time, bool) {
+	t, err := time.Parse(DateLayout, s)
+	return t, err == nil
+}
+
+// MaxTextLength bounds free text fields such as manufacturers and invoice
+// numbers.
+const MaxTextLength = 200
+
+// MinPasswordLength is the shortest password accepted when one is set.
+const MinPasswordLength = 8
+
+// e164 matches phone numbers in E.164 format: a plus sign and up to 15 digits.
+var e164 = regexp.MustCompile(`^\+[1-9][0-9]{1,14}$`)
+
+// IsValidEmail reports whether s is a bare email address such as
+// "jane@example.com".
+func IsValidEmail(s string) bool {
+	addr, err := mail.ParseAddress(s)
+	return err == nil && addr.Address == s && addr.Name == ""
+}
+
+// IsValidPhone reports whether s is an E.164 phone number.
+func IsValidPhone(s string) bool {
+	return e164.MatchString(s)
+}
+
+func isOneOf(s string, values []string) bool {
+	for _, v := range values {
+		if s == v {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,27 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// VersionActionBaseline marks the state a record was found in when its
+// history was first recorded. Other versions use the audit action that
+// produced them: create, update or rollback.
+const VersionActionBaseline = "baseline"
+
+// RecordVersion is a snapshot of an employee or asset as one write left it.
+// Versions are numbered from 1 per record. Snapshots never contain the
+// password hash.
+type RecordVersion struct {
+	ID           primitive.ObjectID     `bson:"_id,omitempty" json:"-"`
+	EntityType   string                 `bson:"entity_type" json:"entity_type"`
+	EntityID     string                 `bson:"entity_id" json:"entity_id"`
+	Version      int                    `bson:"version" json:"version"`
+	Action       string                 `bson:"action" json:"action"`
+	RolledBackTo int                    `bson:"rolled_back_to,omitempty" json:"rolled_back_to,omitempty"` // version a rollback restored
+	ChangedAt    time.Time              `bson:"changed_at" json:"changed_at"`
+	ChangedBy    string                 `bson:"changed_by" json:"changed_by"`
+	Snapshot     map[string]interface{} `bson:"snapshot" json:"snapshot"` // the record as JSON
+}
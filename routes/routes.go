@@ -4,6 +4,7 @@ import (
 	"employee-asset-system/controllers"
 	_ "employee-asset-system/docs"
 	"employee-asset-system/middleware"
+	"employee-asset-system/models"
 	"net/http"
 
 	httpSwagger "github.com/swaggo/http-swagger"
@@ -11,10 +12,15 @@ import (
 	"github.com/gorilla/mux"
 )
 
-func RegisterRoutes(r *mux.Router) {
+func RegisterRoutes(r *mux.Router, s *controllers.Server) {
+
+	r.Use(middleware.RequestID)
 
 	// Public Routes
-	r.HandleFunc("/login/auth", controllers.Login).Methods("POST")
+	r.HandleFunc("/login/auth", s.Login).Methods("POST")
+	r.HandleFunc("/login/refresh", s.Refresh).Methods("POST")
+	r.HandleFunc("/login/logout", s.Logout).Methods("POST")
+	r.HandleFunc("/.well-known/jwks.json", s.GetJWKS).Methods("GET")
 
 	// Swagger endpoint
 	r.PathPrefix("/swagger").Handler(httpSwagger.WrapHandler)
@@ -26,27 +32,69 @@ func RegisterRoutes(r *mux.Router) {
 
 	// Protected Routes
 	api := r.PathPrefix("/api").Subrouter()
-	api.Use(middleware.AuthMiddleware)
+	api.Use(middleware.AuthMiddleware(s.Keys, s.Tokens))
+
+	// Role sets allowed on each group of routes. Regular employees can only
+	// reach their own record and their own mappings.
+	admins := middleware.RequireRole(models.RoleAdmin)
+	assetManagers := middleware.RequireRole(models.RoleAdmin, models.RoleITAssetManager)
+	readers := middleware.RequireRole(models.RoleAdmin, models.RoleITAssetManager, models.RoleManager)
+	readersOrSelf := middleware.RequireRoleOrSelf("employeeId", models.RoleAdmin, models.RoleITAssetManager, models.RoleManager)
 
 	// Employee Routes
-	api.HandleFunc("/employee/createemployee", controllers.CreateEmployee).Methods("POST")
-	api.HandleFunc("/employee/editemployee/{employeeId}", controllers.EditEmployee).Methods("PUT")
-	api.HandleFunc("/employee/deleteemployee/{employeeId}", controllers.DeleteEmployee).Methods("DELETE")
-	api.HandleFunc("/employee/employee/{employeeId}", controllers.GetEmployeeById).Methods("GET")
+	api.Handle("/employee/createemployee", admins(http.HandlerFunc(s.CreateEmployee))).Methods("POST")
+	api.Handle("/employee/import", admins(http.HandlerFunc(s.ImportEmployees))).Methods("POST")
+	api.Handle("/employee/editemployee/{employeeId}", admins(http.HandlerFunc(s.EditEmployee))).Methods("PUT")
+	api.Handle("/employee/editemployee/{employeeId}", admins(http.HandlerFunc(s.PatchEmployee))).Methods("PATCH")
+	api.Handle("/employee/deleteemployee/{employeeId}", admins(http.HandlerFunc(s.DeleteEmployee))).Methods("DELETE")
+	api.Handle("/employee/restoreemployee/{employeeId}", admins(http.HandlerFunc(s.RestoreEmployee))).Methods("POST")
+	api.Handle("/employee/employee/{employeeId}", readersOrSelf(http.HandlerFunc(s.GetEmployeeById))).Methods("GET")
+	api.Handle("/employee/{employeeId}/history", readers(http.HandlerFunc(s.GetEmployeeHistory))).Methods("GET")
+	api.Handle("/employee/{employeeId}/rollback/{version}", admins(http.HandlerFunc(s.RollbackEmployee))).Methods("POST")
 
 	// Asset Routes
-	api.HandleFunc("/asset/createasset", controllers.CreateAsset).Methods("POST")
-	api.HandleFunc("/asset/editasset/{assetId}", controllers.EditAsset).Methods("PUT")
-	api.HandleFunc("/asset/deleteasset/{assetId}", controllers.DeleteAsset).Methods("DELETE")
-	api.HandleFunc("/asset/asset/{assetId}", controllers.GetAssetById).Methods("GET")
-	api.HandleFunc("/asset/getallasset", controllers.GetAllAssets).Methods("GET")
+	api.Handle("/asset/createasset", assetManagers(http.HandlerFunc(s.CreateAsset))).Methods("POST")
+	api.Handle("/asset/editasset/{assetId}", assetManagers(http.HandlerFunc(s.EditAsset))).Methods("PUT")
+	api.Handle("/asset/editasset/{assetId}", assetManagers(http.HandlerFunc(s.PatchAsset))).Methods("PATCH")
+	api.Handle("/asset/deleteasset/{assetId}", assetManagers(http.HandlerFunc(s.DeleteAsset))).Methods("DELETE")
+	api.Handle("/asset/restoreasset/{assetId}", assetManagers(http.HandlerFunc(s.RestoreAsset))).Methods("POST")
+	api.Handle("/asset/intake", assetManagers(http.HandlerFunc(s.IntakeAssets))).Methods("POST")
+	api.Handle("/asset/asset/{assetId}", readers(http.HandlerFunc(s.GetAssetById))).Methods("GET")
+	api.Handle("/asset/getallasset", readers(http.HandlerFunc(s.GetAllAssets))).Methods("GET")
+	api.Handle("/asset/{assetId}/history", readers(http.HandlerFunc(s.GetAssetHistory))).Methods("GET")
+	api.Handle("/asset/{assetId}/rollback/{version}", assetManagers(http.HandlerFunc(s.RollbackAsset))).Methods("POST")
 
 	// Mapping Routes
-	api.HandleFunc("/mapping/assignassetmapping", controllers.AssignAssetMapping).Methods("POST")
-	api.HandleFunc("/mapping/getallassets/{employeeId}", controllers.GetAllAssetsMappedToEmployee).Methods("GET")
-	api.HandleFunc("/mapping/removeassetmapping/{mappingId}", controllers.RemoveAssetMapping).Methods("DELETE")
+	api.Handle("/mapping/assignassetmapping", assetManagers(http.HandlerFunc(s.AssignAssetMapping))).Methods("POST")
+	api.Handle("/mapping/getallassets/{employeeId}", readersOrSelf(http.HandlerFunc(s.GetAllAssetsMappedToEmployee))).Methods("GET")
+	api.Handle("/mapping/returnasset/{mappingId}", assetManagers(http.HandlerFunc(s.ReturnAssetMapping))).Methods("POST")
+	api.Handle("/mapping/mapping/{mappingId}", readers(http.HandlerFunc(s.GetMappingById))).Methods("GET")
+	api.Handle("/mapping/editmapping/{mappingId}", assetManagers(http.HandlerFunc(s.EditMapping))).Methods("PUT")
+	api.Handle("/mapping/editmapping/{mappingId}", assetManagers(http.HandlerFunc(s.PatchMapping))).Methods("PATCH")
+	api.Handle("/mapping/removeassetmapping/{mappingId}", assetManagers(http.HandlerFunc(s.RemoveAssetMapping))).Methods("DELETE")
+	api.Handle("/mapping/bulk/assign", assetManagers(http.HandlerFunc(s.BulkAssign))).Methods("POST")
+	api.Handle("/mapping/bulk/return", assetManagers(http.HandlerFunc(s.BulkReturn))).Methods("POST")
+	api.Handle("/mapping/assethistory/{assetId}", readers(http.HandlerFunc(s.GetAssetMappingHistory))).Methods("GET")
 
 	// Dashboard
-	api.HandleFunc("/dashboard", controllers.GetAllEmployees).Methods("GET")
+	api.Handle("/dashboard", readers(http.HandlerFunc(s.GetAllEmployees))).Methods("GET")
+
+	// Search
+	api.Handle("/search", readers(http.HandlerFunc(s.Search))).Methods("GET")
+
+	// Exports
+	api.Handle("/export/employees", readers(http.HandlerFunc(s.ExportEmployees))).Methods("GET")
+	api.Handle("/export/assets", readers(http.HandlerFunc(s.ExportAssets))).Methods("GET")
+	api.Handle("/export/mappings", readers(http.HandlerFunc(s.ExportMappings))).Methods("GET")
+
+	// Reports
+	api.Handle("/reports/assets", readers(http.HandlerFunc(s.AssetReport))).Methods("GET")
+	api.Handle("/reports/departments", readers(http.HandlerFunc(s.DepartmentReport))).Methods("GET")
+	api.Handle("/reports/assignment-duration", readers(http.HandlerFunc(s.AssignmentDurationReport))).Methods("GET")
+	api.Handle("/reports/top-holders", readers(http.HandlerFunc(s.TopHoldersReport))).Methods("GET")
+
+	// Maintenance
+	api.Handle("/admin/purge", admins(http.HandlerFunc(s.PurgeDeleted))).Methods("POST")
+	api.Handle("/audit", admins(http.HandlerFunc(s.GetAuditLog))).Methods("GET")
 
 }
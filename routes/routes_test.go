@@ -0,0 +1,177 @@
+package routes
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"employee-asset-system/controllers"
+	"employee-asset-system/db"
+	"employee-asset-system/middleware"
+	"employee-asset-system/models"
+	"employee-asset-system/utils"
+
+	"github.com/gorilla/mux"
+)
+
+// password is the password of every employee newTestRouter creates.
+const password = "correct horse"
+
+// newTestRouter returns the API routes over a MemoryStore holding one
+// employee per role, whose emp_id and email local part are the role name.
+func newTestRouter(t *testing.T) http.Handler {
+	t.Helper()
+	store := db.NewMemoryStore()
+	hash := utils.HashPassword(password)
+	now := time.Now()
+	for _, role := range []string{models.RoleAdmin, models.RoleITAssetManager, models.RoleManager, models.RoleEmployee} {
+		err := store.CreateEmployee(context.Background(), &models.Employee{
+			EmpID:         role,
+			FirstName:     role,
+			EmployeeEmail: role + "@example.com",
+			Password:      hash,
+			Roles:         []string{role},
+			CreatedAt:     now,
+			UpdatedAt:     now,
+		})
+		if err != nil {
+			t.Fatalf("CreateEmployee: %v", err)
+		}
+	}
+	keys, err := middleware.LoadKeySet("", "test secret")
+	if err != nil {
+		t.Fatalf("LoadKeySet: %v", err)
+	}
+	r := mux.NewRouter()
+	RegisterRoutes(r, controllers.NewServer(store, keys))
+	return r
+}
+
+// call sends a request with body to h, authenticated with the access token
+// when it is not empty.
+func call(h http.Handler, method, target, token, body string) *httptest.ResponseRecorder {
+	r := httptest.NewRequest(method, target, strings.NewReader(body))
+	r.Header.Set("Content-Type", "application/json")
+	if token != "" {
+		r.Header.Set("Authorization", "Bearer "+token)
+	}
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+	return w
+}
+
+// login signs in as the employee holding role.
+func login(t *testing.T, h http.Handler, role string) controllers.LoginResponse {
+	t.Helper()
+	w := call(h, "POST", "/login/auth", "", `{"identifier":"`+role+`@example.com","password":"`+password+`"}`)
+	if w.Code != http.StatusOK {
+		t.Fatalf("login as %s: status code = %d: %s", role, w.Code, w.Body)
+	}
+	var resp controllers.LoginResponse
+	if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+		t.Fatalf("decoding the login response: %v", err)
+	}
+	return resp
+}
+
+func TestRoleBasedAccess(t *testing.T) {
+	h := newTestRouter(t)
+	tokens := make(map[string]string)
+	for _, role := range []string{models.RoleAdmin, models.RoleITAssetManager, models.RoleManager, models.RoleEmployee} {
+		tokens[role] = login(t, h, role).Token
+	}
+
+	tests := []struct {
+		role   string // empty for no token
+		method string
+		target string
+		code   int
+	}{
+		{"", "GET", "/api/asset/getallasset", http.StatusUnauthorized},
+		{models.RoleEmployee, "GET", "/api/asset/getallasset", http.StatusForbidden},
+		{models.RoleManager, "GET", "/api/asset/getallasset", http.StatusOK},
+		{models.RoleEmployee, "GET", "/api/employee/employee/employee", http.StatusOK},
+		{models.RoleEmployee, "GET", "/api/employee/employee/manager", http.StatusForbidden},
+		{models.RoleManager, "GET", "/api/employee/employee/employee", http.StatusOK},
+		{models.RoleEmployee, "GET", "/api/mapping/getallassets/employee", http.StatusOK},
+		{models.RoleEmployee, "GET", "/api/mapping/getallassets/admin", http.StatusForbidden},
+		{models.RoleManager, "DELETE", "/api/asset/deleteasset/a1", http.StatusForbidden},
+		{models.RoleITAssetManager, "DELETE", "/api/asset/deleteasset/a1", http.StatusNotFound},
+		{models.RoleITAssetManager, "DELETE", "/api/employee/deleteemployee/employee", http.StatusForbidden},
+		{models.RoleITAssetManager, "GET", "/api/audit", http.StatusForbidden},
+		{models.RoleAdmin, "GET", "/api/audit", http.StatusOK},
+	}
+	for _, tt := range tests {
+		w := call(h, tt.method, tt.target, tokens[tt.role], "")
+		if w.Code != tt.code {
+			t.Errorf("%s %s as %q: status code = %d, want %d: %s", tt.method, tt.target, tt.role, w.Code, tt.code, w.Body)
+		}
+	}
+}
+
+// refresh exchanges refreshToken, returning the response and its status.
+func refresh(t *testing.T, h http.Handler, refreshToken string) (controllers.LoginResponse, int) {
+	t.Helper()
+	w := call(h, "POST", "/login/refresh", "", `{"refresh_token":"`+refreshToken+`"}`)
+	var resp controllers.LoginResponse
+	if w.Code == http.StatusOK {
+		if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+			t.Fatalf("decoding the refresh response: %v", err)
+		}
+	}
+	return resp, w.Code
+}
+
+func TestRefreshTokenRotation(t *testing.T) {
+	h := newTestRouter(t)
+	const self = "/api/employee/employee/employee"
+	first := login(t, h, models.RoleEmployee)
+
+	second, code := refresh(t, h, first.RefreshToken)
+	if code != http.StatusOK {
+		t.Fatalf("refresh: status code = %d, want %d", code, http.StatusOK)
+	}
+	if second.RefreshToken == first.RefreshToken {
+		t.Error("refresh returned the same refresh token")
+	}
+	if w := call(h, "GET", self, second.Token, ""); w.Code != http.StatusOK {
+		t.Fatalf("rotated access token: status code = %d, want %d", w.Code, http.StatusOK)
+	}
+
+	// Presenting the rotated token again ends the whole session.
+	if _, code := refresh(t, h, first.RefreshToken); code != http.StatusUnauthorized {
+		t.Errorf("reused refresh token: status code = %d, want %d", code, http.StatusUnauthorized)
+	}
+	if _, code := refresh(t, h, second.RefreshToken); code != http.StatusUnauthorized {
+		t.Errorf("refresh token of the revoked session: status code = %d, want %d", code, http.StatusUnauthorized)
+	}
+	if w := call(h, "GET", self, second.Token, ""); w.Code != http.StatusUnauthorized {
+		t.Errorf("access token of the revoked session: status code = %d, want %d", w.Code, http.StatusUnauthorized)
+	}
+
+	// Other sessions of the same employee are unaffected.
+	other := login(t, h, models.RoleEmployee)
+	if w := call(h, "GET", self, other.Token, ""); w.Code != http.StatusOK {
+		t.Errorf("access token of another session: status code = %d, want %d", w.Code, http.StatusOK)
+	}
+}
+
+func TestLogout(t *testing.T) {
+	h := newTestRouter(t)
+	session := login(t, h, models.RoleEmployee)
+
+	w := call(h, "POST", "/login/logout", "", `{"refresh_token":"`+session.RefreshToken+`"}`)
+	if w.Code != http.StatusOK {
+		t.Fatalf("logout: status code = %d, want %d: %s", w.Code, http.StatusOK, w.Body)
+	}
+	if w := call(h, "GET", "/api/employee/employee/employee", session.Token, ""); w.Code != http.StatusUnauthorized {
+		t.Errorf("access token after logout: status code = %d, want %d", w.Code, http.StatusUnauthorized)
+	}
+	if _, code := refresh(t, h, session.RefreshToken); code != http.StatusUnauthorized {
+		t.Errorf("refresh token after logout: status code = %d, want %d", code, http.StatusUnauthorized)
+	}
+}
@@ -0,0 +1,66 @@
+package utils
+
+import (
+	"html"
+	"strings"
+	"unicode"
+)
+
+// Highlight returns text, HTML escaped, with the words matching one of the
+// lower case search terms wrapped in <mark> tags, and whether any did. Words
+// are runs of letters and digits. A word matches a term equal to it or, in
+// prefix mode, one it starts with, and then only that start is marked.
+func Highlight(text string, terms []string, prefix bool) (string, bool) {
+	var b strings.Builder
+	matched := false
+	runes := []rune(text)
+	for i := 0; i < len(runes); {
+		if !isWordRune(runes[i]) {
+			j := i
+			for j < len(runes) && !isWordRune(runes[j]) {
+				j++
+			}
+			b.WriteString(html.EscapeString(string(runes[i:j])))
+			i = j
+			continue
+		}
+		j := i
+		for j < len(runes) && isWordRune(runes[j]) {
+			j++
+		}
+		word := runes[i:j]
+		if n := matchLength(word, terms, prefix); n > 0 {
+			if n > len(word) { // lower casing grew the word
+				n = len(word)
+			}
+			matched = true
+			b.WriteString("<mark>" + html.EscapeString(string(word[:n])) + "</mark>")
+			b.WriteString(html.EscapeString(string(word[n:])))
+		} else {
+			b.WriteString(html.EscapeString(string(word)))
+		}
+		i = j
+	}
+	return b.String(), matched
+}
+
+func isWordRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r)
+}
+
+// matchLength returns how many runes at the start of word match the longest
+// of terms, or 0 when none does.
+func matchLength(word []rune, terms []string, prefix bool) int {
+	lower := []rune(strings.ToLower(string(word)))
+	best := 0
+	for _, term := range terms {
+		t := []rune(term)
+		if len(t) > len(lower) || (!prefix && len(t) != len(lower)) {
+			continue
+		}
+		if string(lower[:len(t)]) == term && len(t) > best {
+			best = len(t)
+		}
+	}
+	return best
+}
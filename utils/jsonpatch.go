@@ -0,0 +1,298 @@
+package utils
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// Errors returned by MergePatch and JSONPatch. Any other error means the
+// patch was well formed but could not be applied to the document, such as
+// a path that does not exist.
+var (
+	ErrMalformedPatch  = errors.New("malformed patch document")
+	ErrPatchTestFailed = errors.New("patch test operation failed")
+)
+
+// MergePatch applies the JSON Merge Patch (RFC 7386) patch to the JSON
+// document doc: members of patch replace those of doc, recursively for
+// objects, and null members remove them.
+func MergePatch(doc, patch []byte) ([]byte, error) {
+	var target, p interface{}
+	if err := json.Unmarshal(doc, &target); err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(patch, &p); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrMalformedPatch, err)
+	}
+	return json.Marshal(mergePatch(target, p))
+}
+
+func mergePatch(target, patch interface{}) interface{} {
+	members, ok := patch.(map[string]interface{})
+	if !ok {
+		return patch
+	}
+	object, ok := target.(map[string]interface{})
+	if !ok {
+		object = map[string]interface{}{}
+	}
+	for name, value := range members {
+		if value == nil {
+			delete(object, name)
+		} else {
+			object[name] = mergePatch(object[name], value)
+		}
+	}
+	return object
+}
+
+// patchOperation is one operation of a JSON Patch document.
+type patchOperation struct {
+	Op    string          `json:"op"`
+	Path  *string         `json:"path"`
+	From  *string         `json:"from"`
+	Value json.RawMessage `json:"value"`
+}
+
+// JSONPatch applies the JSON Patch (RFC 6902) patch to the JSON document
+// doc. The operations are applied in order and the patch fails as a whole
+// if any of them fails; a failed test operation fails with
+// ErrPatchTestFailed.
+func JSONPatch(doc, patch []byte) ([]byte, error) {
+	var target interface{}
+	if err := json.Unmarshal(doc, &target); err != nil {
+		return nil, err
+	}
+	var operations []patchOperation
+	if err := json.Unmarshal(patch, &operations); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrMalformedPatch, err)
+	}
+
+	for i, op := range operations {
+		var err error
+		if target, err = applyOperation(target, op); err != nil {
+			return nil, fmt.Errorf("operation %d (%s): %w", i, op.Op, err)
+		}
+	}
+	return json.Marshal(target)
+}
+
+func applyOperation(doc interface{}, op patchOperation) (interface{}, error) {
+	if op.Path == nil {
+		return nil, fmt.Errorf("%w: missing path", ErrMalformedPatch)
+	}
+	path, err := parsePointer(*op.Path)
+	if err != nil {
+		return nil, err
+	}
+
+	var value, from interface{}
+	switch op.Op {
+	case "add", "replace", "test":
+		if op.Value == nil {
+			return nil, fmt.Errorf("%w: missing value", ErrMalformedPatch)
+		}
+		if err := json.Unmarshal(op.Value, &value); err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrMalformedPatch, err)
+		}
+	case "move", "copy":
+		if op.From == nil {
+			return nil, fmt.Errorf("%w: missing from", ErrMalformedPatch)
+		}
+		fromPath, err := parsePointer(*op.From)
+		if err != nil {
+			return nil, err
+		}
+		if op.Op == "move" && isPrefix(fromPath, path) && len(fromPath) < len(path) {
+			return nil, fmt.Errorf("cannot move %q into one of its children", *op.From)
+		}
+		if from, err = get(doc, fromPath); err != nil {
+			return nil, err
+		}
+		if op.Op == "move" {
+			if doc, _, err = remove(doc, fromPath); err != nil {
+				return nil, err
+			}
+		} else {
+			from = deepCopy(from)
+		}
+	}
+
+	switch op.Op {
+	case "add":
+		return add(doc, path, value)
+	case "remove":
+		doc, _, err = remove(doc, path)
+		return doc, err
+	case "replace":
+		if doc, _, err = remove(doc, path); err != nil {
+			return nil, err
+		}
+		return add(doc, path, value)
+	case "move", "copy":
+		return add(doc, path, from)
+	case "test":
+		current, err := get(doc, path)
+		if err != nil {
+			return nil, err
+		}
+		if !reflect.DeepEqual(current, value) {
+			return nil, fmt.Errorf("%w: %q does not match", ErrPatchTestFailed, *op.Path)
+		}
+		return doc, nil
+	default:
+		return nil, fmt.Errorf("%w: unknown op %q", ErrMalformedPatch, op.Op)
+	}
+}
+
+// parsePointer splits a JSON Pointer (RFC 6901) into its unescaped
+// reference tokens.
+func parsePointer(pointer string) ([]string, error) {
+	if pointer == "" {
+		return nil, nil
+	}
+	if !strings.HasPrefix(pointer, "/") {
+		return nil, fmt.Errorf("%w: path %q must start with /", ErrMalformedPatch, pointer)
+	}
+	tokens := strings.Split(pointer[1:], "/")
+	for i, token := range tokens {
+		tokens[i] = strings.NewReplacer("~1", "/", "~0", "~").Replace(token)
+	}
+	return tokens, nil
+}
+
+func isPrefix(prefix, path []string) bool {
+	return len(prefix) <= len(path) && reflect.DeepEqual(prefix, path[:len(prefix)])
+}
+
+// arrayIndex parses token as an index into an array of length n. end allows
+// the index n itself, which "add" uses to append.
+func arrayIndex(token string, n int, end bool) (int, error) {
+	if token == "-" && end {
+		return n, nil
+	}
+	i, err := strconv.Atoi(token)
+	if err != nil || i < 0 || i > n || (i == n && !end) || (len(token) > 1 && token[0] == '0') {
+		return 0, fmt.Errorf("array index %q is out of range", token)
+	}
+	return i, nil
+}
+
+func get(doc interface{}, path []string) (interface{}, error) {
+	for _, token := range path {
+		switch node := doc.(type) {
+		case map[string]interface{}:
+			child, ok := node[token]
+			if !ok {
+				return nil, fmt.Errorf("path member %q does not exist", token)
+			}
+			doc = child
+		case []interface{}:
+			i, err := arrayIndex(token, len(node), false)
+			if err != nil {
+				return nil, err
+			}
+			doc = node[i]
+		default:
+			return nil, fmt.Errorf("path member %q does not exist", token)
+		}
+	}
+	return doc, nil
+}
+
+// add sets the member of doc at path to value and returns the updated
+// document. Arrays grow to make room for the new element.
+func add(doc interface{}, path []string, value interface{}) (interface{}, error) {
+	if len(path) == 0 {
+		return value, nil
+	}
+	token, rest := path[0], path[1:]
+	switch node := doc.(type) {
+	case map[string]interface{}:
+		if len(rest) == 0 {
+			node[token] = value
+			return node, nil
+		}
+		child, ok := node[token]
+		if !ok {
+			return nil, fmt.Errorf("path member %q does not exist", token)
+		}
+		child, err := add(child, rest, value)
+		if err != nil {
+			return nil, err
+		}
+		node[token] = child
+		return node, nil
+	case []interface{}:
+		i, err := arrayIndex(token, len(node), len(rest) == 0)
+		if err != nil {
+			return nil, err
+		}
+		if len(rest) == 0 {
+			node = append(node, nil)
+			copy(node[i+1:], node[i:])
+			node[i] = value
+			return node, nil
+		}
+		if node[i], err = add(node[i], rest, value); err != nil {
+			return nil, err
+		}
+		return node, nil
+	default:
+		return nil, fmt.Errorf("path member %q does not exist", token)
+	}
+}
+
+// remove deletes the member of doc at path and returns the updated document
+// along with the removed value.
+func remove(doc interface{}, path []string) (interface{}, interface{}, error) {
+	if len(path) == 0 {
+		return nil, doc, nil
+	}
+	token, rest := path[0], path[1:]
+	switch node := doc.(type) {
+	case map[string]interface{}:
+		child, ok := node[token]
+		if !ok {
+			return nil, nil, fmt.Errorf("path member %q does not exist", token)
+		}
+		if len(rest) == 0 {
+			delete(node, token)
+			return node, child, nil
+		}
+		child, removed, err := remove(child, rest)
+		if err != nil {
+			return nil, nil, err
+		}
+		node[token] = child
+		return node, removed, nil
+	case []interface{}:
+		i, err := arrayIndex(token, len(node), false)
+		if err != nil {
+			return nil, nil, err
+		}
+		if len(rest) == 0 {
+			removed := node[i]
+			return append(node[:i], node[i+1:]...), removed, nil
+		}
+		child, removed, err := remove(node[i], rest)
+		if err != nil {
+			return nil, nil, err
+		}
+		node[i] = child
+		return node, removed, nil
+	default:
+		return nil, nil, fmt.Errorf("path member %q does not exist", token)
+	}
+}
+
+func deepCopy(value interface{}) interface{} {
+	data, _ := json.Marshal(value)
+	var out interface{}
+	json.Unmarshal(data, &out)
+	return out
+}
@@ -0,0 +1,114 @@
+package utils
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+// jsonEqual reports whether a and b hold the same JSON value.
+func jsonEqual(t *testing.T, a, b []byte) bool {
+	t.Helper()
+	var x, y interface{}
+	if err := json.Unmarshal(a, &x); err != nil {
+		t.Fatalf("invalid JSON %s: %v", a, err)
+	}
+	if err := json.Unmarshal(b, &y); err != nil {
+		t.Fatalf("invalid JSON %s: %v", b, err)
+	}
+	return reflect.DeepEqual(x, y)
+}
+
+func TestMergePatch(t *testing.T) {
+	tests := []struct {
+		name, doc, patch, want string
+	}{
+		{"replace member", `{"a":"b"}`, `{"a":"c"}`, `{"a":"c"}`},
+		{"add member", `{"a":"b"}`, `{"b":"c"}`, `{"a":"b","b":"c"}`},
+		{"remove member", `{"a":"b","b":"c"}`, `{"a":null}`, `{"b":"c"}`},
+		{"replace array", `{"a":["b"]}`, `{"a":["c","d"]}`, `{"a":["c","d"]}`},
+		{"nested objects", `{"a":{"b":"c","d":"e"}}`, `{"a":{"b":null,"f":"g"}}`, `{"a":{"d":"e","f":"g"}}`},
+		{"object over scalar", `{"a":"b"}`, `{"a":{"c":null,"d":"e"}}`, `{"a":{"d":"e"}}`},
+		{"non-object patch", `{"a":"b"}`, `["c"]`, `["c"]`},
+		{"empty patch", `{"a":"b"}`, `{}`, `{"a":"b"}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := MergePatch([]byte(tt.doc), []byte(tt.patch))
+			if err != nil {
+				t.Fatalf("MergePatch: %v", err)
+			}
+			if !jsonEqual(t, got, []byte(tt.want)) {
+				t.Errorf("MergePatch = %s, want %s", got, tt.want)
+			}
+		})
+	}
+
+	if _, err := MergePatch([]byte(`{}`), []byte(`{`)); !errors.Is(err, ErrMalformedPatch) {
+		t.Errorf("MergePatch with a truncated patch: err = %v, want ErrMalformedPatch", err)
+	}
+}
+
+func TestJSONPatch(t *testing.T) {
+	tests := []struct {
+		name, doc, patch, want string
+		err                    error // wanted error; any error when want is empty
+	}{
+		{name: "add member", doc: `{"a":1}`, patch: `[{"op":"add","path":"/b","value":2}]`, want: `{"a":1,"b":2}`},
+		{name: "add replaces member", doc: `{"a":1}`, patch: `[{"op":"add","path":"/a","value":2}]`, want: `{"a":2}`},
+		{name: "add array item", doc: `{"a":[1,3]}`, patch: `[{"op":"add","path":"/a/1","value":2}]`, want: `{"a":[1,2,3]}`},
+		{name: "append array item", doc: `{"a":[1]}`, patch: `[{"op":"add","path":"/a/-","value":2}]`, want: `{"a":[1,2]}`},
+		{name: "replace whole document", doc: `{"a":1}`, patch: `[{"op":"add","path":"","value":[1]}]`, want: `[1]`},
+		{name: "remove member", doc: `{"a":1,"b":2}`, patch: `[{"op":"remove","path":"/a"}]`, want: `{"b":2}`},
+		{name: "remove array item", doc: `{"a":[1,2,3]}`, patch: `[{"op":"remove","path":"/a/1"}]`, want: `{"a":[1,3]}`},
+		{name: "replace member", doc: `{"a":{"b":1}}`, patch: `[{"op":"replace","path":"/a/b","value":"x"}]`, want: `{"a":{"b":"x"}}`},
+		{name: "move member", doc: `{"a":{"b":1},"c":{}}`, patch: `[{"op":"move","from":"/a/b","path":"/c/d"}]`, want: `{"a":{},"c":{"d":1}}`},
+		{name: "move array item", doc: `[1,2,3]`, patch: `[{"op":"move","from":"/0","path":"/-"}]`, want: `[2,3,1]`},
+		{name: "copy member", doc: `{"a":{"b":[1]}}`, patch: `[{"op":"copy","from":"/a","path":"/c"},{"op":"add","path":"/c/b/-","value":2}]`, want: `{"a":{"b":[1]},"c":{"b":[1,2]}}`},
+		{name: "test then replace", doc: `{"a":"x"}`, patch: `[{"op":"test","path":"/a","value":"x"},{"op":"replace","path":"/a","value":"y"}]`, want: `{"a":"y"}`},
+		{name: "escaped pointer", doc: `{"a/b":1,"c~d":2}`, patch: `[{"op":"remove","path":"/a~1b"},{"op":"replace","path":"/c~0d","value":3}]`, want: `{"c~d":3}`},
+
+		{name: "failed test", doc: `{"a":"x"}`, patch: `[{"op":"test","path":"/a","value":"y"}]`, err: ErrPatchTestFailed},
+		{name: "unknown op", doc: `{}`, patch: `[{"op":"merge","path":"/a"}]`, err: ErrMalformedPatch},
+		{name: "missing path", doc: `{}`, patch: `[{"op":"remove"}]`, err: ErrMalformedPatch},
+		{name: "missing value", doc: `{}`, patch: `[{"op":"add","path":"/a"}]`, err: ErrMalformedPatch},
+		{name: "relative path", doc: `{}`, patch: `[{"op":"add","path":"a","value":1}]`, err: ErrMalformedPatch},
+		{name: "not an array", doc: `{}`, patch: `{"op":"add","path":"/a","value":1}`, err: ErrMalformedPatch},
+		{name: "remove missing member", doc: `{}`, patch: `[{"op":"remove","path":"/a"}]`},
+		{name: "index out of range", doc: `[1]`, patch: `[{"op":"add","path":"/5","value":2}]`},
+		{name: "add under missing parent", doc: `{}`, patch: `[{"op":"add","path":"/a/b","value":1}]`},
+		{name: "move into own child", doc: `{"a":{}}`, patch: `[{"op":"move","from":"/a","path":"/a/b"}]`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := JSONPatch([]byte(tt.doc), []byte(tt.patch))
+			if tt.want == "" {
+				if err == nil {
+					t.Fatalf("JSONPatch = %s, want an error", got)
+				}
+				if tt.err != nil && !errors.Is(err, tt.err) {
+					t.Errorf("JSONPatch: err = %v, want %v", err, tt.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("JSONPatch: %v", err)
+			}
+			if !jsonEqual(t, got, []byte(tt.want)) {
+				t.Errorf("JSONPatch = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestJSONPatchIsAllOrNothing(t *testing.T) {
+	doc := []byte(`{"a":1}`)
+	_, err := JSONPatch(doc, []byte(`[{"op":"replace","path":"/a","value":2},{"op":"remove","path":"/b"}]`))
+	if err == nil {
+		t.Fatal("JSONPatch succeeded with a failing operation")
+	}
+	if string(doc) != `{"a":1}` {
+		t.Errorf("JSONPatch modified its input to %s", doc)
+	}
+}
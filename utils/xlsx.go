@@ -0,0 +1,142 @@
+package utils
+
+import (
+	"archive/zip"
+	"bufio"
+	"encoding/xml"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// XLSXMediaType is the media type of XLSX workbooks.
+const XLSXMediaType = "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+
+// xlsxParts are the fixed parts of a workbook with a single worksheet.
+var xlsxParts = []struct{ name, content string }{
+	{"[Content_Types].xml", `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types">` +
+		`<Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/>` +
+		`<Default Extension="xml" ContentType="application/xml"/>` +
+		`<Override PartName="/xl/workbook.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.sheet.main+xml"/>` +
+		`<Override PartName="/xl/worksheets/sheet1.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.worksheet+xml"/>` +
+		`</Types>`},
+	{"_rels/.rels", `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+		`<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="xl/workbook.xml"/>` +
+		`</Relationships>`},
+	{"xl/_rels/workbook.xml.rels", `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+		`<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet1.xml"/>` +
+		`</Relationships>`},
+}
+
+// XLSXWriter writes rows to a single worksheet XLSX workbook as they come,
+// so that a workbook of any size can be streamed without holding it in
+// memory. Text is written as inline strings, which spreadsheets never
+// evaluate as formulas.
+type XLSXWriter struct {
+	// Numeric marks the columns written as numbers rather than text. Cells
+	// in them that are empty or do not parse as a number are written as
+	// text all the same.
+	Numeric []bool
+
+	zip   *zip.Writer
+	sheet *bufio.Writer
+	err   error
+}
+
+// NewXLSXWriter starts a workbook on w with one worksheet named sheet.
+func NewXLSXWriter(w io.Writer, sheet string) (*XLSXWriter, error) {
+	z := zip.NewWriter(w)
+	for _, part := range xlsxParts {
+		if err := writeZipPart(z, part.name, part.content); err != nil {
+			return nil, err
+		}
+	}
+	workbook := `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships">` +
+		`<sheets><sheet name="` + xmlEscape(sheet) + `" sheetId="1" r:id="rId1"/></sheets></workbook>`
+	if err := writeZipPart(z, "xl/workbook.xml", workbook); err != nil {
+		return nil, err
+	}
+
+	part, err := z.Create("xl/worksheets/sheet1.xml")
+	if err != nil {
+		return nil, err
+	}
+	x := &XLSXWriter{zip: z, sheet: bufio.NewWriter(part)}
+	x.sheet.WriteString(`<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><sheetData>`)
+	return x, nil
+}
+
+func writeZipPart(z *zip.Writer, name, content string) error {
+	part, err := z.Create(name)
+	if err != nil {
+		return err
+	}
+	_, err = io.WriteString(part, content)
+	return err
+}
+
+// Write appends a row. Errors are sticky and also returned by Flush and
+// Close.
+func (x *XLSXWriter) Write(record []string) error {
+	if x.err != nil {
+		return x.err
+	}
+	x.sheet.WriteString("<row>")
+	for i, value := range record {
+		if i < len(x.Numeric) && x.Numeric[i] && isNumber(value) {
+			x.sheet.WriteString("<c><v>" + value + "</v></c>")
+		} else {
+			x.sheet.WriteString(`<c t="inlineStr"><is><t xml:space="preserve">` + xmlEscape(value) + "</t></is></c>")
+		}
+	}
+	_, x.err = x.sheet.WriteString("</row>")
+	return x.err
+}
+
+// Flush writes the rows buffered so far to the underlying writer.
+func (x *XLSXWriter) Flush() error {
+	if x.err == nil {
+		x.err = x.sheet.Flush()
+	}
+	if x.err == nil {
+		x.err = x.zip.Flush()
+	}
+	return x.err
+}
+
+// Close ends the worksheet and the workbook. It does not close the
+// underlying writer.
+func (x *XLSXWriter) Close() error {
+	if x.err != nil {
+		return x.err
+	}
+	x.sheet.WriteString("</sheetData></worksheet>")
+	if x.err = x.sheet.Flush(); x.err != nil {
+		return x.err
+	}
+	x.err = x.zip.Close()
+	return x.err
+}
+
+func isNumber(value string) bool {
+	for _, r := range value {
+		if (r < '0' || r > '9') && r != '.' && r != '-' && r != 'e' && r != 'E' && r != '+' {
+			return false
+		}
+	}
+	_, err := strconv.ParseFloat(value, 64)
+	return value != "" && err == nil
+}
+
+// xmlEscape escapes s for XML text and attributes, replacing the characters
+// XML cannot hold.
+func xmlEscape(s string) string {
+	var b strings.Builder
+	xml.EscapeText(&b, []byte(s))
+	return b.String()
+}
@@ -0,0 +1,149 @@
+package utils
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"io"
+	"reflect"
+	"testing"
+)
+
+// xlsxCell is a cell of the worksheet XML that XLSXWriter writes.
+type xlsxCell struct {
+	Type   string `xml:"t,attr"`
+	Value  string `xml:"v"`
+	Inline string `xml:"is>t"`
+}
+
+// readXLSX opens the workbook in data and returns the names of its parts and
+// the rows of its worksheet.
+func readXLSX(t *testing.T, data []byte) ([]string, [][]xlsxCell) {
+	t.Helper()
+	z, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatalf("not a zip archive: %v", err)
+	}
+	var names []string
+	var sheet []byte
+	for _, f := range z.File {
+		names = append(names, f.Name)
+		rc, err := f.Open()
+		if err != nil {
+			t.Fatalf("open %s: %v", f.Name, err)
+		}
+		content, err := io.ReadAll(rc)
+		rc.Close()
+		if err != nil {
+			t.Fatalf("read %s: %v", f.Name, err)
+		}
+		var probe interface{}
+		if err := xml.Unmarshal(content, &probe); err != nil {
+			t.Errorf("%s is not well formed XML: %v", f.Name, err)
+		}
+		if f.Name == "xl/worksheets/sheet1.xml" {
+			sheet = content
+		}
+	}
+
+	var worksheet struct {
+		Rows []struct {
+			Cells []xlsxCell `xml:"c"`
+		} `xml:"sheetData>row"`
+	}
+	if err := xml.Unmarshal(sheet, &worksheet); err != nil {
+		t.Fatalf("worksheet: %v", err)
+	}
+	rows := make([][]xlsxCell, len(worksheet.Rows))
+	for i, row := range worksheet.Rows {
+		rows[i] = row.Cells
+	}
+	return names, rows
+}
+
+func TestXLSXWriter(t *testing.T) {
+	text := func(s string) xlsxCell { return xlsxCell{Type: "inlineStr", Inline: s} }
+	number := func(s string) xlsxCell { return xlsxCell{Value: s} }
+
+	tests := []struct {
+		name    string
+		numeric []bool
+		records [][]string
+		want    [][]xlsxCell
+	}{
+		{
+			name:    "text only",
+			records: [][]string{{"id", "name"}, {"1", "Ada"}},
+			want:    [][]xlsxCell{{text("id"), text("name")}, {text("1"), text("Ada")}},
+		},
+		{
+			name:    "numeric columns",
+			numeric: []bool{false, true},
+			records: [][]string{{"a", "1.5"}, {"b", "-2e3"}},
+			want:    [][]xlsxCell{{text("a"), number("1.5")}, {text("b"), number("-2e3")}},
+		},
+		{
+			name:    "non-numbers in numeric columns stay text",
+			numeric: []bool{true, true, true},
+			records: [][]string{{"", "cost", "NaN"}},
+			want:    [][]xlsxCell{{text(""), text("cost"), text("NaN")}},
+		},
+		{
+			name:    "escaped text",
+			records: [][]string{{`<b>&"x"`, "=SUM(A1)", "  padded  "}},
+			want:    [][]xlsxCell{{text(`<b>&"x"`), text("=SUM(A1)"), text("  padded  ")}},
+		},
+		{
+			name:    "ragged rows",
+			numeric: []bool{true},
+			records: [][]string{{"1"}, {"2", "x", "y"}, {}},
+			want:    [][]xlsxCell{{number("1")}, {number("2"), text("x"), text("y")}, nil},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			x, err := NewXLSXWriter(&buf, "Sheet & <1>")
+			if err != nil {
+				t.Fatalf("NewXLSXWriter: %v", err)
+			}
+			x.Numeric = tt.numeric
+			for _, record := range tt.records {
+				if err := x.Write(record); err != nil {
+					t.Fatalf("Write: %v", err)
+				}
+			}
+			if err := x.Close(); err != nil {
+				t.Fatalf("Close: %v", err)
+			}
+
+			names, rows := readXLSX(t, buf.Bytes())
+			wantNames := []string{"[Content_Types].xml", "_rels/.rels", "xl/_rels/workbook.xml.rels",
+				"xl/workbook.xml", "xl/worksheets/sheet1.xml"}
+			if !reflect.DeepEqual(names, wantNames) {
+				t.Errorf("parts = %v, want %v", names, wantNames)
+			}
+			if !reflect.DeepEqual(rows, tt.want) {
+				t.Errorf("rows = %+v, want %+v", rows, tt.want)
+			}
+		})
+	}
+}
+
+func TestXLSXWriterFlushStreams(t *testing.T) {
+	var buf bytes.Buffer
+	x, err := NewXLSXWriter(&buf, "Sheet1")
+	if err != nil {
+		t.Fatalf("NewXLSXWriter: %v", err)
+	}
+	if err := x.Write([]string{"a"}); err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+	before := buf.Len()
+	if err := x.Flush(); err != nil {
+		t.Fatalf("Flush: %v", err)
+	}
+	if buf.Len() <= before {
+		t.Errorf("Flush wrote nothing: %d bytes before, %d after", before, buf.Len())
+	}
+}